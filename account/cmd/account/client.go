@@ -5,10 +5,16 @@ import (
 	"errors"
 
 	"github.com/olujimiAdebakin/ProtoGraph/account/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 
+// ErrNotServing is returned by Health when the service answers but reports
+// it is not ready to serve.
+var ErrNotServing = errors.New("service is not serving")
+
 type Client struct {
 	conn    *grpc.ClientConn
 	service pb.AccountServiceClient
@@ -20,7 +26,7 @@ type Client struct {
 // Example usage:
 // client, err := account.NewClient("localhost:8080")
 func NewClient(url string)(*Client, error){
-	conn, err := grpc.Dial(url, grpc.WithInsecure())
+	conn, err := grpc.Dial(url, grpc.WithInsecure(), tracing.DialOption())
 	if err != nil {
 		return nil, errors.New("failed to connect to server: " + err.Error())
 	}
@@ -37,6 +43,21 @@ func (c *Client) Close() error {
 	return c.conn.Close()
 }
 
+// Health asks the gRPC health service of the account service whether it is
+// serving, for the gateway's /readyz.
+func (c *Client) Health(ctx context.Context) error {
+	res, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.AccountService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return ErrNotServing
+	}
+	return nil
+}
+
 
 func (c *Client) PostAccount(ctx context.Context, name, email, password string)(*pb.Account, error){
 	req, err := c.service.PostAccount(ctx, &pb.PostAccountRequest{
@@ -54,4 +75,65 @@ func (c *Client) PostAccount(ctx context.Context, name, email, password string)(
 		Name:     req.Account.Name,
 		Email:    req.Account.Email,
 	}, nil
-}
\ No newline at end of file
+}
+
+// GetAccount fetches a single account by ID
+func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error){
+	res, err := c.service.GetAccount(ctx, &pb.GetAccountRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{
+		ID:    res.Account.Id,
+		Name:  res.Account.Name,
+		Email: res.Account.Email,
+	}, nil
+}
+
+// ListAccounts fetches a page of accounts (skip = offset, take = limit)
+func (c *Client) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error){
+	res, err := c.service.ListAccounts(ctx, &pb.ListAccountsRequest{
+		Skip: skip,
+		Take: take,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []Account{}
+	for _, a := range res.Accounts {
+		accounts = append(accounts, Account{
+			ID:    a.Id,
+			Name:  a.Name,
+			Email: a.Email,
+		})
+	}
+	return accounts, nil
+}
+
+// GetAccountsByIDs fetches any number of accounts keyed by ID, in batches
+// of MaxBatchSize. IDs that do not exist are returned in notFound.
+func (c *Client) GetAccountsByIDs(ctx context.Context, ids []string) (accounts map[string]Account, notFound []string, err error){
+	accounts = map[string]Account{}
+
+	ids = uniqueIDs(ids)
+	for start := 0; start < len(ids); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(ids))
+
+		res, err := c.service.GetAccountsByIDs(ctx, &pb.GetAccountsByIDsRequest{Ids: ids[start:end]})
+		if err != nil {
+			return nil, nil, err
+		}
+		for id, a := range res.Accounts {
+			accounts[id] = Account{
+				ID:    a.Id,
+				Name:  a.Name,
+				Email: a.Email,
+			}
+		}
+		notFound = append(notFound, res.NotFoundIds...)
+	}
+
+	return accounts, notFound, nil
+}
@@ -2,26 +2,55 @@ package main
 
 
 import (
+	"context"
+	"errors"
 	"log"
 	"time"
 	"github.com/olujimiAdebakin/ProtoGraph/account"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/olujimiAdebakin/ProtoGraph/config"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/postgres"
+	"github.com/olujimiAdebakin/ProtoGraph/ratelimit"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
 	"github.com/avast/retry-go/v4"
 	_"github.com/tinrab/retry"
 )
 
 
 type Config struct{
-	DatabaseURL string `envconfig:"DATABASE_URL"`
+	Port        int    `envconfig:"PORT" default:"8080" validate:"port"`
+	DatabaseURL string `envconfig:"DATABASE_URL" validate:"required,url" secret:"true"`
+	MetricsPort int    `envconfig:"METRICS_PORT" default:"9090" validate:"port"`
+
+	// Connection pool and query timeouts, see DB_* variables
+	DB postgres.Config `envconfig:"DB"`
+
+	// Tracing is disabled unless TRACING_EXPORTER is set
+	Tracing tracing.Config `envconfig:"TRACING"`
+
+	// Per-caller token buckets, see RATE_LIMIT_* variables
+	RateLimit ratelimit.Config `envconfig:"RATE_LIMIT"`
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.Port == c.MetricsPort {
+		return errors.New("PORT and METRICS_PORT must differ")
+	}
+	return nil
 }
 
 
 func main(){
+	// Defaults < config file < environment < flags, validated before anything starts
 	var cfg Config
-	err := envconfig.Process("", &cfg)
+	config.MustLoad("account", &cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), "account", cfg.Tracing)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer shutdownTracing(context.Background())
 
 	var r account.AccountRepository
 	
@@ -29,7 +58,7 @@ func main(){
 	err = retry.Do(
 		func() error {
 			var err error
-			r, err = account.NewPostgresRepositry(cfg.DatabaseURL)
+			r, err = account.NewPostgresRepositry(cfg.DatabaseURL, cfg.DB)
 			if err != nil {
 				log.Printf("failed to connect to database: %v", err)
 			}
@@ -48,7 +77,18 @@ func main(){
 	
 	defer r.Close()
 
-	log.Println("Listening on port 8080......")
+	// Serve Prometheus metrics next to the gRPC API
+	go func() {
+		log.Fatal(metrics.ListenAndServe(cfg.MetricsPort))
+	}()
+
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limits := ratelimit.ServerOptions(limiter, cfg.RateLimit.TrustForwardedFor)
+
+	log.Printf("Listening on port %d......", cfg.Port)
 	s := account.NewService(r)
-	log.Fatal(account.ListenGRPCServer(s, 8080))
+	log.Fatal(account.ListenGRPCServer(s, cfg.Port, limits...))
 }
\ No newline at end of file
@@ -396,6 +396,106 @@ func (x *ListAccountsResponse) GetAccounts() []*Account {
 	return nil
 }
 
+// READ - Batch
+// At most 100 IDs per request; duplicates are ignored.
+type GetAccountsByIDsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountsByIDsRequest) Reset() {
+	*x = GetAccountsByIDsRequest{}
+	mi := &file_account_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountsByIDsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountsByIDsRequest) ProtoMessage() {}
+
+func (x *GetAccountsByIDsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountsByIDsRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountsByIDsRequest) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetAccountsByIDsRequest) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type GetAccountsByIDsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Found accounts keyed by ID
+	Accounts map[string]*Account `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Requested IDs that do not exist
+	NotFoundIds   []string `protobuf:"bytes,2,rep,name=not_found_ids,json=notFoundIds,proto3" json:"not_found_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountsByIDsResponse) Reset() {
+	*x = GetAccountsByIDsResponse{}
+	mi := &file_account_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountsByIDsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountsByIDsResponse) ProtoMessage() {}
+
+func (x *GetAccountsByIDsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_account_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountsByIDsResponse.ProtoReflect.Descriptor instead.
+func (*GetAccountsByIDsResponse) Descriptor() ([]byte, []int) {
+	return file_account_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetAccountsByIDsResponse) GetAccounts() map[string]*Account {
+	if x != nil {
+		return x.Accounts
+	}
+	return nil
+}
+
+func (x *GetAccountsByIDsResponse) GetNotFoundIds() []string {
+	if x != nil {
+		return x.NotFoundIds
+	}
+	return nil
+}
+
 // UPDATE
 type PutAccountRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -408,7 +508,7 @@ type PutAccountRequest struct {
 
 func (x *PutAccountRequest) Reset() {
 	*x = PutAccountRequest{}
-	mi := &file_account_proto_msgTypes[7]
+	mi := &file_account_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -420,7 +520,7 @@ func (x *PutAccountRequest) String() string {
 func (*PutAccountRequest) ProtoMessage() {}
 
 func (x *PutAccountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[7]
+	mi := &file_account_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -433,7 +533,7 @@ func (x *PutAccountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutAccountRequest.ProtoReflect.Descriptor instead.
 func (*PutAccountRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{7}
+	return file_account_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *PutAccountRequest) GetId() string {
@@ -466,7 +566,7 @@ type PutAccountResponse struct {
 
 func (x *PutAccountResponse) Reset() {
 	*x = PutAccountResponse{}
-	mi := &file_account_proto_msgTypes[8]
+	mi := &file_account_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -478,7 +578,7 @@ func (x *PutAccountResponse) String() string {
 func (*PutAccountResponse) ProtoMessage() {}
 
 func (x *PutAccountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[8]
+	mi := &file_account_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -491,7 +591,7 @@ func (x *PutAccountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PutAccountResponse.ProtoReflect.Descriptor instead.
 func (*PutAccountResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{8}
+	return file_account_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *PutAccountResponse) GetAccount() *Account {
@@ -511,7 +611,7 @@ type DeleteAccountRequest struct {
 
 func (x *DeleteAccountRequest) Reset() {
 	*x = DeleteAccountRequest{}
-	mi := &file_account_proto_msgTypes[9]
+	mi := &file_account_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -523,7 +623,7 @@ func (x *DeleteAccountRequest) String() string {
 func (*DeleteAccountRequest) ProtoMessage() {}
 
 func (x *DeleteAccountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[9]
+	mi := &file_account_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -536,7 +636,7 @@ func (x *DeleteAccountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteAccountRequest.ProtoReflect.Descriptor instead.
 func (*DeleteAccountRequest) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{9}
+	return file_account_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *DeleteAccountRequest) GetId() string {
@@ -558,7 +658,7 @@ type DeleteAccountResponse struct {
 
 func (x *DeleteAccountResponse) Reset() {
 	*x = DeleteAccountResponse{}
-	mi := &file_account_proto_msgTypes[10]
+	mi := &file_account_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -570,7 +670,7 @@ func (x *DeleteAccountResponse) String() string {
 func (*DeleteAccountResponse) ProtoMessage() {}
 
 func (x *DeleteAccountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_account_proto_msgTypes[10]
+	mi := &file_account_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -583,7 +683,7 @@ func (x *DeleteAccountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteAccountResponse.ProtoReflect.Descriptor instead.
 func (*DeleteAccountResponse) Descriptor() ([]byte, []int) {
-	return file_account_proto_rawDescGZIP(), []int{10}
+	return file_account_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *DeleteAccountResponse) GetSuccess() bool {
@@ -642,7 +742,15 @@ const file_account_proto_rawDesc = "" +
 	"\x04skip\x18\x01 \x01(\x04R\x04skip\x12\x12\n" +
 	"\x04take\x18\x02 \x01(\x04R\x04take\"?\n" +
 	"\x14ListAccountsResponse\x12'\n" +
-	"\baccounts\x18\x01 \x03(\v2\v.pb.AccountR\baccounts\"M\n" +
+	"\baccounts\x18\x01 \x03(\v2\v.pb.AccountR\baccounts\"+\n" +
+	"\x17GetAccountsByIDsRequest\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\"\xd0\x01\n" +
+	"\x18GetAccountsByIDsResponse\x12F\n" +
+	"\baccounts\x18\x01 \x03(\v2*.pb.GetAccountsByIDsResponse.AccountsEntryR\baccounts\x12\"\n" +
+	"\rnot_found_ids\x18\x02 \x03(\tR\vnotFoundIds\x1aH\n" +
+	"\rAccountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12!\n" +
+	"\x05value\x18\x02 \x01(\v2\v.pb.AccountR\x05value:\x028\x01\"M\n" +
 	"\x11PutAccountRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
@@ -656,12 +764,13 @@ const file_account_proto_rawDesc = "" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12,\n" +
 	"\x12deleted_account_id\x18\x03 \x01(\tR\x10deletedAccountId\x12\x1d\n" +
 	"\n" +
-	"deleted_at\x18\x04 \x01(\tR\tdeletedAt2\xd3\x02\n" +
+	"deleted_at\x18\x04 \x01(\tR\tdeletedAt2\xa2\x03\n" +
 	"\x0eAccountService\x12>\n" +
 	"\vPostAccount\x12\x16.pb.PostAccountRequest\x1a\x17.pb.PostAccountResponse\x12;\n" +
 	"\n" +
 	"GetAccount\x12\x15.pb.GetAccountRequest\x1a\x16.pb.GetAccountResponse\x12A\n" +
-	"\fListAccounts\x12\x17.pb.ListAccountsRequest\x1a\x18.pb.ListAccountsResponse\x12;\n" +
+	"\fListAccounts\x12\x17.pb.ListAccountsRequest\x1a\x18.pb.ListAccountsResponse\x12M\n" +
+	"\x10GetAccountsByIDs\x12\x1b.pb.GetAccountsByIDsRequest\x1a\x1c.pb.GetAccountsByIDsResponse\x12;\n" +
 	"\n" +
 	"PutAccount\x12\x15.pb.PutAccountRequest\x1a\x16.pb.PutAccountResponse\x12D\n" +
 	"\rDeleteAccount\x12\x18.pb.DeleteAccountRequest\x1a\x19.pb.DeleteAccountResponseB#Z!ProtoGraph/account/cmd/account/pbb\x06proto3"
@@ -678,40 +787,47 @@ func file_account_proto_rawDescGZIP() []byte {
 	return file_account_proto_rawDescData
 }
 
-var file_account_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_account_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_account_proto_goTypes = []any{
-	(*Account)(nil),               // 0: pb.Account
-	(*PostAccountRequest)(nil),    // 1: pb.PostAccountRequest
-	(*PostAccountResponse)(nil),   // 2: pb.PostAccountResponse
-	(*GetAccountRequest)(nil),     // 3: pb.GetAccountRequest
-	(*GetAccountResponse)(nil),    // 4: pb.GetAccountResponse
-	(*ListAccountsRequest)(nil),   // 5: pb.ListAccountsRequest
-	(*ListAccountsResponse)(nil),  // 6: pb.ListAccountsResponse
-	(*PutAccountRequest)(nil),     // 7: pb.PutAccountRequest
-	(*PutAccountResponse)(nil),    // 8: pb.PutAccountResponse
-	(*DeleteAccountRequest)(nil),  // 9: pb.DeleteAccountRequest
-	(*DeleteAccountResponse)(nil), // 10: pb.DeleteAccountResponse
+	(*Account)(nil),                  // 0: pb.Account
+	(*PostAccountRequest)(nil),       // 1: pb.PostAccountRequest
+	(*PostAccountResponse)(nil),      // 2: pb.PostAccountResponse
+	(*GetAccountRequest)(nil),        // 3: pb.GetAccountRequest
+	(*GetAccountResponse)(nil),       // 4: pb.GetAccountResponse
+	(*ListAccountsRequest)(nil),      // 5: pb.ListAccountsRequest
+	(*ListAccountsResponse)(nil),     // 6: pb.ListAccountsResponse
+	(*GetAccountsByIDsRequest)(nil),  // 7: pb.GetAccountsByIDsRequest
+	(*GetAccountsByIDsResponse)(nil), // 8: pb.GetAccountsByIDsResponse
+	(*PutAccountRequest)(nil),        // 9: pb.PutAccountRequest
+	(*PutAccountResponse)(nil),       // 10: pb.PutAccountResponse
+	(*DeleteAccountRequest)(nil),     // 11: pb.DeleteAccountRequest
+	(*DeleteAccountResponse)(nil),    // 12: pb.DeleteAccountResponse
+	nil,                              // 13: pb.GetAccountsByIDsResponse.AccountsEntry
 }
 var file_account_proto_depIdxs = []int32{
 	0,  // 0: pb.PostAccountResponse.account:type_name -> pb.Account
 	0,  // 1: pb.GetAccountResponse.account:type_name -> pb.Account
 	0,  // 2: pb.ListAccountsResponse.accounts:type_name -> pb.Account
-	0,  // 3: pb.PutAccountResponse.account:type_name -> pb.Account
-	1,  // 4: pb.AccountService.PostAccount:input_type -> pb.PostAccountRequest
-	3,  // 5: pb.AccountService.GetAccount:input_type -> pb.GetAccountRequest
-	5,  // 6: pb.AccountService.ListAccounts:input_type -> pb.ListAccountsRequest
-	7,  // 7: pb.AccountService.PutAccount:input_type -> pb.PutAccountRequest
-	9,  // 8: pb.AccountService.DeleteAccount:input_type -> pb.DeleteAccountRequest
-	2,  // 9: pb.AccountService.PostAccount:output_type -> pb.PostAccountResponse
-	4,  // 10: pb.AccountService.GetAccount:output_type -> pb.GetAccountResponse
-	6,  // 11: pb.AccountService.ListAccounts:output_type -> pb.ListAccountsResponse
-	8,  // 12: pb.AccountService.PutAccount:output_type -> pb.PutAccountResponse
-	10, // 13: pb.AccountService.DeleteAccount:output_type -> pb.DeleteAccountResponse
-	9,  // [9:14] is the sub-list for method output_type
-	4,  // [4:9] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	13, // 3: pb.GetAccountsByIDsResponse.accounts:type_name -> pb.GetAccountsByIDsResponse.AccountsEntry
+	0,  // 4: pb.PutAccountResponse.account:type_name -> pb.Account
+	0,  // 5: pb.GetAccountsByIDsResponse.AccountsEntry.value:type_name -> pb.Account
+	1,  // 6: pb.AccountService.PostAccount:input_type -> pb.PostAccountRequest
+	3,  // 7: pb.AccountService.GetAccount:input_type -> pb.GetAccountRequest
+	5,  // 8: pb.AccountService.ListAccounts:input_type -> pb.ListAccountsRequest
+	7,  // 9: pb.AccountService.GetAccountsByIDs:input_type -> pb.GetAccountsByIDsRequest
+	9,  // 10: pb.AccountService.PutAccount:input_type -> pb.PutAccountRequest
+	11, // 11: pb.AccountService.DeleteAccount:input_type -> pb.DeleteAccountRequest
+	2,  // 12: pb.AccountService.PostAccount:output_type -> pb.PostAccountResponse
+	4,  // 13: pb.AccountService.GetAccount:output_type -> pb.GetAccountResponse
+	6,  // 14: pb.AccountService.ListAccounts:output_type -> pb.ListAccountsResponse
+	8,  // 15: pb.AccountService.GetAccountsByIDs:output_type -> pb.GetAccountsByIDsResponse
+	10, // 16: pb.AccountService.PutAccount:output_type -> pb.PutAccountResponse
+	12, // 17: pb.AccountService.DeleteAccount:output_type -> pb.DeleteAccountResponse
+	12, // [12:18] is the sub-list for method output_type
+	6,  // [6:12] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_account_proto_init() }
@@ -725,7 +841,7 @@ func file_account_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_account_proto_rawDesc), len(file_account_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,11 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AccountService_PostAccount_FullMethodName   = "/pb.AccountService/PostAccount"
-	AccountService_GetAccount_FullMethodName    = "/pb.AccountService/GetAccount"
-	AccountService_ListAccounts_FullMethodName  = "/pb.AccountService/ListAccounts"
-	AccountService_PutAccount_FullMethodName    = "/pb.AccountService/PutAccount"
-	AccountService_DeleteAccount_FullMethodName = "/pb.AccountService/DeleteAccount"
+	AccountService_PostAccount_FullMethodName      = "/pb.AccountService/PostAccount"
+	AccountService_GetAccount_FullMethodName       = "/pb.AccountService/GetAccount"
+	AccountService_ListAccounts_FullMethodName     = "/pb.AccountService/ListAccounts"
+	AccountService_GetAccountsByIDs_FullMethodName = "/pb.AccountService/GetAccountsByIDs"
+	AccountService_PutAccount_FullMethodName       = "/pb.AccountService/PutAccount"
+	AccountService_DeleteAccount_FullMethodName    = "/pb.AccountService/DeleteAccount"
 )
 
 // AccountServiceClient is the client API for AccountService service.
@@ -36,6 +37,8 @@ type AccountServiceClient interface {
 	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*GetAccountResponse, error)
 	// READ - Multiple
 	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	// READ - Batch
+	GetAccountsByIDs(ctx context.Context, in *GetAccountsByIDsRequest, opts ...grpc.CallOption) (*GetAccountsByIDsResponse, error)
 	// UPDATE
 	PutAccount(ctx context.Context, in *PutAccountRequest, opts ...grpc.CallOption) (*PutAccountResponse, error)
 	// DELETE
@@ -80,6 +83,16 @@ func (c *accountServiceClient) ListAccounts(ctx context.Context, in *ListAccount
 	return out, nil
 }
 
+func (c *accountServiceClient) GetAccountsByIDs(ctx context.Context, in *GetAccountsByIDsRequest, opts ...grpc.CallOption) (*GetAccountsByIDsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAccountsByIDsResponse)
+	err := c.cc.Invoke(ctx, AccountService_GetAccountsByIDs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *accountServiceClient) PutAccount(ctx context.Context, in *PutAccountRequest, opts ...grpc.CallOption) (*PutAccountResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(PutAccountResponse)
@@ -110,6 +123,8 @@ type AccountServiceServer interface {
 	GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
 	// READ - Multiple
 	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	// READ - Batch
+	GetAccountsByIDs(context.Context, *GetAccountsByIDsRequest) (*GetAccountsByIDsResponse, error)
 	// UPDATE
 	PutAccount(context.Context, *PutAccountRequest) (*PutAccountResponse, error)
 	// DELETE
@@ -133,6 +148,9 @@ func (UnimplementedAccountServiceServer) GetAccount(context.Context, *GetAccount
 func (UnimplementedAccountServiceServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListAccounts not implemented")
 }
+func (UnimplementedAccountServiceServer) GetAccountsByIDs(context.Context, *GetAccountsByIDsRequest) (*GetAccountsByIDsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAccountsByIDs not implemented")
+}
 func (UnimplementedAccountServiceServer) PutAccount(context.Context, *PutAccountRequest) (*PutAccountResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method PutAccount not implemented")
 }
@@ -214,6 +232,24 @@ func _AccountService_ListAccounts_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AccountService_GetAccountsByIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountsByIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccountServiceServer).GetAccountsByIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccountService_GetAccountsByIDs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccountServiceServer).GetAccountsByIDs(ctx, req.(*GetAccountsByIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AccountService_PutAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PutAccountRequest)
 	if err := dec(in); err != nil {
@@ -269,6 +305,10 @@ var AccountService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListAccounts",
 			Handler:    _AccountService_ListAccounts_Handler,
 		},
+		{
+			MethodName: "GetAccountsByIDs",
+			Handler:    _AccountService_GetAccountsByIDs_Handler,
+		},
 		{
 			MethodName: "PutAccount",
 			Handler:    _AccountService_PutAccount_Handler,
@@ -3,7 +3,12 @@ package account
 import (
     "context"
     "database/sql"
-    _ "github.com/lib/pq"
+    "time"
+
+    "github.com/lib/pq"
+
+    "github.com/olujimiAdebakin/ProtoGraph/postgres"
+    "github.com/olujimiAdebakin/ProtoGraph/tracing"
 
 )
 
@@ -17,6 +22,9 @@ type AccountRepository interface {
     // Fetch one account by ID
     GetAccountByID(ctx context.Context, id string) (*Account, error)
 
+    // Fetch every account whose ID is in ids; missing IDs are simply absent
+    GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error)
+
     // List accounts with pagination (skip = offset, take = limit)
     ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
 
@@ -54,26 +62,43 @@ type AccountRepository interface {
 // It provides all DB operations for accounts using PostgreSQL.
 type postgresRepositry struct{
 	db *sql.DB
+	timeout time.Duration
+
+    // Prepared once, used by every request
+	getAccountByID *sql.Stmt
 }
 
-// NewPostgresRepository connects to PostgreSQL and returns a repository instance.
-func NewPostgresRepositry(url string) (AccountRepository, error){
-	db, err := sql.Open("postgres", url)
+// getAccountByIDQuery is the hottest query of the service: the gateway
+// resolves the account of every order through it.
+const getAccountByIDQuery = "SELECT id, name, email FROM accounts WHERE id = $1"
+
+// NewPostgresRepository connects to PostgreSQL with the pool settings of cfg
+// and returns a repository instance.
+func NewPostgresRepositry(url string, cfg postgres.Config) (AccountRepository, error){
+	db, err := postgres.Open("account", url, cfg)
 	if err != nil {
 		return nil,err
 	}
 
-     // Verify connection
-	if err := db.Ping(); err != nil{
-		return nil,err
+	r := &postgresRepositry{db: db, timeout: cfg.QueryTimeout}
+
+	ctx, cancel := postgres.WithTimeout(context.Background(), cfg.QueryTimeout)
+	defer cancel()
+	err = postgres.Prepare(ctx, db, map[string]**sql.Stmt{
+		getAccountByIDQuery: &r.getAccountByID,
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	return &postgresRepositry{db: db}, nil
+	return r, nil
 }
 
 
 
 func (r *postgresRepositry) Close(){
+	r.getAccountByID.Close()
 	r.db.Close()
 }
 
@@ -82,8 +107,15 @@ func (r *postgresRepositry) Ping() error{
 }
 
 // PutAccount inserts or updates an account (UPSERT logic).
-func (r *postgresRepositry) PutAccount(ctx context.Context, a Account) error{
-	_, err := r.db.ExecContext(ctx, "INSERT INTO accounts (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email", a.ID, a.Name, a.Email)
+func (r *postgresRepositry) PutAccount(ctx context.Context, a Account) (err error){
+	const query = "INSERT INTO accounts (id, name, email) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, email = EXCLUDED.email"
+	ctx, span := tracing.StartDBSpan(ctx, "accounts", "PutAccount", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, a.ID, a.Name, a.Email)
     return err
 }
 
@@ -91,12 +123,18 @@ func (r *postgresRepositry) PutAccount(ctx context.Context, a Account) error{
 // It returns (*Account, nil) if found.
 // It returns (nil, nil) if no row exists.
 // It returns (nil, error) for DB errors.
-func (r *postgresRepositry) GetAccountByID(ctx context.Context, id string) (*Account, error){
+func (r *postgresRepositry) GetAccountByID(ctx context.Context, id string) (_ *Account, err error){
+	ctx, span := tracing.StartDBSpan(ctx, "accounts", "GetAccountByID", getAccountByIDQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
     // Create the struct that will hold the scanned DB values
        acc := &Account{}
 
         // Query the database
-	err := r.db.QueryRowContext(ctx, "SELECT id, name, email FROM accounts WHERE id = $1", id).Scan(&acc.ID, &acc.Name, &acc.Email)
+	err = r.getAccountByID.QueryRowContext(ctx, id).Scan(&acc.ID, &acc.Name, &acc.Email)
 
    // If no row found, return nil instead of error
         if err == sql.ErrNoRows {
@@ -112,9 +150,49 @@ func (r *postgresRepositry) GetAccountByID(ctx context.Context, id string) (*Acc
     return acc, nil
 }
 
+// GetAccountsByIDs fetches a batch of accounts in one round-trip.
+func (r *postgresRepositry) GetAccountsByIDs(ctx context.Context, ids []string) (_ []Account, err error){
+	const query = "SELECT id, name, email FROM accounts WHERE id = ANY($1)"
+	ctx, span := tracing.StartDBSpan(ctx, "accounts", "GetAccountsByIDs", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+    if err != nil {
+        return nil, err
+    }
+
+    defer rows.Close()
+
+    accounts := []Account{}
+
+    for rows.Next() {
+        a := Account{}
+        if err := rows.Scan(&a.ID, &a.Name, &a.Email); err != nil {
+            return nil, err
+        }
+        accounts = append(accounts, a)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return accounts, nil
+}
+
 // ListAccounts returns paginated accounts using LIMIT + OFFSET.
-func (r *postgresRepositry) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error){
-	rows, err := r.db.QueryContext(ctx, "SELECT id, name, email FROM accounts ORDER BY id OFFSER $1  LIMIT $2", skip, take)
+func (r *postgresRepositry) ListAccounts(ctx context.Context, skip uint64, take uint64) (_ []Account, err error){
+	const query = "SELECT id, name, email FROM accounts ORDER BY id OFFSET $1 LIMIT $2"
+	ctx, span := tracing.StartDBSpan(ctx, "accounts", "ListAccounts", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, skip, take)
 
     if err != nil {
         return nil, err
@@ -141,7 +219,14 @@ func (r *postgresRepositry) ListAccounts(ctx context.Context, skip uint64, take
 }
 
 // DeleteAccount removes an account by ID.
-func (r *postgresRepositry) DeleteAccount(ctx context.Context, id string) error{
-    _, err := r.db.ExecContext(ctx, "DELETE FROM accounts WHERE id = $1", id)
+func (r *postgresRepositry) DeleteAccount(ctx context.Context, id string) (err error){
+	const query = "DELETE FROM accounts WHERE id = $1"
+	ctx, span := tracing.StartDBSpan(ctx, "accounts", "DeleteAccount", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+    _, err = r.db.ExecContext(ctx, query, id)
     return err
 }
\ No newline at end of file
@@ -3,15 +3,22 @@ package account
 
 import (
 	"context"    // For context management (timeouts, cancellation)
+	"errors"
 	"fmt"        
 	"net"  
     "time"    
       
 
 	"google.golang.org/grpc"          
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection" 
+	"google.golang.org/grpc/status"
 
 	"github.com/olujimiAdebakin/ProtoGraphql/account/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
 )
 
 // grpcServer wraps the business logic service and implements gRPC methods
@@ -22,8 +29,9 @@ type grpcServer struct {
 // ListenGRPCServer starts a gRPC server on the specified port
 // service: Business logic implementation
 // port: TCP port to listen on (e.g., 50051)
+// opts: extra server options (e.g. rate limiting) appended after the built-in ones
 // Returns error if server fails to start
-func ListenGRPCServer(service Service, port int) error {
+func ListenGRPCServer(service Service, port int, opts ...grpc.ServerOption) error {
 	// Create TCP listener on specified port (e.g., ":50051")
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
@@ -32,12 +40,24 @@ func ListenGRPCServer(service Service, port int) error {
 	}
 
 	// Create new gRPC server instance
-	grpcSrv := grpc.NewServer()
+	// Interceptors record RPC latency and status codes for /metrics,
+	// the stats handler continues the caller's trace
+	grpcSrv := grpc.NewServer(append([]grpc.ServerOption{
+		tracing.ServerOption(),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor()),
+	}, opts...)...)
 	
 	// Register our gRPC server implementation with the gRPC framework
 	// This connects our grpcServer methods to the AccountService protobuf definition
 	pb.RegisterAccountServiceServer(grpcSrv, &grpcServer{service: service})
 	
+	// Report SERVING on the standard gRPC health service, probed by the
+	// gateway's /readyz and by load balancers
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pb.AccountService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+	
 	// Enable gRPC reflection - allows tools like grpcurl to discover API
 	reflection.Register(grpcSrv)
 	
@@ -124,6 +144,35 @@ func (s *grpcServer) ListAccounts(ctx context.Context, req *pb.ListAccountsReque
 	return resp, nil 
 }
 
+// GetAccountsByIDs handles batch account lookups via gRPC
+// ctx: Request context
+// req: Incoming request with up to MaxBatchSize account IDs
+// Returns: found accounts keyed by ID, every other requested ID in not_found_ids
+func (s *grpcServer) GetAccountsByIDs(ctx context.Context, req *pb.GetAccountsByIDsRequest) (*pb.GetAccountsByIDsResponse, error) {
+	accounts, err := s.service.GetAccountsByIDs(ctx, req.Ids)
+	if errors.Is(err, ErrTooManyIDs) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetAccountsByIDsResponse{Accounts: map[string]*pb.Account{}}
+	for id, account := range accounts {
+		resp.Accounts[id] = &pb.Account{
+			Id:    account.ID,    // Map ID
+			Name:  account.Name,  // Map name
+			Email: account.Email, // Map email
+		}
+	}
+	for _, id := range uniqueIDs(req.Ids) {
+		if _, ok := accounts[id]; !ok {
+			resp.NotFoundIds = append(resp.NotFoundIds, id)
+		}
+	}
+	return resp, nil
+}
+
 // DeleteAccount handles account deletion requests via gRPC
 // ctx: Request context
 // req: Incoming request with account ID to delete
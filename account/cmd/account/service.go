@@ -14,8 +14,12 @@ var (
 	ErrInvalidName  = errors.New("account name cannot be empty")
 	ErrInvalidEmail = errors.New("email cannot be empty")
 	ErrWeakPassword = errors.New("password must be at least 5 characters")
+	ErrTooManyIDs   = fmt.Errorf("at most %d IDs can be fetched at once", MaxBatchSize)
 )
 
+// MaxBatchSize caps how many accounts GetAccountsByIDs fetches per call.
+const MaxBatchSize = 100
+
 // Service defines the business operations related to accounts.
 type Service interface {
 	// PostAccount creates a new account with the given name, email, and password.
@@ -25,6 +29,10 @@ type Service interface {
 	// GetAccount fetches an account by its unique ID.
 	GetAccount(ctx context.Context, id string) (*Account, error)
 
+	// GetAccountsByIDs fetches up to MaxBatchSize accounts keyed by ID.
+	// IDs that do not exist are absent from the map.
+	GetAccountsByIDs(ctx context.Context, ids []string) (map[string]Account, error)
+
 	// ListAccounts returns a paginated list of accounts, skipping 'skip' and taking 'take' items.
 	ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
 
@@ -97,6 +105,28 @@ func (s *accountService) GetAccount(ctx context.Context, id string) (*Account, e
 	return s.repository.GetAccountByID(ctx, id)
 }
 
+// GetAccountsByIDs de-duplicates ids and fetches them in one query.
+func (s *accountService) GetAccountsByIDs(ctx context.Context, ids []string) (map[string]Account, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	accounts := map[string]Account{}
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+
+	found, err := s.repository.GetAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range found {
+		accounts[a.ID] = a
+	}
+	return accounts, nil
+}
+
 // ListAccounts provides a paginated list of accounts.
 // Caps the page size to 50 to prevent overloading.
 func (s *accountService) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
@@ -122,3 +152,17 @@ func (s *accountService) DeleteAccount(ctx context.Context, id string) (*Account
 	// 3. Return the deleted account
 	return acc, nil
 }
+
+// uniqueIDs drops empty and repeated IDs, keeping the first occurrence.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RoleAdmin may call every operation and passes every ownership check.
+const RoleAdmin = "ADMIN"
+
+// minSecretLength is the shortest HS256 secret accepted, 256 bits.
+const minSecretLength = 32
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrNoSecret     = errors.New("no token secret configured")
+)
+
+// Config holds the key tokens are signed with. The gateway embeds it as
+// `Auth auth.Config envconfig:"AUTH"`, so the variables are AUTH_JWT_SECRET
+// and AUTH_JWT_ISSUER.
+//
+// Without a secret no token verifies: every caller is anonymous and only
+// the operations open to anonymous callers work.
+type Config struct {
+	// Shared HS256 key, at least 32 bytes
+	Secret string `envconfig:"JWT_SECRET" secret:"true"`
+
+	// When set, tokens must carry it as their iss claim
+	Issuer string `envconfig:"JWT_ISSUER"`
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.Secret != "" && len(c.Secret) < minSecretLength {
+		return errors.New("AUTH_JWT_SECRET must be at least 32 bytes")
+	}
+	return nil
+}
+
+// Claims is what a token says about its bearer. Subject is the ID of the
+// caller's account in the account service.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Roles     []string `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// HasRole reports whether the bearer was granted role.
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier checks HS256 JSON Web Tokens signed with the configured secret.
+type Verifier struct {
+	secret []byte
+	issuer string
+	now    func() time.Time
+}
+
+// NewVerifier creates a Verifier for cfg.
+func NewVerifier(cfg Config) *Verifier {
+	return &Verifier{
+		secret: []byte(cfg.Secret),
+		issuer: cfg.Issuer,
+		now:    time.Now,
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Verify checks the signature and the time window of token and returns its
+// claims. Only HS256 is accepted, whatever the token header says otherwise.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	if len(v.secret) == 0 {
+		return nil, ErrNoSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, v.sign(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	now := v.now().Unix()
+	if c.ExpiresAt != 0 && now >= c.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now < c.NotBefore {
+		return nil, ErrInvalidToken
+	}
+
+	return &c, nil
+}
+
+// Sign issues a token carrying c, e.g. for the account service after a
+// login or for operators minting an admin token.
+func (v *Verifier) Sign(c Claims) (string, error) {
+	if len(v.secret) == 0 {
+		return "", ErrNoSecret
+	}
+	if c.Issuer == "" {
+		c.Issuer = v.issuer
+	}
+
+	h, err := encodeSegment(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	p, err := encodeSegment(c)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := h + "." + p
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(v.sign(unsigned)), nil
+}
+
+func (v *Verifier) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(seg string, out interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func encodeSegment(in interface{}) (string, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type claimsCtxKey struct{}
+
+// WithClaims returns a copy of ctx carrying the claims of the caller.
+func WithClaims(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey{}, c)
+}
+
+// FromContext returns the claims of the caller, or nil for anonymous callers.
+func FromContext(ctx context.Context) *Claims {
+	c, _ := ctx.Value(claimsCtxKey{}).(*Claims)
+	return c
+}
+
+// BearerToken extracts the token of an "Authorization: Bearer <token>"
+// header value; it returns "" for any other scheme.
+func BearerToken(authorization string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+var testNow = time.Unix(1700000000, 0)
+
+func testVerifier(issuer string) *Verifier {
+	v := NewVerifier(Config{Secret: testSecret, Issuer: issuer})
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+// forge builds a token with any header, signed with secret like HS256.
+func forge(t *testing.T, h header, c Claims, secret string) string {
+	t.Helper()
+	hs, err := encodeSegment(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := encodeSegment(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(hs + "." + cs))
+	return hs + "." + cs + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	now := testNow.Unix()
+	hs256 := header{Alg: "HS256", Typ: "JWT"}
+	valid := Claims{Subject: "account-1", Roles: []string{RoleAdmin}, ExpiresAt: now + 60, NotBefore: now - 60}
+
+	tests := []struct {
+		name   string
+		issuer string
+		token  func(t *testing.T) string
+		err    error
+	}{
+		{"valid", "", func(t *testing.T) string {
+			return forge(t, hs256, valid, testSecret)
+		}, nil},
+		{"without time window", "", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1"}, testSecret)
+		}, nil},
+		{"expired", "", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1", ExpiresAt: now - 1}, testSecret)
+		}, ErrTokenExpired},
+		{"expires now", "", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1", ExpiresAt: now}, testSecret)
+		}, ErrTokenExpired},
+		{"not yet valid", "", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1", NotBefore: now + 1}, testSecret)
+		}, ErrInvalidToken},
+		{"alg none", "", func(t *testing.T) string {
+			token := forge(t, header{Alg: "none"}, valid, testSecret)
+			return token[:strings.LastIndex(token, ".")+1]
+		}, ErrInvalidToken},
+		{"alg none with signature", "", func(t *testing.T) string {
+			return forge(t, header{Alg: "none"}, valid, testSecret)
+		}, ErrInvalidToken},
+		{"alg HS512", "", func(t *testing.T) string {
+			return forge(t, header{Alg: "HS512"}, valid, testSecret)
+		}, ErrInvalidToken},
+		{"alg RS256", "", func(t *testing.T) string {
+			return forge(t, header{Alg: "RS256"}, valid, testSecret)
+		}, ErrInvalidToken},
+		{"other secret", "", func(t *testing.T) string {
+			return forge(t, hs256, valid, strings.Repeat("x", len(testSecret)))
+		}, ErrInvalidToken},
+		{"tampered claims", "", func(t *testing.T) string {
+			token := forge(t, hs256, valid, testSecret)
+			parts := strings.Split(token, ".")
+			other, _ := encodeSegment(Claims{Subject: "account-2", Roles: []string{RoleAdmin}})
+			return parts[0] + "." + other + "." + parts[2]
+		}, ErrInvalidToken},
+		{"without subject", "", func(t *testing.T) string {
+			return forge(t, hs256, Claims{ExpiresAt: now + 60}, testSecret)
+		}, ErrInvalidToken},
+		{"malformed", "", func(t *testing.T) string {
+			return "not.a-token"
+		}, ErrInvalidToken},
+		{"issuer", "shop", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1", Issuer: "shop"}, testSecret)
+		}, nil},
+		{"wrong issuer", "shop", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1", Issuer: "other"}, testSecret)
+		}, ErrInvalidToken},
+		{"missing issuer", "shop", func(t *testing.T) string {
+			return forge(t, hs256, Claims{Subject: "account-1"}, testSecret)
+		}, ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := testVerifier(tt.issuer).Verify(tt.token(t))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.err)
+			}
+			if err == nil && c.Subject != "account-1" {
+				t.Errorf("Verify() subject = %q, want %q", c.Subject, "account-1")
+			}
+			if err != nil && c != nil {
+				t.Errorf("Verify() = %+v with error %v, want nil claims", c, err)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutSecret(t *testing.T) {
+	token := forge(t, header{Alg: "HS256"}, Claims{Subject: "account-1"}, "")
+	if _, err := NewVerifier(Config{}).Verify(token); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrNoSecret)
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	v := testVerifier("shop")
+	want := Claims{Subject: "account-1", Roles: []string{RoleAdmin}, ExpiresAt: testNow.Unix() + 60}
+
+	token, err := v.Sign(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify(Sign()) error = %v", err)
+	}
+	if got.Subject != want.Subject || got.Issuer != "shop" || !got.HasRole(RoleAdmin) || got.ExpiresAt != want.ExpiresAt {
+		t.Errorf("Verify(Sign(%+v)) = %+v", want, got)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		authorization, want string
+	}{
+		{"Bearer abc.def.ghi", "abc.def.ghi"},
+		{"bearer  abc.def.ghi ", "abc.def.ghi"},
+		{"Basic dXNlcjpwYXNz", ""},
+		{"abc.def.ghi", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := BearerToken(tt.authorization); got != tt.want {
+			t.Errorf("BearerToken(%q) = %q, want %q", tt.authorization, got, tt.want)
+		}
+	}
+}
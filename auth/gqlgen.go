@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// extensions.code of errors returned by the access directives.
+const (
+	UnauthenticatedCode = "UNAUTHENTICATED"
+	ForbiddenCode       = "FORBIDDEN"
+)
+
+// ErrNoOwner is returned by an OwnerFunc when the object does not exist.
+// There is nothing to protect then, and the resolver reports the missing object.
+var ErrNoOwner = errors.New("object has no owner")
+
+// OwnerFunc maps the ID found by @owner to the ID of the owning account.
+type OwnerFunc func(ctx context.Context, id string) (accountID string, err error)
+
+// Middleware verifies the bearer token of every HTTP request and stores its
+// claims in the request context for the directives. Requests without a
+// token run as anonymous; requests with a bad or expired token are refused
+// with 401 rather than silently downgraded.
+func Middleware(next http.Handler, v *Verifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		claims, err := v.verifyAuthorization(header)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// WebsocketInit authenticates subscriptions. Browsers cannot set headers on
+// a WebSocket, so the token comes in the Authorization field of the
+// connection_init payload; a bad token closes the connection.
+func WebsocketInit(v *Verifier) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		header := payload.Authorization()
+		if header == "" {
+			return ctx, nil, nil
+		}
+
+		claims, err := v.verifyAuthorization(header)
+		if err != nil {
+			return ctx, nil, err
+		}
+		return WithClaims(ctx, claims), nil, nil
+	}
+}
+
+func (v *Verifier) verifyAuthorization(header string) (*Claims, error) {
+	token := BearerToken(header)
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	return v.Verify(token)
+}
+
+// Authenticated implements the @authenticated directive.
+func Authenticated(ctx context.Context, obj any, next graphql.Resolver) (any, error) {
+	if FromContext(ctx) == nil {
+		return nil, unauthenticated()
+	}
+	return next(ctx)
+}
+
+// HasRole implements the @hasRole(role:) directive.
+func HasRole(ctx context.Context, obj any, next graphql.Resolver, role string) (any, error) {
+	claims := FromContext(ctx)
+	if claims == nil {
+		return nil, unauthenticated()
+	}
+	if !claims.HasRole(role) {
+		return nil, forbidden("requires role " + role)
+	}
+	return next(ctx)
+}
+
+// Owner implements the @owner(field:) directive. The ID is read from the
+// arguments of the field at the dotted path field, or from the parent
+// object when no argument matches, and owner maps it to the owning
+// account. A missing value fails closed; admins pass without a lookup.
+func Owner(ctx context.Context, obj any, next graphql.Resolver, field string, owner OwnerFunc) (any, error) {
+	claims := FromContext(ctx)
+	if claims == nil {
+		return nil, unauthenticated()
+	}
+	if claims.HasRole(RoleAdmin) {
+		return next(ctx)
+	}
+
+	path := strings.Split(field, ".")
+	var args any
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		args = fc.Args
+	}
+	id, ok := lookupPath(args, path)
+	if !ok {
+		id, ok = lookupPath(obj, path)
+	}
+	if !ok || id == "" {
+		return nil, forbidden("owner of the object is unknown")
+	}
+
+	accountID, err := owner(ctx, id)
+	if errors.Is(err, ErrNoOwner) {
+		return next(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if accountID != claims.Subject {
+		return nil, forbidden("only the owner may access this field")
+	}
+	return next(ctx)
+}
+
+// AuthorizeOwner is the @owner check for objects reached without a field
+// directive, such as through node(id:).
+func AuthorizeOwner(ctx context.Context, accountID string) error {
+	claims := FromContext(ctx)
+	if claims == nil {
+		return unauthenticated()
+	}
+	if claims.Subject != accountID && !claims.HasRole(RoleAdmin) {
+		return forbidden("only the owner may access this object")
+	}
+	return nil
+}
+
+// lookupPath follows path through v, where every step is a key of a map
+// (the field arguments) or the GraphQL name of a struct field, i.e. its
+// json tag (accountId for AccountID). It returns the string at the end.
+func lookupPath(v any, path []string) (string, bool) {
+	cur := reflect.ValueOf(v)
+	for _, name := range path {
+		for cur.Kind() == reflect.Ptr || cur.Kind() == reflect.Interface {
+			if cur.IsNil() {
+				return "", false
+			}
+			cur = cur.Elem()
+		}
+
+		switch cur.Kind() {
+		case reflect.Map:
+			if cur.Type().Key().Kind() != reflect.String {
+				return "", false
+			}
+			cur = cur.MapIndex(reflect.ValueOf(name))
+			if !cur.IsValid() {
+				return "", false
+			}
+		case reflect.Struct:
+			f, ok := structFieldByTag(cur, name)
+			if !ok {
+				return "", false
+			}
+			cur = f
+		default:
+			return "", false
+		}
+	}
+
+	for cur.Kind() == reflect.Ptr || cur.Kind() == reflect.Interface {
+		if cur.IsNil() {
+			return "", false
+		}
+		cur = cur.Elem()
+	}
+	if cur.Kind() != reflect.String {
+		return "", false
+	}
+	return cur.String(), true
+}
+
+func structFieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func unauthenticated() *gqlerror.Error {
+	err := gqlerror.Errorf("authentication required")
+	errcode.Set(err, UnauthenticatedCode)
+	return err
+}
+
+func forbidden(reason string) *gqlerror.Error {
+	err := gqlerror.Errorf("forbidden: %s", reason)
+	errcode.Set(err, ForbiddenCode)
+	return err
+}
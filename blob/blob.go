@@ -0,0 +1,182 @@
+// Package blob stores binary objects, such as product images, under
+// slash-separated keys, on the local filesystem or in an S3-compatible
+// object store.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Supported values for Config.Backend.
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("blob key must be a relative slash-separated path without . or .. segments")
+)
+
+// BlobStore keeps blobs under keys such as "products/<id>/<image>.jpg".
+// Implementations are safe for concurrent use.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing the blob
+	// stored there before.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the blob stored under key; it fails with ErrNotFound when
+	// there is none. The caller closes the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key. Deleting a missing blob is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+
+	// URL is the address clients download the blob from.
+	URL(key string) string
+}
+
+// Config selects and configures a BlobStore.
+// Services embed it as `Blob blob.Config envconfig:"BLOB"`, so the variables
+// are BLOB_BACKEND, BLOB_PUBLIC_URL, BLOB_DIR, BLOB_S3_ENDPOINT, ...
+type Config struct {
+	Backend string `envconfig:"BACKEND" default:"local" validate:"oneof=local|s3"`
+
+	// PublicURL prefixes the keys in the URLs handed to clients, e.g. a
+	// CDN in front of the store. Without it, S3 blobs are linked at the
+	// endpoint and local blobs are not linked at all.
+	PublicURL string `envconfig:"PUBLIC_URL" validate:"url"`
+
+	// Dir is the root directory of the local backend.
+	Dir string `envconfig:"DIR" default:"./media"`
+
+	// S3 configures the s3 backend, see BLOB_S3_* variables
+	S3 S3Config `envconfig:"S3"`
+}
+
+// S3Config points the s3 backend at a bucket of AWS S3 or of a compatible
+// store such as MinIO.
+type S3Config struct {
+	// Endpoint is the base URL of the store, e.g. https://s3.eu-west-1.amazonaws.com
+	// or http://localhost:9000 for a local MinIO
+	Endpoint        string `envconfig:"ENDPOINT" validate:"url"`
+	Region          string `envconfig:"REGION" default:"us-east-1"`
+	Bucket          string `envconfig:"BUCKET"`
+	AccessKeyID     string `envconfig:"ACCESS_KEY_ID"`
+	SecretAccessKey string `envconfig:"SECRET_ACCESS_KEY" secret:"true"`
+
+	// PathStyle addresses objects as endpoint/bucket/key instead of
+	// bucket.endpoint/key; local stand-ins usually need it.
+	PathStyle bool `envconfig:"PATH_STYLE" default:"true"`
+}
+
+// Validate implements config.Validator: the s3 backend needs a bucket to
+// write to and credentials to sign with.
+func (c *Config) Validate() error {
+	if c.Backend != BackendS3 {
+		return nil
+	}
+	if c.S3.Endpoint == "" || c.S3.Bucket == "" {
+		return errors.New("BLOB_S3_ENDPOINT and BLOB_S3_BUCKET are required for the s3 backend")
+	}
+	if c.S3.AccessKeyID == "" || c.S3.SecretAccessKey == "" {
+		return errors.New("BLOB_S3_ACCESS_KEY_ID and BLOB_S3_SECRET_ACCESS_KEY are required for the s3 backend")
+	}
+	return nil
+}
+
+// Open returns the BlobStore selected by cfg.
+func Open(cfg Config) (BlobStore, error) {
+	switch cfg.Backend {
+	case BackendS3:
+		return NewS3Store(cfg.S3, cfg.PublicURL)
+	case "", BackendLocal:
+		return NewLocalStore(cfg.Dir, cfg.PublicURL)
+	}
+	return nil, errors.New("unknown blob backend " + cfg.Backend)
+}
+
+// ValidKey reports whether key can name a blob in every backend.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// publicURL joins base and key, or returns "" without a base.
+func publicURL(base, key string) string {
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + escapePath(key)
+}
+
+// escapePath percent-encodes every byte of key outside the unreserved set
+// of RFC 3986, keeping the slashes; S3 signatures need exactly this form.
+func escapePath(key string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&15])
+	}
+	return b.String()
+}
+
+// Handler serves the blobs of store by key below the path it is mounted
+// at, e.g. GET /media/products/1/2.jpg with http.StripPrefix("/media/", ...).
+// Blobs never change under a key, so responses may be cached for a year.
+func Handler(store BlobStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if !ValidKey(key) {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := store.Get(r.Context(), key)
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			return
+		}
+		defer body.Close()
+
+		if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if r.Method == http.MethodGet {
+			io.Copy(w, body)
+		}
+	})
+}
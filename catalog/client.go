@@ -0,0 +1,1205 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// ErrNotServing is returned by Health when the service answers but reports
+// it is not ready to serve.
+var ErrNotServing = errors.New("service is not serving")
+
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.CatalogServiceClient
+}
+
+// NewClient creates a new gRPC client for the Catalog service
+// url: the server address in the format "host:port"
+// Example usage:
+// client, err := catalog.NewClient("localhost:8080")
+func NewClient(url string) (*Client, error) {
+	conn, err := grpc.NewClient(url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		tracing.DialOption(),
+	)
+	if err != nil {
+		return nil, errors.New("failed to connect to server: " + err.Error())
+	}
+
+	return &Client{
+		conn:    conn,
+		service: pb.NewCatalogServiceClient(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Health asks the gRPC health service of the catalog service whether it is
+// serving, for the gateway's /readyz.
+func (c *Client) Health(ctx context.Context) error {
+	res, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.CatalogService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return ErrNotServing
+	}
+	return nil
+}
+
+func (c *Client) PostProduct(ctx context.Context, name, description string, price money.Money) (*Product, error) {
+	res, err := c.service.PostProduct(ctx, &pb.PostProductRequest{
+		Name:        name,
+		Description: description,
+		Price:       moneyToProto(price),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Product), nil
+}
+
+func (c *Client) PutProduct(ctx context.Context, id, name, description string, price money.Money) (*Product, error) {
+	res, err := c.service.PutProduct(ctx, &pb.PutProductRequest{
+		Id:          id,
+		Name:        name,
+		Description: description,
+		Price:       moneyToProto(price),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Product), nil
+}
+
+func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
+	res, err := c.service.GetProduct(ctx, &pb.GetProductRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Product), nil
+}
+
+func (c *Client) ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
+	res, err := c.service.ListProducts(ctx, &pb.ListProductsRequest{
+		Skip: skip,
+		Take: take,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := []Product{}
+	for _, p := range res.Products {
+		products = append(products, *fromProto(p))
+	}
+	return products, nil
+}
+
+// GetProductsByIDs fetches any number of products keyed by ID, in batches
+// of MaxBatchSize. IDs that do not exist are returned in notFound.
+func (c *Client) GetProductsByIDs(ctx context.Context, ids []string) (products map[string]Product, notFound []string, err error) {
+	products = map[string]Product{}
+
+	ids = uniqueIDs(ids)
+	for start := 0; start < len(ids); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(ids))
+
+		res, err := c.service.GetProductsByIDs(ctx, &pb.GetProductsByIDsRequest{Ids: ids[start:end]})
+		if err != nil {
+			return nil, nil, err
+		}
+		for id, p := range res.Products {
+			products[id] = *fromProto(p)
+		}
+		notFound = append(notFound, res.NotFoundIds...)
+	}
+
+	return products, notFound, nil
+}
+
+// SearchProducts returns a page of the products matching query, most
+// relevant first, or of products with a similar name when nothing matches
+// (res.Fuzzy). Invalid queries fail with codes.InvalidArgument.
+func (c *Client) SearchProducts(ctx context.Context, query string, filters SearchFilters, skip uint64, take uint64) (*SearchResult, error) {
+	res, err := c.service.SearchProducts(ctx, &pb.SearchProductsRequest{
+		Query: query,
+		Filters: &pb.ProductFilters{
+			MinPrice: optionalMoneyToProto(filters.MinPrice),
+			MaxPrice: optionalMoneyToProto(filters.MaxPrice),
+		},
+		Skip: skip,
+		Take: take,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SearchResult{
+		Hits:  []SearchHit{},
+		Total: res.Total,
+		Fuzzy: res.Fuzzy,
+	}
+	for _, h := range res.Hits {
+		result.Hits = append(result.Hits, SearchHit{
+			Product:              *fromProto(h.Product),
+			Rank:                 h.Rank,
+			NameHighlight:        h.NameHighlight,
+			DescriptionHighlight: h.DescriptionHighlight,
+		})
+	}
+	return result, nil
+}
+
+// WatchProductPrice streams a product every time its price changes. The
+// channel is closed when ctx is done or the stream ends, e.g. because the
+// service dropped a watcher that fell behind.
+func (c *Client) WatchProductPrice(ctx context.Context, productID string) (<-chan Product, error) {
+	stream, err := c.service.WatchProductPrice(ctx, &pb.WatchProductPriceRequest{ProductId: productID})
+	if err != nil {
+		return nil, err
+	}
+
+	products := make(chan Product)
+	go func() {
+		defer close(products)
+		for {
+			p, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case products <- *fromProto(p):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return products, nil
+}
+
+func (c *Client) DeleteProduct(ctx context.Context, id string) error {
+	_, err := c.service.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: id})
+	return err
+}
+
+// CreateCategory adds a category below parentID, or a root category when
+// parentID is empty. An empty slug is derived from the name.
+func (c *Client) CreateCategory(ctx context.Context, parentID, name, slug string, position int32) (*Category, error) {
+	res, err := c.service.CreateCategory(ctx, &pb.CreateCategoryRequest{
+		ParentId: parentID,
+		Name:     name,
+		Slug:     slug,
+		Position: position,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return categoryFromProto(res.Category), nil
+}
+
+// UpdateCategory renames, reorders or moves a category.
+func (c *Client) UpdateCategory(ctx context.Context, id, parentID, name, slug string, position int32) (*Category, error) {
+	res, err := c.service.UpdateCategory(ctx, &pb.UpdateCategoryRequest{
+		Id:       id,
+		ParentId: parentID,
+		Name:     name,
+		Slug:     slug,
+		Position: position,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return categoryFromProto(res.Category), nil
+}
+
+// GetCategory fetches a category by ID. A missing category fails with
+// codes.NotFound.
+func (c *Client) GetCategory(ctx context.Context, id string) (*Category, error) {
+	res, err := c.service.GetCategory(ctx, &pb.GetCategoryRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return categoryFromProto(res.Category), nil
+}
+
+// GetCategoryBySlug fetches a category by slug. A missing category fails
+// with codes.NotFound.
+func (c *Client) GetCategoryBySlug(ctx context.Context, slug string) (*Category, error) {
+	res, err := c.service.GetCategory(ctx, &pb.GetCategoryRequest{Slug: slug})
+	if err != nil {
+		return nil, err
+	}
+	return categoryFromProto(res.Category), nil
+}
+
+// ListCategories returns the children of parentID in order, or the root
+// categories when parentID is empty.
+func (c *Client) ListCategories(ctx context.Context, parentID string) ([]Category, error) {
+	res, err := c.service.ListCategories(ctx, &pb.ListCategoriesRequest{ParentId: parentID})
+	if err != nil {
+		return nil, err
+	}
+	return categoriesFromProto(res), nil
+}
+
+// GetCategoryPaths fetches the breadcrumbs of any number of categories,
+// from the root down to the category itself, in batches of MaxBatchSize.
+// Unknown IDs are absent from the map.
+func (c *Client) GetCategoryPaths(ctx context.Context, ids []string) (map[string][]Category, error) {
+	paths := map[string][]Category{}
+
+	ids = uniqueIDs(ids)
+	for start := 0; start < len(ids); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(ids))
+
+		res, err := c.service.GetCategoryPaths(ctx, &pb.GetCategoryPathsRequest{Ids: ids[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, path := range res.Paths {
+			paths[id] = categoriesFromProto(path)
+		}
+	}
+
+	return paths, nil
+}
+
+func (c *Client) DeleteCategory(ctx context.Context, id string) error {
+	_, err := c.service.DeleteCategory(ctx, &pb.DeleteCategoryRequest{Id: id})
+	return err
+}
+
+// SetProductCategories replaces the categories of a product and returns
+// the ones it now has.
+func (c *Client) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) ([]Category, error) {
+	res, err := c.service.SetProductCategories(ctx, &pb.SetProductCategoriesRequest{
+		ProductId:   productID,
+		CategoryIds: categoryIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return categoriesFromProto(&pb.CategoryList{Categories: res.Categories}), nil
+}
+
+// GetProductCategories fetches the categories of any number of products,
+// in batches of MaxBatchSize. Products without categories map to an empty list.
+func (c *Client) GetProductCategories(ctx context.Context, productIDs []string) (map[string][]Category, error) {
+	categories := map[string][]Category{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetProductCategories(ctx, &pb.GetProductCategoriesRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Categories {
+			categories[id] = categoriesFromProto(list)
+		}
+	}
+
+	return categories, nil
+}
+
+// ListProductsByCategory returns a page of the products of a category and
+// of all its descendants.
+func (c *Client) ListProductsByCategory(ctx context.Context, categoryID string, skip uint64, take uint64) ([]Product, error) {
+	res, err := c.service.ListProductsByCategory(ctx, &pb.ListProductsByCategoryRequest{
+		CategoryId: categoryID,
+		Skip:       skip,
+		Take:       take,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := []Product{}
+	for _, p := range res.Products {
+		products = append(products, *fromProto(p))
+	}
+	return products, nil
+}
+
+// categoryFromProto maps a gRPC category to the internal representation
+// CreateVariant adds a variant to the product v.ProductID; v.ID and v.Price are ignored.
+func (c *Client) CreateVariant(ctx context.Context, v Variant) (*Variant, error) {
+	res, err := c.service.CreateVariant(ctx, &pb.CreateVariantRequest{
+		ProductId:     v.ProductID,
+		Sku:           v.SKU,
+		Options:       v.Options,
+		PriceOverride: optionalMoneyToProto(v.PriceOverride),
+		Barcode:       v.Barcode,
+		Position:      v.Position,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variantFromProto(res.Variant), nil
+}
+
+// UpdateVariant changes the variant v.ID; v.ProductID and v.Price are ignored.
+func (c *Client) UpdateVariant(ctx context.Context, v Variant) (*Variant, error) {
+	res, err := c.service.UpdateVariant(ctx, &pb.UpdateVariantRequest{
+		Id:            v.ID,
+		Sku:           v.SKU,
+		Options:       v.Options,
+		PriceOverride: optionalMoneyToProto(v.PriceOverride),
+		Barcode:       v.Barcode,
+		Position:      v.Position,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return variantFromProto(res.Variant), nil
+}
+
+// GetVariantsByIDs fetches any number of variants keyed by ID, in batches
+// of MaxBatchSize. IDs that do not exist are returned in notFound.
+func (c *Client) GetVariantsByIDs(ctx context.Context, ids []string) (variants map[string]Variant, notFound []string, err error) {
+	variants = map[string]Variant{}
+
+	ids = uniqueIDs(ids)
+	for start := 0; start < len(ids); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(ids))
+
+		res, err := c.service.GetVariants(ctx, &pb.GetVariantsRequest{Ids: ids[start:end]})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range res.Variants {
+			variants[v.Id] = *variantFromProto(v)
+		}
+		notFound = append(notFound, res.NotFound...)
+	}
+
+	return variants, notFound, nil
+}
+
+// GetVariantsBySKUs fetches any number of variants keyed by their
+// normalized SKU (see NormalizeSKU), in batches of MaxBatchSize. SKUs that
+// do not exist are returned in notFound.
+func (c *Client) GetVariantsBySKUs(ctx context.Context, skus []string) (variants map[string]Variant, notFound []string, err error) {
+	variants = map[string]Variant{}
+
+	normalized := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		normalized = append(normalized, NormalizeSKU(sku))
+	}
+	skus = uniqueIDs(normalized)
+	for start := 0; start < len(skus); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(skus))
+
+		res, err := c.service.GetVariants(ctx, &pb.GetVariantsRequest{Skus: skus[start:end]})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range res.Variants {
+			variants[v.Sku] = *variantFromProto(v)
+		}
+		notFound = append(notFound, res.NotFound...)
+	}
+
+	return variants, notFound, nil
+}
+
+// GetProductVariants fetches the variants of any number of products keyed
+// by product ID, in batches of MaxBatchSize.
+func (c *Client) GetProductVariants(ctx context.Context, productIDs []string) (map[string][]Variant, error) {
+	variants := map[string][]Variant{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetProductVariants(ctx, &pb.GetProductVariantsRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Variants {
+			variants[id] = variantsFromProto(list)
+		}
+	}
+
+	return variants, nil
+}
+
+func (c *Client) DeleteVariant(ctx context.Context, id string) error {
+	_, err := c.service.DeleteVariant(ctx, &pb.DeleteVariantRequest{Id: id})
+	return err
+}
+
+// SetStock sets the on-hand quantity of a variant, or of a product sold
+// without variants when variantID is empty.
+func (c *Client) SetStock(ctx context.Context, productID, variantID string, onHand uint32) (*StockLevel, error) {
+	res, err := c.service.SetStock(ctx, &pb.SetStockRequest{
+		ProductId: productID,
+		VariantId: variantID,
+		OnHand:    onHand,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stockLevelFromProto(res.Level), nil
+}
+
+// AdjustStock adds delta to the on-hand quantity of an item.
+func (c *Client) AdjustStock(ctx context.Context, productID, variantID string, delta int32) (*StockLevel, error) {
+	res, err := c.service.AdjustStock(ctx, &pb.AdjustStockRequest{
+		ProductId: productID,
+		VariantId: variantID,
+		Delta:     delta,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stockLevelFromProto(res.Level), nil
+}
+
+// GetStockLevels fetches the stock of the tracked items of any number of
+// products keyed by product ID, in batches of MaxBatchSize. Untracked
+// products map to an empty list.
+func (c *Client) GetStockLevels(ctx context.Context, productIDs []string) (map[string][]StockLevel, error) {
+	levels := map[string][]StockLevel{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetStockLevels(ctx, &pb.GetStockLevelsRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Levels {
+			levels[id] = []StockLevel{}
+			for _, l := range list.Levels {
+				levels[id] = append(levels[id], *stockLevelFromProto(l))
+			}
+		}
+	}
+
+	return levels, nil
+}
+
+// ReserveStock holds items for ttl, all of them or none; a zero ttl uses
+// the service default. It fails with FAILED_PRECONDITION when a tracked
+// item has less available.
+func (c *Client) ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error) {
+	// Rounded up, so a short ttl does not become the default
+	req := &pb.ReserveStockRequest{TtlSeconds: uint32((ttl + time.Second - 1) / time.Second)}
+	for _, item := range items {
+		req.Items = append(req.Items, &pb.StockItem{
+			ProductId: item.ProductID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	res, err := c.service.ReserveStock(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(res.Reservation), nil
+}
+
+// CommitReservation takes the items of a pending reservation out of stock.
+func (c *Client) CommitReservation(ctx context.Context, id string) (*Reservation, error) {
+	res, err := c.service.CommitReservation(ctx, &pb.CommitReservationRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(res.Reservation), nil
+}
+
+// ReleaseReservation gives the items of a pending reservation back.
+func (c *Client) ReleaseReservation(ctx context.Context, id string) (*Reservation, error) {
+	res, err := c.service.ReleaseReservation(ctx, &pb.ReleaseReservationRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(res.Reservation), nil
+}
+
+// ReturnReservation puts the items of a committed reservation back into stock.
+func (c *Client) ReturnReservation(ctx context.Context, id string) (*Reservation, error) {
+	res, err := c.service.ReturnReservation(ctx, &pb.ReturnReservationRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return reservationFromProto(res.Reservation), nil
+}
+
+// SetListPrice sets the explicit price of a variant, or of the product
+// itself when variantID is empty, in a currency other than the product's.
+func (c *Client) SetListPrice(ctx context.Context, productID, variantID string, price money.Money) (*ListPrice, error) {
+	res, err := c.service.SetListPrice(ctx, &pb.SetListPriceRequest{
+		ProductId: productID,
+		VariantId: variantID,
+		Price:     moneyToProto(price),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return listPriceFromProto(res.Price), nil
+}
+
+// DeleteListPrice removes the list price of an item in currency, which is
+// then converted from the product price again.
+func (c *Client) DeleteListPrice(ctx context.Context, productID, variantID, currency string) error {
+	_, err := c.service.DeleteListPrice(ctx, &pb.DeleteListPriceRequest{
+		ProductId: productID,
+		VariantId: variantID,
+		Currency:  currency,
+	})
+	return err
+}
+
+// GetListPrices fetches the list prices of any number of products and
+// their variants keyed by product ID, in batches of MaxBatchSize.
+func (c *Client) GetListPrices(ctx context.Context, productIDs []string) (map[string][]ListPrice, error) {
+	prices := map[string][]ListPrice{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetListPrices(ctx, &pb.GetListPricesRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Prices {
+			prices[id] = []ListPrice{}
+			for _, p := range list.Prices {
+				prices[id] = append(prices[id], *listPriceFromProto(p))
+			}
+		}
+	}
+
+	return prices, nil
+}
+
+// GetPrices prices any number of items in currency, in batches of
+// MaxBatchSize, returning them in the order of items. An empty currency
+// prices every item in the currency of its product.
+func (c *Client) GetPrices(ctx context.Context, items []PriceItem, currency string) ([]Price, error) {
+	prices := make([]Price, 0, len(items))
+
+	for start := 0; start < len(items); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(items))
+
+		req := &pb.GetPricesRequest{Currency: currency}
+		for _, item := range items[start:end] {
+			req.Items = append(req.Items, &pb.PriceItem{ProductId: item.ProductID, VariantId: item.VariantID})
+		}
+		res, err := c.service.GetPrices(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res.Prices {
+			prices = append(prices, priceFromProto(p))
+		}
+	}
+
+	return prices, nil
+}
+
+// ScheduleProductPrice schedules a price of a product, see
+// Service.ScheduleProductPrice. A zero from starts it now.
+func (c *Client) ScheduleProductPrice(ctx context.Context, productID string, price money.Money, from time.Time, until *time.Time, label string) (*PriceChange, error) {
+	req := &pb.ScheduleProductPriceRequest{
+		ProductId: productID,
+		Price:     moneyToProto(price),
+		Label:     label,
+	}
+	if !from.IsZero() {
+		req.EffectiveFrom = from.Format(time.RFC3339)
+	}
+	if until != nil {
+		req.EffectiveUntil = until.Format(time.RFC3339)
+	}
+
+	res, err := c.service.ScheduleProductPrice(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return priceChangeFromProto(res.Change), nil
+}
+
+// CancelScheduledPrice removes a scheduled price that has not started, or
+// ends a running one now.
+func (c *Client) CancelScheduledPrice(ctx context.Context, id string) error {
+	_, err := c.service.CancelScheduledPrice(ctx, &pb.CancelScheduledPriceRequest{Id: id})
+	return err
+}
+
+// GetPriceHistory fetches the price history of any number of products
+// keyed by product ID, in batches of MaxBatchSize.
+func (c *Client) GetPriceHistory(ctx context.Context, productIDs []string) (map[string][]PriceChange, error) {
+	history := map[string][]PriceChange{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetPriceHistory(ctx, &pb.GetPriceHistoryRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.History {
+			changes := make([]PriceChange, 0, len(list.Changes))
+			for _, change := range list.Changes {
+				changes = append(changes, *priceChangeFromProto(change))
+			}
+			history[id] = changes
+		}
+	}
+
+	return history, nil
+}
+
+// SetExchangeRates stores rates against the base currency, keeping the
+// rates of other currencies, and returns the base currency and every rate.
+func (c *Client) SetExchangeRates(ctx context.Context, rates []ExchangeRate) (string, []ExchangeRate, error) {
+	req := &pb.SetExchangeRatesRequest{}
+	for _, r := range rates {
+		req.Rates = append(req.Rates, &pb.ExchangeRate{Currency: r.Currency, Rate: r.Rate})
+	}
+
+	res, err := c.service.SetExchangeRates(ctx, req)
+	if err != nil {
+		return "", nil, err
+	}
+	return res.BaseCurrency, exchangeRatesFromProto(res.Rates), nil
+}
+
+// ListExchangeRates returns the base currency and every exchange rate.
+func (c *Client) ListExchangeRates(ctx context.Context) (string, []ExchangeRate, error) {
+	res, err := c.service.ListExchangeRates(ctx, &pb.ListExchangeRatesRequest{})
+	if err != nil {
+		return "", nil, err
+	}
+	return res.BaseCurrency, exchangeRatesFromProto(res.Rates), nil
+}
+
+// uploadChunkSize is the size of the chunks images are streamed in.
+const uploadChunkSize = 64 << 10
+
+// UploadProductImage streams the image read from r to the service, which
+// stores it with its thumbnails as the last image of the product.
+func (c *Client) UploadProductImage(ctx context.Context, productID, altText string, r io.Reader) (*ProductImage, error) {
+	// Cancelling aborts the upload on a read error, where closing the stream
+	// would have the server store a truncated file
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.service.UploadProductImage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stream.Send(&pb.UploadProductImageRequest{
+		Data: &pb.UploadProductImageRequest_Metadata_{Metadata: &pb.UploadProductImageRequest_Metadata{
+			ProductId: productID,
+			AltText:   altText,
+		}},
+	})
+	buf := make([]byte, uploadChunkSize)
+	for err == nil {
+		var n int
+		n, err = io.ReadFull(r, buf)
+		if n > 0 {
+			// A send error means the server ended the stream; its status
+			// comes with CloseAndRecv
+			if sendErr := stream.Send(&pb.UploadProductImageRequest{
+				Data: &pb.UploadProductImageRequest_Chunk{Chunk: buf[:n]},
+			}); sendErr != nil {
+				err = io.EOF
+			}
+		}
+	}
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return productImageFromProto(res.Image), nil
+}
+
+// UpdateProductImage changes the alt text of an image.
+func (c *Client) UpdateProductImage(ctx context.Context, id, altText string) (*ProductImage, error) {
+	res, err := c.service.UpdateProductImage(ctx, &pb.UpdateProductImageRequest{Id: id, AltText: altText})
+	if err != nil {
+		return nil, err
+	}
+	return productImageFromProto(res.Image), nil
+}
+
+// ReorderProductImages puts the images of a product in the order of
+// imageIDs, which must list each of them once, and returns them.
+func (c *Client) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error) {
+	res, err := c.service.ReorderProductImages(ctx, &pb.ReorderProductImagesRequest{
+		ProductId: productID,
+		ImageIds:  imageIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return productImagesFromProto(res.Images), nil
+}
+
+// DeleteProductImage removes an image and its files.
+func (c *Client) DeleteProductImage(ctx context.Context, id string) error {
+	_, err := c.service.DeleteProductImage(ctx, &pb.DeleteProductImageRequest{Id: id})
+	return err
+}
+
+// GetProductImages fetches the images of any number of products keyed by
+// product ID, in batches of MaxBatchSize.
+func (c *Client) GetProductImages(ctx context.Context, productIDs []string) (map[string][]ProductImage, error) {
+	images := map[string][]ProductImage{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetProductImages(ctx, &pb.GetProductImagesRequest{ProductIds: productIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Images {
+			images[id] = productImagesFromProto(list.Images)
+		}
+	}
+
+	return images, nil
+}
+
+// importChunkSize is how many records are sent per message of an import.
+const importChunkSize = 500
+
+// ImportProducts streams the records returned by next, until it returns
+// io.EOF, to the service and returns its report. See Service.ImportProducts.
+func (c *Client) ImportProducts(ctx context.Context, next func() (*ImportRecord, error), dryRun bool) (*ImportReport, error) {
+	// Cancelling aborts the import on a read error, where closing the
+	// stream would have the service report a partial import
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.service.ImportProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &pb.ImportProductsRequest{DryRun: dryRun}
+	for {
+		rec, err := next()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if rec != nil && err == nil {
+			req.Records = append(req.Records, &pb.ImportRecord{Line: rec.Line, Record: productRecordToProto(&rec.ProductRecord)})
+		}
+		if len(req.Records) == importChunkSize || err == io.EOF {
+			// A send error means the server ended the stream; its status
+			// comes with CloseAndRecv
+			if stream.Send(req) != nil {
+				break
+			}
+			req = &pb.ImportProductsRequest{}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+	return importReportFromProto(res), nil
+}
+
+// ExportProducts passes the whole catalog to emit, a page of records at a
+// time, and stops at the first error emit returns.
+func (c *Client) ExportProducts(ctx context.Context, emit func([]ProductRecord) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.service.ExportProducts(ctx, &pb.ExportProductsRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		records := make([]ProductRecord, 0, len(res.Records))
+		for _, r := range res.Records {
+			records = append(records, productRecordFromProto(r))
+		}
+		if err := emit(records); err != nil {
+			return err
+		}
+	}
+}
+
+// CreateReview stores a review of the account for the product, PENDING
+// moderation.
+func (c *Client) CreateReview(ctx context.Context, r Review) (*Review, error) {
+	res, err := c.service.CreateReview(ctx, &pb.CreateReviewRequest{
+		ProductId: r.ProductID,
+		AccountId: r.AccountID,
+		Rating:    r.Rating,
+		Title:     r.Title,
+		Body:      r.Body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewFromProto(res.Review), nil
+}
+
+// ModerateReview sets the status of a review.
+func (c *Client) ModerateReview(ctx context.Context, id string, status ReviewStatus) (*Review, error) {
+	res, err := c.service.ModerateReview(ctx, &pb.ModerateReviewRequest{
+		Id:     id,
+		Status: reviewStatusToProto(status),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewFromProto(res.Review), nil
+}
+
+// GetProductReviews fetches a page of the approved reviews of each
+// product, splitting the products into batches of MaxBatchSize.
+func (c *Client) GetProductReviews(ctx context.Context, productIDs []string, skip uint64, take uint64) (map[string][]Review, error) {
+	reviews := map[string][]Review{}
+
+	productIDs = uniqueIDs(productIDs)
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+
+		res, err := c.service.GetProductReviews(ctx, &pb.GetProductReviewsRequest{
+			ProductIds: productIDs[start:end],
+			Skip:       skip,
+			Take:       take,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range res.Reviews {
+			reviews[id] = reviewsFromProto(list.Reviews)
+		}
+	}
+
+	return reviews, nil
+}
+
+// ListReviews lists the reviews in status, or in any status when it is
+// empty, newest first.
+func (c *Client) ListReviews(ctx context.Context, status ReviewStatus, skip uint64, take uint64) ([]Review, error) {
+	res, err := c.service.ListReviews(ctx, &pb.ListReviewsRequest{
+		Status: reviewStatusToProto(status),
+		Skip:   skip,
+		Take:   take,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reviewsFromProto(res.Reviews), nil
+}
+
+func productRecordFromProto(r *pb.ProductRecord) ProductRecord {
+	return ProductRecord{
+		SKU:           r.Sku,
+		ProductID:     r.ProductId,
+		Name:          r.Name,
+		Description:   r.Description,
+		Price:         moneyFromProto(r.Price),
+		Options:       r.Options,
+		PriceOverride: optionalMoneyFromProto(r.PriceOverride),
+		Barcode:       r.Barcode,
+	}
+}
+
+func importReportFromProto(r *pb.ImportProductsResponse) *ImportReport {
+	report := &ImportReport{
+		DryRun:          r.DryRun,
+		Received:        r.Received,
+		Created:         r.Created,
+		Updated:         r.Updated,
+		Unchanged:       r.Unchanged,
+		Failed:          r.Failed,
+		ProductsCreated: r.ProductsCreated,
+		Issues:          make([]ImportIssue, 0, len(r.Issues)),
+	}
+	for _, issue := range r.Issues {
+		report.Issues = append(report.Issues, ImportIssue{Line: issue.Line, SKU: issue.Sku, Message: issue.Message})
+	}
+	return report
+}
+
+func listPriceFromProto(p *pb.ListPrice) *ListPrice {
+	return &ListPrice{
+		ProductID: p.ProductId,
+		VariantID: p.VariantId,
+		Price:     moneyFromProto(p.Price),
+	}
+}
+
+func priceFromProto(p *pb.Price) Price {
+	return Price{
+		ProductID: p.ProductId,
+		VariantID: p.VariantId,
+		Amount:    moneyFromProto(p.Amount),
+		Base:      moneyFromProto(p.Base),
+		Rate:      p.Rate,
+	}
+}
+
+func exchangeRatesFromProto(list []*pb.ExchangeRate) []ExchangeRate {
+	rates := make([]ExchangeRate, 0, len(list))
+	for _, r := range list {
+		updatedAt, _ := time.Parse(time.RFC3339, r.UpdatedAt)
+		rates = append(rates, ExchangeRate{Currency: r.Currency, Rate: r.Rate, UpdatedAt: updatedAt})
+	}
+	return rates
+}
+
+func priceChangeFromProto(c *pb.PriceChange) *PriceChange {
+	effectiveFrom, _ := time.Parse(time.RFC3339, c.EffectiveFrom)
+	createdAt, _ := time.Parse(time.RFC3339, c.CreatedAt)
+
+	out := &PriceChange{
+		ID:            c.Id,
+		ProductID:     c.ProductId,
+		Price:         moneyFromProto(c.Price),
+		EffectiveFrom: effectiveFrom,
+		Scheduled:     c.Scheduled,
+		Label:         c.Label,
+		CreatedAt:     createdAt,
+	}
+	if c.EffectiveUntil != "" {
+		if until, err := time.Parse(time.RFC3339, c.EffectiveUntil); err == nil {
+			out.EffectiveUntil = &until
+		}
+	}
+	return out
+}
+
+func productImageFromProto(img *pb.ProductImage) *ProductImage {
+	createdAt, _ := time.Parse(time.RFC3339, img.CreatedAt)
+
+	out := &ProductImage{
+		ID:          img.Id,
+		ProductID:   img.ProductId,
+		Position:    img.Position,
+		AltText:     img.AltText,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        img.Size,
+		Key:         img.Key,
+		URL:         img.Url,
+		Thumbnails:  make([]Thumbnail, 0, len(img.Thumbnails)),
+		CreatedAt:   createdAt,
+	}
+	for _, t := range img.Thumbnails {
+		out.Thumbnails = append(out.Thumbnails, Thumbnail{
+			Size:        t.Size,
+			ContentType: t.ContentType,
+			Width:       t.Width,
+			Height:      t.Height,
+			Key:         t.Key,
+			URL:         t.Url,
+		})
+	}
+	return out
+}
+
+func productImagesFromProto(list []*pb.ProductImage) []ProductImage {
+	images := make([]ProductImage, 0, len(list))
+	for _, img := range list {
+		images = append(images, *productImageFromProto(img))
+	}
+	return images
+}
+
+func stockLevelFromProto(l *pb.StockLevel) *StockLevel {
+	return &StockLevel{
+		ProductID: l.ProductId,
+		VariantID: l.VariantId,
+		OnHand:    l.OnHand,
+		Reserved:  l.Reserved,
+	}
+}
+
+func reservationFromProto(r *pb.Reservation) *Reservation {
+	createdAt, _ := time.Parse(time.RFC3339, r.CreatedAt)
+	expiresAt, _ := time.Parse(time.RFC3339, r.ExpiresAt)
+
+	res := &Reservation{
+		ID:        r.Id,
+		Status:    reservationStatusFromProto(r.Status),
+		CreatedAt: createdAt,
+		ExpiresAt: expiresAt,
+		Items:     []StockItem{},
+	}
+	for _, item := range r.Items {
+		res.Items = append(res.Items, StockItem{
+			ProductID: item.ProductId,
+			VariantID: item.VariantId,
+			Quantity:  item.Quantity,
+		})
+	}
+	return res
+}
+
+// reservationStatusFromProto maps a gRPC reservation status to the internal one
+func reservationStatusFromProto(s pb.ReservationStatus) ReservationStatus {
+	switch s {
+	case pb.ReservationStatus_RESERVATION_STATUS_PENDING:
+		return ReservationPending
+	case pb.ReservationStatus_RESERVATION_STATUS_COMMITTED:
+		return ReservationCommitted
+	case pb.ReservationStatus_RESERVATION_STATUS_RELEASED:
+		return ReservationReleased
+	case pb.ReservationStatus_RESERVATION_STATUS_EXPIRED:
+		return ReservationExpired
+	case pb.ReservationStatus_RESERVATION_STATUS_RETURNED:
+		return ReservationReturned
+	}
+	return ""
+}
+
+func reviewFromProto(r *pb.Review) *Review {
+	createdAt, _ := time.Parse(time.RFC3339, r.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339, r.UpdatedAt)
+
+	return &Review{
+		ID:               r.Id,
+		ProductID:        r.ProductId,
+		AccountID:        r.AccountId,
+		Rating:           r.Rating,
+		Title:            r.Title,
+		Body:             r.Body,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           reviewStatusFromProto(r.Status),
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+	}
+}
+
+func reviewsFromProto(list []*pb.Review) []Review {
+	out := make([]Review, 0, len(list))
+	for _, r := range list {
+		out = append(out, *reviewFromProto(r))
+	}
+	return out
+}
+
+// reviewStatusFromProto maps a gRPC review status to the internal one;
+// UNSPECIFIED is the empty status
+func reviewStatusFromProto(s pb.ReviewStatus) ReviewStatus {
+	switch s {
+	case pb.ReviewStatus_REVIEW_STATUS_PENDING:
+		return ReviewPending
+	case pb.ReviewStatus_REVIEW_STATUS_APPROVED:
+		return ReviewApproved
+	case pb.ReviewStatus_REVIEW_STATUS_REJECTED:
+		return ReviewRejected
+	}
+	return ""
+}
+
+func variantFromProto(v *pb.Variant) *Variant {
+	return &Variant{
+		ID:            v.Id,
+		ProductID:     v.ProductId,
+		SKU:           v.Sku,
+		Options:       v.Options,
+		PriceOverride: optionalMoneyFromProto(v.PriceOverride),
+		Price:         moneyFromProto(v.Price),
+		Barcode:       v.Barcode,
+		Position:      v.Position,
+	}
+}
+
+func variantsFromProto(list *pb.VariantList) []Variant {
+	variants := []Variant{}
+	for _, v := range list.Variants {
+		variants = append(variants, *variantFromProto(v))
+	}
+	return variants
+}
+
+func categoryFromProto(c *pb.Category) *Category {
+	return &Category{
+		ID:       c.Id,
+		ParentID: c.ParentId,
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Position: c.Position,
+	}
+}
+
+func categoriesFromProto(list *pb.CategoryList) []Category {
+	categories := []Category{}
+	for _, c := range list.GetCategories() {
+		categories = append(categories, *categoryFromProto(c))
+	}
+	return categories
+}
+
+// fromProto maps a gRPC product to the internal representation
+func fromProto(p *pb.Product) *Product {
+	return &Product{
+		ID:            p.Id,
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         moneyFromProto(p.Price),
+		RegularPrice:  optionalMoneyFromProto(p.RegularPrice),
+		RatingCount:   p.RatingCount,
+		AverageRating: p.AverageRating,
+	}
+}
+
+// moneyFromProto maps a gRPC amount to the internal representation; an
+// unset amount is the zero Money.
+func moneyFromProto(m *pb.Money) money.Money {
+	return money.Money{Amount: m.GetAmount(), Currency: m.GetCurrency()}
+}
+
+func optionalMoneyFromProto(m *pb.Money) *money.Money {
+	if m == nil {
+		return nil
+	}
+	out := moneyFromProto(m)
+	return &out
+}
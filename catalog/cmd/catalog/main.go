@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+
+	"github.com/olujimiAdebakin/ProtoGraph/blob"
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/config"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+	"github.com/olujimiAdebakin/ProtoGraph/postgres"
+	"github.com/olujimiAdebakin/ProtoGraph/ratelimit"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+type Config struct {
+	Port        int    `envconfig:"PORT" default:"8080" validate:"port"`
+	DatabaseURL string `envconfig:"DATABASE_URL" validate:"required,url" secret:"true"`
+	MetricsPort int    `envconfig:"METRICS_PORT" default:"9090" validate:"port"`
+
+	// Connection pool and query timeouts, see DB_* variables
+	DB postgres.Config `envconfig:"DB"`
+
+	// Tracing is disabled unless TRACING_EXPORTER is set
+	Tracing tracing.Config `envconfig:"TRACING"`
+
+	// Per-caller token buckets, see RATE_LIMIT_* variables
+	RateLimit ratelimit.Config `envconfig:"RATE_LIMIT"`
+
+	// Exchange rates are quoted against BASE_CURRENCY. EXCHANGE_RATES_FILE
+	// optionally names a JSON object of currency to rate, e.g.
+	// {"EUR": "0.9172", "JPY": "151.2"}, loaded on every start.
+	BaseCurrency      string `envconfig:"BASE_CURRENCY" default:"USD"`
+	ExchangeRatesFile string `envconfig:"EXCHANGE_RATES_FILE"`
+
+	// Where product images and their thumbnails are kept, see BLOB_*
+	// variables. Stored files are served below /media/ on MEDIA_PORT, which
+	// BLOB_PUBLIC_URL should point at unless a CDN or the bucket serves them.
+	Blob      blob.Config `envconfig:"BLOB"`
+	MediaPort int         `envconfig:"MEDIA_PORT" default:"8070" validate:"port"`
+
+	// Reviews are flagged as verified purchases when the order service at
+	// ORDER_SERVICE_URL has an order of the product by their author; without
+	// it no review is verified.
+	OrderURL string `envconfig:"ORDER_SERVICE_URL" validate:"hostport"`
+
+	// How often watchers of product prices are told about scheduled prices
+	// that started or ended
+	PriceScheduleInterval time.Duration `envconfig:"PRICE_SCHEDULE_INTERVAL" default:"30s" validate:"min=1s"`
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.Port == c.MetricsPort || c.MediaPort == c.Port || c.MediaPort == c.MetricsPort {
+		return errors.New("PORT, METRICS_PORT and MEDIA_PORT must differ")
+	}
+	if !money.ValidCurrency(c.BaseCurrency) {
+		return errors.New("BASE_CURRENCY must be a three-letter ISO 4217 code")
+	}
+	return nil
+}
+
+// loadExchangeRates stores the rates of the JSON file at path, keeping the
+// rates of currencies it does not list.
+func loadExchangeRates(ctx context.Context, s catalog.Service, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// json.Number keeps rates exact and accepts them quoted or not
+	var file map[string]json.Number
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	rates := make([]catalog.ExchangeRate, 0, len(file))
+	for currency, rate := range file {
+		rates = append(rates, catalog.ExchangeRate{Currency: currency, Rate: rate.String()})
+	}
+	if _, err := s.SetExchangeRates(ctx, rates); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func main() {
+	// Defaults < config file < environment < flags, validated before anything starts
+	var cfg Config
+	config.MustLoad("catalog", &cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), "catalog", cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	var r catalog.Repository
+
+	err = retry.Do(
+		func() error {
+			var err error
+			r, err = catalog.NewPostgresRepositry(cfg.DatabaseURL, cfg.DB)
+			if err != nil {
+				log.Printf("failed to connect to database: %v", err)
+			}
+			return err
+		},
+		retry.Attempts(5),
+		retry.Delay(2*time.Second),
+		retry.OnRetry(func(n uint, err error) {
+			log.Printf("Retry attempt %d: %v", n, err)
+		}),
+	)
+
+	if err != nil {
+		log.Fatal("Failed to connect after retries: ", err)
+	}
+
+	defer r.Close()
+
+	// Serve Prometheus metrics next to the gRPC API
+	go func() {
+		log.Fatal(metrics.ListenAndServe(cfg.MetricsPort))
+	}()
+
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limits := ratelimit.ServerOptions(limiter, cfg.RateLimit.TrustForwardedFor)
+
+	store, err := blob.Open(cfg.Blob)
+	if err != nil {
+		log.Fatal("Failed to open blob store: ", err)
+	}
+
+	// Serve stored media, e.g. GET /media/products/<id>/<image>.jpg
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/media/", http.StripPrefix("/media/", blob.Handler(store)))
+		log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.MediaPort), mux))
+	}()
+
+	var purchases catalog.PurchaseVerifier
+	if cfg.OrderURL != "" {
+		orders, err := order.NewClient(cfg.OrderURL)
+		if err != nil {
+			log.Fatal("Failed to connect to the order service: ", err)
+		}
+		defer orders.Close()
+		purchases = orders
+	}
+
+	s := catalog.NewService(r, cfg.BaseCurrency, store, purchases)
+	if cfg.ExchangeRatesFile != "" {
+		if err := loadExchangeRates(context.Background(), s, cfg.ExchangeRatesFile); err != nil {
+			log.Fatal("Failed to load exchange rates: ", err)
+		}
+	}
+
+	go s.PublishPriceSchedules(context.Background(), cfg.PriceScheduleInterval)
+
+	log.Printf("Listening on port %d......", cfg.Port)
+	log.Fatal(catalog.ListenGRPCServer(s, cfg.Port, limits...))
+}
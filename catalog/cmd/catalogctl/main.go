@@ -0,0 +1,293 @@
+// Command catalogctl imports products into the catalog service from CSV or
+// JSON Lines files, e.g. exported from a spreadsheet, and exports the
+// catalog in the same formats.
+//
+//	catalogctl import [-dry-run] [-map field=column]... [-currency USD] products.csv
+//	catalogctl export [-format jsonl] catalog.jsonl
+//
+// Records are keyed by SKU, so importing a file twice changes nothing the
+// second time, and an exported file can be edited and imported back.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
+
+const usage = `usage:
+  catalogctl import [flags] FILE   upsert the records of FILE, - for stdin
+  catalogctl export [flags] FILE   write every product to FILE, - for stdout
+
+Run catalogctl import -h or catalogctl export -h for the flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "catalogctl:", err)
+		os.Exit(1)
+	}
+}
+
+// errRecordsFailed makes catalogctl exit with status 1 after an import
+// whose report lists failed records.
+var errRecordsFailed = errors.New("some records failed")
+
+// commonFlags are the flags of both commands.
+type commonFlags struct {
+	catalogURL string
+	format     string
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	url := os.Getenv("CATALOG_SERVICE_URL")
+	if url == "" {
+		url = "localhost:8082"
+	}
+	fs.StringVar(&c.catalogURL, "catalog-service-url", url, "gRPC target of the catalog service, host:port (or set CATALOG_SERVICE_URL)")
+	fs.StringVar(&c.format, "format", "", "csv or jsonl; by default taken from the file extension, csv for - ")
+}
+
+// resolveFormat picks the format of path when -format is not given.
+func (c *commonFlags) resolveFormat(path string) (string, error) {
+	format := strings.ToLower(c.format)
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".ndjson":
+			format = formatJSONL
+		default:
+			format = formatCSV
+		}
+	}
+	if format != formatCSV && format != formatJSONL {
+		return "", fmt.Errorf("unknown format %q, want csv or jsonl", c.format)
+	}
+	return format, nil
+}
+
+func runImport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("catalogctl import", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	dryRun := fs.Bool("dry-run", false, "validate every record and report what would change, without writing anything")
+	currency := fs.String("currency", "", "currency of the rows without a currency column or value")
+	reportPath := fs.String("report", "", "also write the report as JSON to this file")
+	var specs []string
+	fs.Func("map", "read a field from a differently named column, as field=column, e.g. -map sku=\"Item number\"; repeatable. "+
+		"Fields: "+strings.Join(fields, ", ")+", or "+optionPrefix+"<name> for a column holding one option", func(s string) error {
+		specs = append(specs, s)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("import takes exactly one FILE")
+	}
+	path := fs.Arg(0)
+
+	format, err := common.resolveFormat(path)
+	if err != nil {
+		return err
+	}
+	m, err := parseMapping(specs, *currency)
+	if err != nil {
+		return err
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var rows rowReader
+	if format == formatCSV {
+		if rows, err = newCSVReader(in, m); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		rows = newJSONLReader(in, m)
+	}
+
+	client, err := catalog.NewClient(common.catalogURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Rows that cannot be read are reported along with the issues of the
+	// service, and skipped
+	var rowIssues []catalog.ImportIssue
+	next := func() (*catalog.ImportRecord, error) {
+		for {
+			r, err := rows.Read()
+			if err == nil {
+				var rec *catalog.ImportRecord
+				if rec, err = m.record(r); err == nil {
+					return rec, nil
+				}
+			}
+			var rowErr *rowError
+			if !errors.As(err, &rowErr) {
+				return nil, err
+			}
+			rowIssues = append(rowIssues, catalog.ImportIssue{Line: rowErr.line, SKU: rowErr.sku, Message: rowErr.Error()})
+		}
+	}
+
+	report, err := client.ImportProducts(ctx, next, *dryRun)
+	if err != nil {
+		return err
+	}
+	mergeRowIssues(report, rowIssues)
+
+	printReport(os.Stdout, report)
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	if report.Failed > 0 {
+		return errRecordsFailed
+	}
+	return nil
+}
+
+// mergeRowIssues counts the rows that could not be read as failed records
+// and lists their issues with the others, by line.
+func mergeRowIssues(report *catalog.ImportReport, rowIssues []catalog.ImportIssue) {
+	report.Received += int64(len(rowIssues))
+	report.Failed += int64(len(rowIssues))
+	report.Issues = append(report.Issues, rowIssues...)
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Line < report.Issues[j].Line
+	})
+}
+
+// printReport writes the validation report of an import.
+func printReport(w io.Writer, r *catalog.ImportReport) {
+	if r.DryRun {
+		fmt.Fprintln(w, "Dry run: nothing was written.")
+	}
+	fmt.Fprintf(w, "%d records: %d created, %d updated, %d unchanged, %d failed; %d new products\n",
+		r.Received, r.Created, r.Updated, r.Unchanged, r.Failed, r.ProductsCreated)
+	for _, issue := range r.Issues {
+		if issue.SKU != "" {
+			fmt.Fprintf(w, "line %d (%s): %s\n", issue.Line, issue.SKU, issue.Message)
+		} else {
+			fmt.Fprintf(w, "line %d: %s\n", issue.Line, issue.Message)
+		}
+	}
+	if listed := int64(len(r.Issues)); listed < r.Failed {
+		fmt.Fprintf(w, "... and %d more failed records\n", r.Failed-listed)
+	}
+}
+
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("catalogctl export", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("export takes exactly one FILE")
+	}
+	path := fs.Arg(0)
+
+	format, err := common.resolveFormat(path)
+	if err != nil {
+		return err
+	}
+
+	client, err := catalog.NewClient(common.catalogURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Write to a temporary file renamed into place at the end, so a failed
+	// export does not leave a truncated file that could be imported
+	out := io.Writer(os.Stdout)
+	var tmp *os.File
+	if path != "-" {
+		if tmp, err = os.CreateTemp(filepath.Dir(path), ".catalogctl-*"); err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		out = tmp
+	}
+
+	var w recordWriter
+	if format == formatCSV {
+		if w, err = newCSVWriter(out); err != nil {
+			return err
+		}
+	} else {
+		w = newJSONLWriter(out)
+	}
+
+	count := 0
+	err = client.ExportProducts(ctx, func(records []catalog.ProductRecord) error {
+		count += len(records)
+		return w.Write(records)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if tmp != nil {
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "exported %d records\n", count)
+	return nil
+}
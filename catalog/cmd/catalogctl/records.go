@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+)
+
+// Supported file formats.
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+// Fields of a record, which are also the default column names of CSV
+// files and the keys of JSON Lines objects. A column named option:<name>
+// holds the value of one option, e.g. option:Size.
+var fields = []string{"sku", "product_id", "name", "description", "price", "currency", "price_override", "barcode", "options"}
+
+const optionPrefix = "option:"
+
+// rowError is a row that cannot be turned into a record. It is reported
+// like the issues of the service and the row is skipped.
+type rowError struct {
+	line int64
+	sku  string
+	err  error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+// row is a row of a file: CSV cells by header, or the values of a JSON
+// object by key. Column names are matched case insensitively, so they are
+// lowercase here.
+type row struct {
+	line   int64
+	values map[string]string
+
+	// optionNames maps the option:<name> columns of the row to <name>,
+	// in its original case
+	optionNames map[string]string
+
+	// options is the options field given as a JSON object
+	options map[string]string
+}
+
+// mapping tells which column holds each field. Fields not mapped are read
+// from the column of the same name; defaultCurrency is used for rows
+// without a currency.
+type mapping struct {
+	columns         map[string]string
+	options         map[string]string // option name to column
+	defaultCurrency string
+}
+
+// parseMapping reads -map values of the form field=column, where field is
+// one of fields or option:<name>.
+func parseMapping(specs []string, defaultCurrency string) (*mapping, error) {
+	m := &mapping{
+		columns:         map[string]string{},
+		options:         map[string]string{},
+		defaultCurrency: money.NormalizeCurrency(defaultCurrency),
+	}
+	for _, spec := range specs {
+		field, column, ok := strings.Cut(spec, "=")
+		field, column = strings.TrimSpace(field), strings.ToLower(strings.TrimSpace(column))
+		if !ok || column == "" {
+			return nil, fmt.Errorf("-map %q: want field=column", spec)
+		}
+		if name, ok := optionName(field); ok {
+			m.options[name] = column
+			continue
+		}
+		field = strings.ToLower(field)
+		if !slices.Contains(fields, field) {
+			return nil, fmt.Errorf("-map %q: unknown field %q, want one of %s or %s<name>", spec, field, strings.Join(fields, ", "), optionPrefix)
+		}
+		m.columns[field] = column
+	}
+	return m, nil
+}
+
+// optionName returns <name> for a column or field named option:<name>.
+func optionName(column string) (string, bool) {
+	if len(column) <= len(optionPrefix) || !strings.EqualFold(column[:len(optionPrefix)], optionPrefix) {
+		return "", false
+	}
+	name := strings.TrimSpace(column[len(optionPrefix):])
+	return name, name != ""
+}
+
+// column returns the column of field.
+func (m *mapping) column(field string) string {
+	if c, ok := m.columns[field]; ok {
+		return c
+	}
+	return strings.ToLower(field)
+}
+
+// check fails when the header of a CSV file lacks a column needed for
+// every row, before anything is imported.
+func (m *mapping) check(header map[string]bool) error {
+	required := []string{"name", "price"}
+	if m.defaultCurrency == "" {
+		required = append(required, "currency")
+	}
+	for _, field := range required {
+		if !header[m.column(field)] {
+			return fmt.Errorf("no column %q for %s; name it with -map %s=COLUMN", m.column(field), field, field)
+		}
+	}
+	if !header[m.column("sku")] && !header[m.column("product_id")] {
+		return fmt.Errorf("no column %q for sku nor %q for product_id", m.column("sku"), m.column("product_id"))
+	}
+	return nil
+}
+
+// record turns r into an import record.
+func (m *mapping) record(r *row) (*catalog.ImportRecord, error) {
+	get := func(field string) string {
+		return strings.TrimSpace(r.values[m.column(field)])
+	}
+	rec := &catalog.ImportRecord{Line: r.line}
+	rec.SKU = get("sku")
+	rec.ProductID = get("product_id")
+	rec.Name = get("name")
+	rec.Description = get("description")
+	rec.Barcode = get("barcode")
+	fail := func(format string, args ...any) error {
+		return &rowError{line: r.line, sku: rec.SKU, err: fmt.Errorf(format, args...)}
+	}
+
+	currency := get("currency")
+	if currency == "" {
+		currency = m.defaultCurrency
+	}
+	price, err := money.Parse(get("price"), currency)
+	if err != nil {
+		return nil, fail("price %q %s: %v", get("price"), currency, err)
+	}
+	rec.Price = price
+	if s := get("price_override"); s != "" {
+		override, err := money.Parse(s, currency)
+		if err != nil {
+			return nil, fail("price_override %q %s: %v", s, currency, err)
+		}
+		rec.PriceOverride = &override
+	}
+
+	rec.Options = r.options
+	if rec.Options == nil {
+		if rec.Options, err = parseOptions(get("options")); err != nil {
+			return nil, fail("options: %v", err)
+		}
+	}
+	for name, column := range m.optionColumns(r) {
+		value := strings.TrimSpace(r.values[column])
+		if value == "" {
+			continue
+		}
+		if _, ok := rec.Options[name]; ok {
+			return nil, fail("option %s is given twice", name)
+		}
+		rec.Options[name] = value
+	}
+	return rec, nil
+}
+
+// optionColumns maps option names to the columns of r holding them: the
+// mapped ones and the columns named option:<name> that are not mapped.
+func (m *mapping) optionColumns(r *row) map[string]string {
+	out := make(map[string]string, len(m.options)+len(r.optionNames))
+	for column, name := range r.optionNames {
+		out[name] = column
+	}
+	for name, column := range m.options {
+		for other, c := range out {
+			if c == column {
+				delete(out, other)
+			}
+		}
+		out[name] = column
+	}
+	return out
+}
+
+// parseOptions reads options written as "Color=Red; Size=M".
+func parseOptions(s string) (map[string]string, error) {
+	options := map[string]string{}
+	for _, pair := range strings.Split(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("%q is not name=value", strings.TrimSpace(pair))
+		}
+		if _, ok := options[name]; ok {
+			return nil, fmt.Errorf("option %s is given twice", name)
+		}
+		options[name] = value
+	}
+	return options, nil
+}
+
+// formatOptions writes options like parseOptions reads them, by name.
+func formatOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+options[name])
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// rowReader reads the rows of a file; it returns io.EOF at the end.
+type rowReader interface {
+	Read() (*row, error)
+}
+
+// csvReader reads a CSV file whose first line names the columns.
+type csvReader struct {
+	r           *csv.Reader
+	header      []string
+	optionNames map[string]string
+}
+
+func newCSVReader(r io.Reader, m *mapping) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	optionNames := map[string]string{}
+	for i, name := range header {
+		// Spreadsheets like to start files with a byte order mark
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		column := strings.ToLower(name)
+		header[i] = column
+		if column == "" {
+			continue
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		seen[column] = true
+		if option, ok := optionName(name); ok {
+			optionNames[column] = option
+		}
+	}
+	if err := m.check(seen); err != nil {
+		return nil, err
+	}
+	return &csvReader{r: cr, header: header, optionNames: optionNames}, nil
+}
+
+func (c *csvReader) Read() (*row, error) {
+	for {
+		cells, err := c.r.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, &rowError{line: int64(parseErr.StartLine), err: parseErr.Err}
+			}
+			return nil, err
+		}
+		line, _ := c.r.FieldPos(0)
+
+		r := &row{line: int64(line), values: make(map[string]string, len(c.header)), optionNames: c.optionNames}
+		blank := true
+		for i, cell := range cells {
+			empty := strings.TrimSpace(cell) == ""
+			if i >= len(c.header) && !empty {
+				return nil, &rowError{line: r.line, err: fmt.Errorf("cell %d is outside the %d columns", i+1, len(c.header))}
+			}
+			if i < len(c.header) {
+				r.values[c.header[i]] = cell
+			}
+			blank = blank && empty
+		}
+		// Spreadsheets often end with empty rows
+		if !blank {
+			return r, nil
+		}
+	}
+}
+
+// jsonlReader reads one JSON object per line. Values are strings, numbers
+// or booleans; options may also be an object of strings.
+type jsonlReader struct {
+	s       *bufio.Scanner
+	line    int64
+	options string
+}
+
+func newJSONLReader(r io.Reader, m *mapping) *jsonlReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64<<10), 1<<20)
+	return &jsonlReader{s: s, options: m.column("options")}
+}
+
+func (j *jsonlReader) Read() (*row, error) {
+	for j.s.Scan() {
+		j.line++
+		text := strings.TrimSpace(j.s.Text())
+		if text == "" {
+			continue
+		}
+
+		d := json.NewDecoder(strings.NewReader(text))
+		d.UseNumber()
+		var object map[string]any
+		if err := d.Decode(&object); err != nil {
+			return nil, &rowError{line: j.line, err: fmt.Errorf("invalid JSON: %v", err)}
+		}
+
+		r := &row{line: j.line, values: make(map[string]string, len(object)), optionNames: map[string]string{}}
+		for name, value := range object {
+			key := strings.ToLower(name)
+			if option, ok := optionName(name); ok {
+				r.optionNames[key] = option
+			}
+			switch v := value.(type) {
+			case nil:
+			case string:
+				r.values[key] = v
+			case json.Number:
+				r.values[key] = v.String()
+			case bool:
+				r.values[key] = fmt.Sprint(v)
+			case map[string]any:
+				if key != j.options {
+					return nil, &rowError{line: j.line, err: fmt.Errorf("%s: only options can be an object", key)}
+				}
+				r.options = make(map[string]string, len(v))
+				for name, value := range v {
+					s, ok := value.(string)
+					if !ok {
+						return nil, &rowError{line: j.line, err: fmt.Errorf("option %s: value must be a string", name)}
+					}
+					r.options[name] = s
+				}
+			default:
+				return nil, &rowError{line: j.line, err: fmt.Errorf("%s: unexpected %T", key, value)}
+			}
+		}
+		return r, nil
+	}
+	if err := j.s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// recordWriter writes exported records in one of the formats.
+type recordWriter interface {
+	Write(records []catalog.ProductRecord) error
+	Flush() error
+}
+
+// csvWriter writes the fields as columns, options as "Color=Red; Size=M".
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw}, nil
+}
+
+func (c *csvWriter) Write(records []catalog.ProductRecord) error {
+	for _, r := range records {
+		var override string
+		if r.PriceOverride != nil {
+			override = r.PriceOverride.Decimal()
+		}
+		err := c.w.Write([]string{
+			r.SKU, r.ProductID, r.Name, r.Description, r.Price.Decimal(), r.Price.Currency,
+			override, r.Barcode, formatOptions(r.Options),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter writes a JSON object per record, options as an object.
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// jsonlRecord is the object of a record in a JSON Lines export.
+type jsonlRecord struct {
+	SKU           string            `json:"sku,omitempty"`
+	ProductID     string            `json:"product_id"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Price         string            `json:"price"`
+	Currency      string            `json:"currency"`
+	PriceOverride string            `json:"price_override,omitempty"`
+	Barcode       string            `json:"barcode,omitempty"`
+	Options       map[string]string `json:"options,omitempty"`
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{w: bw, enc: enc}
+}
+
+func (j *jsonlWriter) Write(records []catalog.ProductRecord) error {
+	for _, r := range records {
+		out := jsonlRecord{
+			SKU:         r.SKU,
+			ProductID:   r.ProductID,
+			Name:        r.Name,
+			Description: r.Description,
+			Price:       r.Price.Decimal(),
+			Currency:    r.Price.Currency,
+			Barcode:     r.Barcode,
+			Options:     r.Options,
+		}
+		if r.PriceOverride != nil {
+			out.PriceOverride = r.PriceOverride.Decimal()
+		}
+		if err := j.enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
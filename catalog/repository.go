@@ -1,15 +1,1824 @@
 package catalog
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
+
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/postgres"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// Product repository interface
 type Repository interface {
-    Close()
-    PutProduct(ctx context.Context, p Product) error
-    GetProductByID(ctx context.Context, id string) (*Product, error)
-    ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
-    DeleteProduct(ctx context.Context, id string) error
+	Close()
+
+	// Create or Update a product, recording change in its price history in
+	// the same transaction when it is set
+	PutProduct(ctx context.Context, p Product, change *PriceChange) error
+
+	// Fetch one product by ID
+	GetProductByID(ctx context.Context, id string) (*Product, error)
+
+	// Fetch every product whose ID is in ids; missing IDs are simply absent
+	GetProductsByIDs(ctx context.Context, ids []string) ([]Product, error)
+
+	// List products with pagination (skip = offset, take = limit)
+	ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
+
+	// Full-text search over name and description, most relevant first.
+	// Returns one page of hits and the number of hits over all pages.
+	SearchProducts(ctx context.Context, q SearchQuery) ([]SearchHit, uint64, error)
+
+	// Search by trigram similarity of the name, most similar first, for
+	// queries whose words match nothing (typically misspelled ones)
+	SearchProductsFuzzy(ctx context.Context, q SearchQuery) ([]SearchHit, uint64, error)
+
+	// Delete product by ID
+	DeleteProduct(ctx context.Context, id string) error
+
+	// Create or update a category
+	PutCategory(ctx context.Context, c Category) error
+
+	// Fetch one category by ID or by slug; (nil, nil) when it does not exist
+	GetCategoryByID(ctx context.Context, id string) (*Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*Category, error)
+
+	// List the children of parentID in order, or the roots when it is empty
+	ListCategories(ctx context.Context, parentID string) ([]Category, error)
+
+	// Fetch the path from the root down to every category of ids, keyed by ID
+	GetCategoryPaths(ctx context.Context, ids []string) (map[string][]Category, error)
+
+	// Delete a category without subcategories
+	DeleteCategory(ctx context.Context, id string) error
+
+	// Replace the categories a product is assigned to
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error
+
+	// Fetch the categories of every product of productIDs, keyed by product ID
+	GetProductCategories(ctx context.Context, productIDs []string) (map[string][]Category, error)
+
+	// List the products of a category and of all its descendants
+	ListProductsByCategory(ctx context.Context, categoryID string, skip uint64, take uint64) ([]Product, error)
+
+	// Create or update a variant
+	PutVariant(ctx context.Context, v Variant) error
+
+	// Fetch every variant whose ID, or SKU, is in the list; missing ones are simply absent
+	GetVariantsByIDs(ctx context.Context, ids []string) ([]Variant, error)
+	GetVariantsBySKUs(ctx context.Context, skus []string) ([]Variant, error)
+
+	// Fetch the variants of every product of productIDs, in order
+	GetProductVariants(ctx context.Context, productIDs []string) ([]Variant, error)
+
+	// Delete variant by ID
+	DeleteVariant(ctx context.Context, id string) error
+
+	// Set, or change by delta, the on-hand quantity of an item and start tracking it
+	SetStock(ctx context.Context, item StockItem, onHand int64) error
+	AdjustStock(ctx context.Context, item StockItem, delta int64) error
+
+	// Fetch the stock of every tracked item of productIDs
+	GetStockLevels(ctx context.Context, productIDs []string) ([]StockLevel, error)
+
+	// Hold items for ttl, all of them or none
+	ReserveStock(ctx context.Context, id string, items []StockItem, ttl time.Duration) (*Reservation, error)
+
+	// Take the items of a reservation out of stock, or give them back
+	CommitReservation(ctx context.Context, id string) (*Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) (*Reservation, error)
+
+	// Put the items of a committed reservation back into stock
+	ReturnReservation(ctx context.Context, id string) (*Reservation, error)
+
+	// Set or remove the list price of an item in one currency
+	PutListPrice(ctx context.Context, l ListPrice) error
+	DeleteListPrice(ctx context.Context, itemID, currency string) error
+
+	// Fetch the list prices of every product of productIDs and of their
+	// variants, in currency or in every currency when it is empty
+	GetListPrices(ctx context.Context, productIDs []string, currency string) ([]ListPrice, error)
+
+	// Store rates against the base currency, and fetch them all
+	PutExchangeRates(ctx context.Context, rates []ExchangeRate) error
+	GetExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+
+	// Record a new image after the last one of its product, setting its
+	// Position
+	PutProductImage(ctx context.Context, img *ProductImage) error
+
+	// Change the alt text of an image and return it
+	UpdateProductImage(ctx context.Context, id, altText string) (*ProductImage, error)
+
+	// Number the images of a product in the order of imageIDs
+	ReorderProductImages(ctx context.Context, productID string, imageIDs []string) error
+
+	// Delete the record of an image and return it, so its files can go too
+	DeleteProductImage(ctx context.Context, id string) (*ProductImage, error)
+
+	// Fetch the images of every product of productIDs, ordered by product
+	// and position
+	GetProductImages(ctx context.Context, productIDs []string) ([]ProductImage, error)
+
+	// Record a scheduled price, setting its CreatedAt, and its EffectiveFrom
+	// when it is zero
+	PutPriceChange(ctx context.Context, c *PriceChange) error
+
+	// Delete a scheduled price that has not started, or end a running one
+	// now. It returns the product of the price and whether it was running.
+	CancelScheduledPrice(ctx context.Context, id string) (productID string, running bool, err error)
+
+	// Fetch the products whose scheduled prices started or ended after
+	// since, up to the time of the database, which is returned as well
+	GetPriceTransitions(ctx context.Context, since time.Time) (productIDs []string, until time.Time, err error)
+
+	// Fetch the price history of every product of productIDs, ordered by
+	// product and latest effective first
+	GetPriceHistory(ctx context.Context, productIDs []string) ([]PriceChange, error)
+
+	// Create a review
+	PutReview(ctx context.Context, r Review) error
+
+	// Set the status of a review, moving its rating in or out of the rating
+	// of its product, and return it
+	ModerateReview(ctx context.Context, id string, status ReviewStatus) (*Review, error)
+
+	// Fetch a page of the APPROVED reviews of every product of productIDs,
+	// ordered by product and newest first
+	GetProductReviews(ctx context.Context, productIDs []string, skip uint64, take uint64) ([]Review, error)
+
+	// List the reviews in status, or in any status when it is empty, newest
+	// first, with LIMIT + OFFSET
+	ListReviews(ctx context.Context, status ReviewStatus, skip uint64, take uint64) ([]Review, error)
+}
+
+// SQLSTATE codes of the constraint violations the repository translates
+const (
+	foreignKeyViolation pq.ErrorCode = "23503"
+	uniqueViolation     pq.ErrorCode = "23505"
+	checkViolation      pq.ErrorCode = "23514"
+)
+
+// violation reports whether err is a violation of the given kind, and of
+// which constraint.
+func violation(err error, code pq.ErrorCode) (constraint string, ok bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == code {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
+
+// postgresRepositry implements the Repository interface.
+// It provides all DB operations for products using PostgreSQL.
+type postgresRepositry struct {
+	db      *sql.DB
+	timeout time.Duration
+
+	// Prepared once, used by every request
+	getProductByID *sql.Stmt
+}
+
+// productColumns are scanned by scanProduct. Products are read from the
+// priced_products view, which resolves the price in effect at query time.
+const productColumns = "id, name, description, price, currency, regular_price, rating_count, rating_sum"
+
+// getProductByIDQuery is the hottest query of the service: every order
+// prices its products through it.
+const getProductByIDQuery = "SELECT " + productColumns + " FROM priced_products WHERE id = $1"
+
+// NewPostgresRepositry connects to PostgreSQL with the pool settings of cfg
+// and returns a repository instance.
+func NewPostgresRepositry(url string, cfg postgres.Config) (Repository, error) {
+	db, err := postgres.Open("catalog", url, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &postgresRepositry{db: db, timeout: cfg.QueryTimeout}
+
+	ctx, cancel := postgres.WithTimeout(context.Background(), cfg.QueryTimeout)
+	defer cancel()
+	err = postgres.Prepare(ctx, db, map[string]**sql.Stmt{
+		getProductByIDQuery: &r.getProductByID,
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *postgresRepositry) Close() {
+	r.getProductByID.Close()
+	r.db.Close()
+}
+
+func (r *postgresRepositry) Ping() error {
+	return r.db.Ping()
+}
+
+// PutProduct inserts or updates a product (UPSERT logic). A price change
+// is recorded along, effective at the time of the database.
+func (r *postgresRepositry) PutProduct(ctx context.Context, p Product, change *PriceChange) (err error) {
+	const query = "INSERT INTO products (id, name, description, price, currency) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, price = EXCLUDED.price, currency = EXCLUDED.currency"
+	ctx, span := tracing.StartDBSpan(ctx, "products", "PutProduct", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if change == nil {
+		_, err = r.db.ExecContext(ctx, query, p.ID, p.Name, p.Description, p.Price.Amount, p.Price.Currency)
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if _, err = tx.ExecContext(ctx, query, p.ID, p.Name, p.Description, p.Price.Amount, p.Price.Currency); err != nil {
+		return err
+	}
+	return insertPriceChange(ctx, tx, change)
+}
+
+// GetProductByID fetches a single product by ID.
+// It returns (nil, nil) if no row exists.
+func (r *postgresRepositry) GetProductByID(ctx context.Context, id string) (_ *Product, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "products", "GetProductByID", getProductByIDQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p, err := scanProduct(r.getProductByID.QueryRowContext(ctx, id))
+
+	// If no row found, return nil instead of error
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetProductsByIDs fetches a batch of products in one round-trip.
+func (r *postgresRepositry) GetProductsByIDs(ctx context.Context, ids []string) (_ []Product, err error) {
+	const query = "SELECT " + productColumns + " FROM priced_products WHERE id = ANY($1)"
+	ctx, span := tracing.StartDBSpan(ctx, "products", "GetProductsByIDs", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ListProducts returns paginated products using LIMIT + OFFSET.
+func (r *postgresRepositry) ListProducts(ctx context.Context, skip uint64, take uint64) (_ []Product, err error) {
+	const query = "SELECT " + productColumns + " FROM priced_products ORDER BY id OFFSET $1 LIMIT $2"
+	ctx, span := tracing.StartDBSpan(ctx, "products", "ListProducts", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, skip, take)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// Options of ts_headline: the whole name is returned, but only the best
+// fragments of the description. Matched words are wrapped in <mark></mark>.
+const (
+	nameHeadlineOptions        = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=20"
+)
+
+// searchMatch is the FROM and WHERE clauses shared by the count and the page
+// of a full-text search.
+// websearch_to_tsquery accepts what users type: "quoted phrases", or, -excluded.
+const searchMatch = `FROM priced_products, websearch_to_tsquery('english', $1) tsq
+	WHERE search_vector @@ tsq
+	AND ($2::bigint IS NULL OR price >= $2)
+	AND ($3::bigint IS NULL OR price <= $3)
+	AND ($4::text = '' OR currency = $4)`
+
+// fuzzyMatch is the FROM and WHERE clauses of a trigram search: products
+// with a word of their name similar to the query, per
+// pg_trgm.word_similarity_threshold (0.6 by default).
+const fuzzyMatch = `FROM priced_products
+	WHERE $1 <% name
+	AND ($2::bigint IS NULL OR price >= $2)
+	AND ($3::bigint IS NULL OR price <= $3)
+	AND ($4::text = '' OR currency = $4)`
+
+// SearchProducts ranks the matches with ts_rank_cd, name matches weighing
+// more than description matches. Headlines are only computed for the page.
+func (r *postgresRepositry) SearchProducts(ctx context.Context, q SearchQuery) (_ []SearchHit, _ uint64, err error) {
+	const query = `SELECT ` + productColumns + `, rank,
+		ts_headline('english', name, tsq, '` + nameHeadlineOptions + `'),
+		ts_headline('english', description, tsq, '` + descriptionHeadlineOptions + `')
+	FROM (
+		SELECT ` + productColumns + `, ts_rank_cd(search_vector, tsq) AS rank, tsq
+		` + searchMatch + `
+		ORDER BY rank DESC, id
+		OFFSET $5 LIMIT $6
+	) page
+	ORDER BY rank DESC, id`
+	ctx, span := tracing.StartDBSpan(ctx, "products", "SearchProducts", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.search(ctx, "SELECT count(*) "+searchMatch, query, q)
+}
+
+// SearchProductsFuzzy ranks the matches by word similarity to the query.
+// Nothing is highlighted; the description is cut to 200 characters instead.
+func (r *postgresRepositry) SearchProductsFuzzy(ctx context.Context, q SearchQuery) (_ []SearchHit, _ uint64, err error) {
+	const query = `SELECT ` + productColumns + `, word_similarity($1, name) AS rank,
+		name, left(description, 200)
+	` + fuzzyMatch + `
+	ORDER BY rank DESC, id
+	OFFSET $5 LIMIT $6`
+	ctx, span := tracing.StartDBSpan(ctx, "products", "SearchProductsFuzzy", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.search(ctx, "SELECT count(*) "+fuzzyMatch, query, q)
+}
+
+// priceBound is a search price bound as a query argument: its minor units,
+// or NULL when the bound is not set.
+func priceBound(m *money.Money) interface{} {
+	if m == nil {
+		return nil
+	}
+	return m.Amount
+}
+
+// search counts the hits with countQuery, then fetches the requested page
+// with pageQuery unless it is past the last hit. Both take the query text,
+// the price bounds in minor units and their currency as $1 to $4; pageQuery
+// takes skip and take as $5 and $6.
+func (r *postgresRepositry) search(ctx context.Context, countQuery, pageQuery string, q SearchQuery) ([]SearchHit, uint64, error) {
+	minPrice, maxPrice, currency := priceBound(q.Filters.MinPrice), priceBound(q.Filters.MaxPrice), q.Filters.currency()
+
+	var total uint64
+	err := r.db.QueryRowContext(ctx, countQuery, q.Text, minPrice, maxPrice, currency).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := []SearchHit{}
+	if total <= q.Skip {
+		return hits, total, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, pageQuery, q.Text, minPrice, maxPrice, currency, q.Skip, q.Take)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		h := SearchHit{}
+		var regularPrice sql.NullInt64
+		var ratingSum int64
+		p := &h.Product
+		err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Price.Amount, &p.Price.Currency, &regularPrice,
+			&p.RatingCount, &ratingSum, &h.Rank, &h.NameHighlight, &h.DescriptionHighlight)
+		if err != nil {
+			return nil, 0, err
+		}
+		p.RegularPrice = optionalMoney(regularPrice, p.Price.Currency)
+		p.AverageRating = averageRating(ratingSum, p.RatingCount)
+		hits = append(hits, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+// DeleteProduct removes a product by ID.
+func (r *postgresRepositry) DeleteProduct(ctx context.Context, id string) (err error) {
+	const query = "DELETE FROM products WHERE id = $1"
+	ctx, span := tracing.StartDBSpan(ctx, "products", "DeleteProduct", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// scanProduct reads the productColumns of one row.
+func scanProduct(row interface{ Scan(...any) error }) (*Product, error) {
+	p := &Product{}
+	var regularPrice sql.NullInt64
+	var ratingSum int64
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Price.Amount, &p.Price.Currency, &regularPrice,
+		&p.RatingCount, &ratingSum)
+	if err != nil {
+		return nil, err
+	}
+	p.RegularPrice = optionalMoney(regularPrice, p.Price.Currency)
+	p.AverageRating = averageRating(ratingSum, p.RatingCount)
+	return p, nil
+}
+
+// averageRating is the mean of count ratings adding up to sum, 0 without
+// any.
+func averageRating(sum, count int64) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
+
+// optionalMoney is a nullable amount of currency.
+func optionalMoney(amount sql.NullInt64, currency string) *money.Money {
+	if !amount.Valid {
+		return nil
+	}
+	return &money.Money{Amount: amount.Int64, Currency: currency}
+}
+
+// categoryColumns are scanned by scanCategory, in this order.
+const categoryColumns = "id, COALESCE(parent_id, ''), name, slug, position"
+
+// PutCategory inserts or updates a category. A slug used by another
+// category fails with ErrSlugTaken, a missing parent with ErrParentNotFound.
+func (r *postgresRepositry) PutCategory(ctx context.Context, c Category) (err error) {
+	const query = "INSERT INTO categories (id, parent_id, name, slug, position) VALUES ($1, NULLIF($2, ''), $3, $4, $5) ON CONFLICT (id) DO UPDATE SET parent_id = EXCLUDED.parent_id, name = EXCLUDED.name, slug = EXCLUDED.slug, position = EXCLUDED.position"
+	ctx, span := tracing.StartDBSpan(ctx, "categories", "PutCategory", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, c.ID, c.ParentID, c.Name, c.Slug, c.Position)
+	if _, ok := violation(err, uniqueViolation); ok {
+		return ErrSlugTaken
+	}
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrParentNotFound
+	}
+	return err
+}
+
+// GetCategoryByID fetches a single category by ID.
+// It returns (nil, nil) if no row exists.
+func (r *postgresRepositry) GetCategoryByID(ctx context.Context, id string) (*Category, error) {
+	return r.getCategory(ctx, "GetCategoryByID", "SELECT "+categoryColumns+" FROM categories WHERE id = $1", id)
+}
+
+// GetCategoryBySlug fetches a single category by slug.
+// It returns (nil, nil) if no row exists.
+func (r *postgresRepositry) GetCategoryBySlug(ctx context.Context, slug string) (*Category, error) {
+	return r.getCategory(ctx, "GetCategoryBySlug", "SELECT "+categoryColumns+" FROM categories WHERE slug = $1", slug)
+}
+
+func (r *postgresRepositry) getCategory(ctx context.Context, operation, query string, arg string) (_ *Category, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "categories", operation, query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	c := &Category{}
+	err = r.db.QueryRowContext(ctx, query, arg).Scan(&c.ID, &c.ParentID, &c.Name, &c.Slug, &c.Position)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListCategories returns the children of parentID, or the root categories
+// when parentID is empty, ordered by position and name.
+func (r *postgresRepositry) ListCategories(ctx context.Context, parentID string) (_ []Category, err error) {
+	const query = "SELECT " + categoryColumns + " FROM categories WHERE parent_id IS NOT DISTINCT FROM NULLIF($1, '') ORDER BY position, name, id"
+	ctx, span := tracing.StartDBSpan(ctx, "categories", "ListCategories", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		c := Category{}
+		if err := rows.Scan(&c.ID, &c.ParentID, &c.Name, &c.Slug, &c.Position); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// maxCategoryDepth bounds the walks up and down the tree. The service
+// never creates cycles, but two concurrent moves could; the walks then stop
+// instead of running forever.
+const maxCategoryDepth = 64
+
+// GetCategoryPaths walks up from every category of ids to its root in one
+// recursive query. Each path starts at the root and ends with the category
+// itself; unknown IDs are absent from the map.
+func (r *postgresRepositry) GetCategoryPaths(ctx context.Context, ids []string) (_ map[string][]Category, err error) {
+	const query = `WITH RECURSIVE path AS (
+		SELECT id AS leaf_id, id, parent_id, name, slug, position, 0 AS depth
+		FROM categories WHERE id = ANY($1)
+		UNION ALL
+		SELECT path.leaf_id, c.id, c.parent_id, c.name, c.slug, c.position, path.depth + 1
+		FROM categories c JOIN path ON c.id = path.parent_id
+		WHERE path.depth < $2
+	)
+	SELECT leaf_id, id, COALESCE(parent_id, ''), name, slug, position FROM path ORDER BY leaf_id, depth DESC`
+	ctx, span := tracing.StartDBSpan(ctx, "categories", "GetCategoryPaths", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), maxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := map[string][]Category{}
+	for rows.Next() {
+		var leafID string
+		c := Category{}
+		if err := rows.Scan(&leafID, &c.ID, &c.ParentID, &c.Name, &c.Slug, &c.Position); err != nil {
+			return nil, err
+		}
+		paths[leafID] = append(paths[leafID], c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// DeleteCategory removes a category and its product assignments. A
+// category with subcategories fails with ErrCategoryHasChildren.
+func (r *postgresRepositry) DeleteCategory(ctx context.Context, id string) (err error) {
+	const query = "DELETE FROM categories WHERE id = $1"
+	ctx, span := tracing.StartDBSpan(ctx, "categories", "DeleteCategory", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, id)
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrCategoryHasChildren
+	}
+	return err
+}
+
+// SetProductCategories replaces the assignments of a product in one
+// transaction. A missing product fails with ErrProductNotFound, a missing
+// category with ErrCategoryNotFound.
+func (r *postgresRepositry) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) (err error) {
+	const (
+		deleteAssignments = "DELETE FROM product_categories WHERE product_id = $1"
+		insertAssignments = "INSERT INTO product_categories (product_id, category_id) SELECT $1, unnest($2::text[])"
+	)
+	ctx, span := tracing.StartDBSpan(ctx, "product_categories", "SetProductCategories", deleteAssignments+"; "+insertAssignments)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	// Lock the product so concurrent assignments apply one after the other
+	var id string
+	err = tx.QueryRowContext(ctx, "SELECT id FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteAssignments, productID); err != nil {
+		return err
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, insertAssignments, productID, pq.Array(categoryIDs))
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+// GetProductCategories fetches the categories of a batch of products in one
+// round-trip, each product's ordered by position and name. Products without
+// categories are absent from the map.
+func (r *postgresRepositry) GetProductCategories(ctx context.Context, productIDs []string) (_ map[string][]Category, err error) {
+	const query = `SELECT pc.product_id, c.id, COALESCE(c.parent_id, ''), c.name, c.slug, c.position
+	FROM product_categories pc JOIN categories c ON c.id = pc.category_id
+	WHERE pc.product_id = ANY($1)
+	ORDER BY pc.product_id, c.position, c.name, c.id`
+	ctx, span := tracing.StartDBSpan(ctx, "product_categories", "GetProductCategories", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := map[string][]Category{}
+	for rows.Next() {
+		var productID string
+		c := Category{}
+		if err := rows.Scan(&productID, &c.ID, &c.ParentID, &c.Name, &c.Slug, &c.Position); err != nil {
+			return nil, err
+		}
+		categories[productID] = append(categories[productID], c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// ListProductsByCategory returns the products assigned to the category or
+// to any of its descendants, each product once, with LIMIT + OFFSET.
+func (r *postgresRepositry) ListProductsByCategory(ctx context.Context, categoryID string, skip uint64, take uint64) (_ []Product, err error) {
+	const query = `WITH RECURSIVE tree AS (
+		SELECT id, 0 AS depth FROM categories WHERE id = $1
+		UNION ALL
+		SELECT c.id, tree.depth + 1 FROM categories c JOIN tree ON c.parent_id = tree.id
+		WHERE tree.depth < $4
+	)
+	SELECT ` + productColumns + ` FROM priced_products p
+	WHERE EXISTS (
+		SELECT 1 FROM product_categories pc JOIN tree ON tree.id = pc.category_id
+		WHERE pc.product_id = p.id
+	)
+	ORDER BY p.id OFFSET $2 LIMIT $3`
+	ctx, span := tracing.StartDBSpan(ctx, "products", "ListProductsByCategory", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, categoryID, skip, take, maxCategoryDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// PutVariant inserts or updates a variant. The product of an existing
+// variant never changes. A SKU or barcode used by another variant fails
+// with ErrSKUTaken or ErrBarcodeTaken, options already used by another
+// variant of the product with ErrDuplicateVariant, and a missing product
+// with ErrProductNotFound.
+func (r *postgresRepositry) PutVariant(ctx context.Context, v Variant) (err error) {
+	const query = `INSERT INTO product_variants (id, product_id, sku, options, price, barcode, position)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	ON CONFLICT (id) DO UPDATE SET sku = EXCLUDED.sku, options = EXCLUDED.options, price = EXCLUDED.price, barcode = EXCLUDED.barcode, position = EXCLUDED.position`
+	ctx, span := tracing.StartDBSpan(ctx, "product_variants", "PutVariant", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return err
+	}
+
+	var price interface{}
+	if v.PriceOverride != nil {
+		price = v.PriceOverride.Amount
+	}
+
+	_, err = r.db.ExecContext(ctx, query, v.ID, v.ProductID, v.SKU, options, price, v.Barcode, v.Position)
+	if constraint, ok := violation(err, uniqueViolation); ok {
+		switch constraint {
+		case "product_variants_sku_key":
+			return ErrSKUTaken
+		case "product_variants_barcode_key":
+			return ErrBarcodeTaken
+		}
+		return ErrDuplicateVariant
+	}
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// variantsQuery selects variants with their effective price, filtered by
+// where. Variant prices are in the currency of their product. Variants of
+// the same product come back together, in order.
+func variantsQuery(where string) string {
+	return "SELECT v.id, v.product_id, v.sku, v.options, v.price, COALESCE(v.price, p.price), p.currency, COALESCE(v.barcode, ''), v.position " +
+		"FROM product_variants v JOIN priced_products p ON p.id = v.product_id " +
+		where + " ORDER BY v.product_id, v.position, v.sku"
+}
+
+// GetVariantsByIDs fetches a batch of variants in one round-trip.
+func (r *postgresRepositry) GetVariantsByIDs(ctx context.Context, ids []string) ([]Variant, error) {
+	return r.queryVariants(ctx, "GetVariantsByIDs", variantsQuery("WHERE v.id = ANY($1)"), pq.Array(ids))
+}
+
+// GetVariantsBySKUs fetches a batch of variants in one round-trip.
+func (r *postgresRepositry) GetVariantsBySKUs(ctx context.Context, skus []string) ([]Variant, error) {
+	return r.queryVariants(ctx, "GetVariantsBySKUs", variantsQuery("WHERE v.sku = ANY($1)"), pq.Array(skus))
+}
+
+// GetProductVariants fetches the variants of a batch of products in one round-trip.
+func (r *postgresRepositry) GetProductVariants(ctx context.Context, productIDs []string) ([]Variant, error) {
+	return r.queryVariants(ctx, "GetProductVariants", variantsQuery("WHERE v.product_id = ANY($1)"), pq.Array(productIDs))
+}
+
+// queryVariants runs a variantsQuery. operation names the tracing span.
+func (r *postgresRepositry) queryVariants(ctx context.Context, operation, query string, arg interface{}) (_ []Variant, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "product_variants", operation, query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := []Variant{}
+	for rows.Next() {
+		v := Variant{}
+		var options []byte
+		var override sql.NullInt64
+		if err := rows.Scan(&v.ID, &v.ProductID, &v.SKU, &options, &override, &v.Price.Amount, &v.Price.Currency, &v.Barcode, &v.Position); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(options, &v.Options); err != nil {
+			return nil, err
+		}
+		if override.Valid {
+			v.PriceOverride = &money.Money{Amount: override.Int64, Currency: v.Price.Currency}
+		}
+		variants = append(variants, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// DeleteVariant removes a variant by ID. Orders keep its ID and SKU.
+func (r *postgresRepositry) DeleteVariant(ctx context.Context, id string) (err error) {
+	const query = "DELETE FROM product_variants WHERE id = $1"
+	ctx, span := tracing.StartDBSpan(ctx, "product_variants", "DeleteVariant", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// SetStock inserts or overwrites the on-hand quantity of an item. A missing
+// product or variant fails with ErrProductNotFound or ErrVariantNotFound.
+func (r *postgresRepositry) SetStock(ctx context.Context, item StockItem, onHand int64) (err error) {
+	const query = `INSERT INTO stock_items (item_id, product_id, variant_id, on_hand) VALUES ($1, $2, NULLIF($3, ''), $4)
+	ON CONFLICT (item_id) DO UPDATE SET on_hand = EXCLUDED.on_hand`
+	return r.putStock(ctx, "SetStock", query, item, onHand)
+}
+
+// AdjustStock adds delta to the on-hand quantity of an item, starting from
+// zero for an untracked one. Going below zero fails with ErrInsufficientStock.
+func (r *postgresRepositry) AdjustStock(ctx context.Context, item StockItem, delta int64) (err error) {
+	const query = `INSERT INTO stock_items (item_id, product_id, variant_id, on_hand) VALUES ($1, $2, NULLIF($3, ''), $4)
+	ON CONFLICT (item_id) DO UPDATE SET on_hand = stock_items.on_hand + EXCLUDED.on_hand`
+	return r.putStock(ctx, "AdjustStock", query, item, delta)
+}
+
+func (r *postgresRepositry) putStock(ctx context.Context, operation, query string, item StockItem, quantity int64) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "stock_items", operation, query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, item.itemID(), item.ProductID, item.VariantID, quantity)
+	if _, ok := violation(err, checkViolation); ok {
+		return ErrInsufficientStock
+	}
+	if constraint, ok := violation(err, foreignKeyViolation); ok {
+		if constraint == "stock_items_variant_id_fkey" {
+			return ErrVariantNotFound
+		}
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// heldQuery adds up, per item matching where, the quantities held by
+// pending reservations that have not expired.
+func heldQuery(where string) string {
+	return "SELECT ri.item_id, SUM(ri.quantity) FROM stock_reservation_items ri " +
+		"JOIN stock_reservations r ON r.id = ri.reservation_id " +
+		where + " AND r.status = 'PENDING' AND r.expires_at > now() GROUP BY ri.item_id"
+}
+
+// GetStockLevels fetches the stock of the tracked items of a batch of
+// products in one round-trip.
+func (r *postgresRepositry) GetStockLevels(ctx context.Context, productIDs []string) (_ []StockLevel, err error) {
+	query := "SELECT s.product_id, COALESCE(s.variant_id, ''), s.on_hand, COALESCE(held.quantity, 0) " +
+		"FROM stock_items s LEFT JOIN (" +
+		heldQuery("WHERE ri.item_id IN (SELECT item_id FROM stock_items WHERE product_id = ANY($1))") +
+		") AS held (item_id, quantity) ON held.item_id = s.item_id " +
+		"WHERE s.product_id = ANY($1) ORDER BY s.product_id, s.item_id"
+	ctx, span := tracing.StartDBSpan(ctx, "stock_items", "GetStockLevels", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := []StockLevel{}
+	for rows.Next() {
+		l := StockLevel{}
+		if err := rows.Scan(&l.ProductID, &l.VariantID, &l.OnHand, &l.Reserved); err != nil {
+			return nil, err
+		}
+		levels = append(levels, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// ReserveStock holds the tracked items among items in one transaction.
+// Their stock rows are locked in ID order, so concurrent reservations and
+// commits of the same items apply one after the other and cannot deadlock.
+// A tracked item with less available than asked fails with
+// ErrInsufficientStock, a variant of another product with ErrVariantMismatch.
+func (r *postgresRepositry) ReserveStock(ctx context.Context, id string, items []StockItem, ttl time.Duration) (_ *Reservation, err error) {
+	const (
+		lockStock         = "SELECT item_id, product_id, on_hand FROM stock_items WHERE item_id = ANY($1) ORDER BY item_id FOR UPDATE"
+		insertReservation = "INSERT INTO stock_reservations (id, status, created_at, expires_at) VALUES ($1, 'PENDING', now(), now() + $2 * interval '1 millisecond') RETURNING created_at, expires_at"
+		insertItems       = "INSERT INTO stock_reservation_items (reservation_id, item_id, quantity) SELECT $1, unnest($2::text[]), unnest($3::int[])"
+	)
+	held := heldQuery("WHERE ri.item_id = ANY($1)")
+	ctx, span := tracing.StartDBSpan(ctx, "stock_reservations", "ReserveStock", lockStock+"; "+held+"; "+insertReservation+"; "+insertItems)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.itemID())
+	}
+
+	type stock struct {
+		productID string
+		available int64
+	}
+	tracked := map[string]*stock{}
+
+	rows, err := tx.QueryContext(ctx, lockStock, pq.Array(itemIDs))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var itemID string
+		st := &stock{}
+		if err = rows.Scan(&itemID, &st.productID, &st.available); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tracked[itemID] = st
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, held, pq.Array(itemIDs))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var itemID string
+		var quantity int64
+		if err = rows.Scan(&itemID, &quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if st, ok := tracked[itemID]; ok {
+			st.available -= quantity
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := &Reservation{ID: id, Status: ReservationPending, Items: []StockItem{}}
+	heldIDs, quantities := []string{}, []int64{}
+	for _, item := range items {
+		st, ok := tracked[item.itemID()]
+		if !ok {
+			continue
+		}
+		if st.productID != item.ProductID {
+			return nil, ErrVariantMismatch
+		}
+		if st.available < int64(item.Quantity) {
+			return nil, fmt.Errorf("%w: %d of %s available", ErrInsufficientStock, max(st.available, 0), item.itemID())
+		}
+		res.Items = append(res.Items, item)
+		heldIDs = append(heldIDs, item.itemID())
+		quantities = append(quantities, int64(item.Quantity))
+	}
+
+	err = tx.QueryRowContext(ctx, insertReservation, id, ttl.Milliseconds()).Scan(&res.CreatedAt, &res.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(heldIDs) > 0 {
+		if _, err = tx.ExecContext(ctx, insertItems, id, pq.Array(heldIDs), pq.Array(quantities)); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// CommitReservation takes the items of a pending reservation out of on-hand
+// stock and marks it committed, in one transaction. Committing it again
+// returns it unchanged; a released, expired or returned one fails with
+// ErrReservationReleased, ErrReservationExpired or ErrReservationReturned.
+func (r *postgresRepositry) CommitReservation(ctx context.Context, id string) (_ *Reservation, err error) {
+	const (
+		lockStock   = "SELECT item_id FROM stock_items WHERE item_id IN (SELECT item_id FROM stock_reservation_items WHERE reservation_id = $1) ORDER BY item_id FOR UPDATE"
+		updateStock = "UPDATE stock_items s SET on_hand = GREATEST(s.on_hand - ri.quantity, 0) FROM stock_reservation_items ri WHERE ri.reservation_id = $1 AND ri.item_id = s.item_id"
+		commit      = "UPDATE stock_reservations SET status = 'COMMITTED' WHERE id = $1"
+	)
+	return r.closeReservation(ctx, "CommitReservation", id, func(tx *sql.Tx, res *Reservation) error {
+		switch res.Status {
+		case ReservationCommitted:
+			return nil
+		case ReservationReleased:
+			return ErrReservationReleased
+		case ReservationExpired:
+			return ErrReservationExpired
+		case ReservationReturned:
+			return ErrReservationReturned
+		}
+
+		// Same lock order as ReserveStock
+		if _, err := tx.ExecContext(ctx, lockStock, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, updateStock, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, commit, id); err != nil {
+			return err
+		}
+		res.Status = ReservationCommitted
+		return nil
+	}, lockStock, updateStock, commit)
+}
+
+// ReleaseReservation marks a pending reservation released, which gives its
+// items back. Releasing a released or expired one returns it unchanged; a
+// committed or returned one fails with ErrReservationCommitted.
+func (r *postgresRepositry) ReleaseReservation(ctx context.Context, id string) (_ *Reservation, err error) {
+	const release = "UPDATE stock_reservations SET status = 'RELEASED' WHERE id = $1"
+	return r.closeReservation(ctx, "ReleaseReservation", id, func(tx *sql.Tx, res *Reservation) error {
+		switch res.Status {
+		case ReservationReleased, ReservationExpired:
+			return nil
+		case ReservationCommitted, ReservationReturned:
+			return ErrReservationCommitted
+		}
+
+		if _, err := tx.ExecContext(ctx, release, id); err != nil {
+			return err
+		}
+		res.Status = ReservationReleased
+		return nil
+	}, release)
+}
+
+// ReturnReservation adds the items of a committed reservation back to
+// on-hand stock and marks it returned, in one transaction. Returning it
+// again returns it unchanged; one that was never committed fails with
+// ErrReservationPending, ErrReservationReleased or ErrReservationExpired.
+func (r *postgresRepositry) ReturnReservation(ctx context.Context, id string) (_ *Reservation, err error) {
+	const (
+		lockStock    = "SELECT item_id FROM stock_items WHERE item_id IN (SELECT item_id FROM stock_reservation_items WHERE reservation_id = $1) ORDER BY item_id FOR UPDATE"
+		updateStock  = "UPDATE stock_items s SET on_hand = s.on_hand + ri.quantity FROM stock_reservation_items ri WHERE ri.reservation_id = $1 AND ri.item_id = s.item_id"
+		markReturned = "UPDATE stock_reservations SET status = 'RETURNED' WHERE id = $1"
+	)
+	return r.closeReservation(ctx, "ReturnReservation", id, func(tx *sql.Tx, res *Reservation) error {
+		switch res.Status {
+		case ReservationReturned:
+			return nil
+		case ReservationPending:
+			return ErrReservationPending
+		case ReservationReleased:
+			return ErrReservationReleased
+		case ReservationExpired:
+			return ErrReservationExpired
+		}
+
+		// Same lock order as ReserveStock
+		if _, err := tx.ExecContext(ctx, lockStock, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, updateStock, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, markReturned, id); err != nil {
+			return err
+		}
+		res.Status = ReservationReturned
+		return nil
+	}, lockStock, updateStock, markReturned)
+}
+
+// closeReservation locks the reservation id, reads it with its items and
+// hands it to apply, in one transaction. A missing reservation fails with
+// ErrReservationNotFound. statements are traced along with the reads.
+func (r *postgresRepositry) closeReservation(ctx context.Context, operation, id string, apply func(*sql.Tx, *Reservation) error, statements ...string) (_ *Reservation, err error) {
+	const (
+		lockReservation = "SELECT status, created_at, expires_at, expires_at <= now() FROM stock_reservations WHERE id = $1 FOR UPDATE"
+		selectItems     = "SELECT s.product_id, COALESCE(s.variant_id, ''), ri.quantity FROM stock_reservation_items ri JOIN stock_items s ON s.item_id = ri.item_id WHERE ri.reservation_id = $1 ORDER BY ri.item_id"
+	)
+	ctx, span := tracing.StartDBSpan(ctx, "stock_reservations", operation, strings.Join(append([]string{lockReservation, selectItems}, statements...), "; "))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	res := &Reservation{ID: id, Items: []StockItem{}}
+	var expired bool
+	err = tx.QueryRowContext(ctx, lockReservation, id).Scan(&res.Status, &res.CreatedAt, &res.ExpiresAt, &expired)
+	if err == sql.ErrNoRows {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expired && res.Status == ReservationPending {
+		res.Status = ReservationExpired
+	}
+
+	rows, err := tx.QueryContext(ctx, selectItems, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		item := StockItem{}
+		if err = rows.Scan(&item.ProductID, &item.VariantID, &item.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		res.Items = append(res.Items, item)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = apply(tx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PutListPrice inserts or overwrites the list price of an item in one
+// currency. A missing product or variant fails with ErrProductNotFound or
+// ErrVariantNotFound.
+func (r *postgresRepositry) PutListPrice(ctx context.Context, l ListPrice) (err error) {
+	const query = `INSERT INTO list_prices (item_id, product_id, variant_id, currency, price) VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+	ON CONFLICT (item_id, currency) DO UPDATE SET price = EXCLUDED.price`
+	ctx, span := tracing.StartDBSpan(ctx, "list_prices", "PutListPrice", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, l.itemID(), l.ProductID, l.VariantID, l.Price.Currency, l.Price.Amount)
+	if constraint, ok := violation(err, foreignKeyViolation); ok {
+		if constraint == "list_prices_variant_id_fkey" {
+			return ErrVariantNotFound
+		}
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// DeleteListPrice removes the list price of an item in one currency.
+func (r *postgresRepositry) DeleteListPrice(ctx context.Context, itemID, currency string) (err error) {
+	const query = "DELETE FROM list_prices WHERE item_id = $1 AND currency = $2"
+	ctx, span := tracing.StartDBSpan(ctx, "list_prices", "DeleteListPrice", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, itemID, currency)
+	return err
+}
+
+// GetListPrices fetches the list prices of a batch of products and of
+// their variants in one round-trip, ordered by product, item and currency.
+func (r *postgresRepositry) GetListPrices(ctx context.Context, productIDs []string, currency string) (_ []ListPrice, err error) {
+	const query = `SELECT product_id, COALESCE(variant_id, ''), price, currency FROM list_prices
+	WHERE product_id = ANY($1) AND ($2::text = '' OR currency = $2)
+	ORDER BY product_id, item_id, currency`
+	ctx, span := tracing.StartDBSpan(ctx, "list_prices", "GetListPrices", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs), currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []ListPrice{}
+	for rows.Next() {
+		l := ListPrice{}
+		if err := rows.Scan(&l.ProductID, &l.VariantID, &l.Price.Amount, &l.Price.Currency); err != nil {
+			return nil, err
+		}
+		prices = append(prices, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// PutExchangeRates upserts a batch of rates in one statement.
+func (r *postgresRepositry) PutExchangeRates(ctx context.Context, rates []ExchangeRate) (err error) {
+	const query = `INSERT INTO exchange_rates (currency, rate, updated_at)
+	SELECT currency, rate::numeric, now() FROM unnest($1::text[], $2::text[]) AS r (currency, rate)
+	ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at`
+	ctx, span := tracing.StartDBSpan(ctx, "exchange_rates", "PutExchangeRates", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	currencies := make([]string, 0, len(rates))
+	values := make([]string, 0, len(rates))
+	for _, rate := range rates {
+		currencies = append(currencies, rate.Currency)
+		values = append(values, rate.Rate)
+	}
+
+	_, err = r.db.ExecContext(ctx, query, pq.Array(currencies), pq.Array(values))
+	return err
+}
+
+// GetExchangeRates fetches every rate, ordered by currency.
+func (r *postgresRepositry) GetExchangeRates(ctx context.Context) (_ []ExchangeRate, err error) {
+	const query = "SELECT currency, rate::text, updated_at FROM exchange_rates ORDER BY currency"
+	ctx, span := tracing.StartDBSpan(ctx, "exchange_rates", "GetExchangeRates", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []ExchangeRate{}
+	for rows.Next() {
+		rate := ExchangeRate{}
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// productImageColumns are scanned by scanProductImage.
+const productImageColumns = "id, product_id, position, alt_text, content_type, width, height, size_bytes, blob_key, thumbnails, created_at"
+
+// PutProductImage inserts the image at the position after the last image
+// of its product. Concurrent uploads may share a position; they are then
+// ordered by creation.
+func (r *postgresRepositry) PutProductImage(ctx context.Context, img *ProductImage) (err error) {
+	const query = `INSERT INTO product_images (` + productImageColumns + `)
+	SELECT $1, $2, COALESCE(MAX(position) + 1, 0), $3, $4, $5, $6, $7, $8, $9, $10 FROM product_images WHERE product_id = $2
+	RETURNING position`
+	ctx, span := tracing.StartDBSpan(ctx, "product_images", "PutProductImage", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	thumbnails, err := json.Marshal(img.Thumbnails)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx, query, img.ID, img.ProductID, img.AltText, img.ContentType,
+		img.Width, img.Height, img.Size, img.Key, thumbnails, img.CreatedAt).Scan(&img.Position)
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// UpdateProductImage sets the alt text of an image.
+func (r *postgresRepositry) UpdateProductImage(ctx context.Context, id, altText string) (_ *ProductImage, err error) {
+	const query = "UPDATE product_images SET alt_text = $2 WHERE id = $1 RETURNING " + productImageColumns
+	ctx, span := tracing.StartDBSpan(ctx, "product_images", "UpdateProductImage", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	img, err := scanProductImage(r.db.QueryRowContext(ctx, query, id, altText))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImageNotFound
+	}
+	return img, err
+}
+
+// ReorderProductImages numbers the images from 0 in the order of imageIDs
+// with a single statement.
+func (r *postgresRepositry) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) (err error) {
+	const query = `UPDATE product_images SET position = o.n - 1
+	FROM unnest($2::text[]) WITH ORDINALITY AS o (id, n)
+	WHERE product_images.id = o.id AND product_images.product_id = $1`
+	ctx, span := tracing.StartDBSpan(ctx, "product_images", "ReorderProductImages", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, productID, pq.Array(imageIDs))
+	return err
+}
+
+// DeleteProductImage removes the record of an image and returns it.
+func (r *postgresRepositry) DeleteProductImage(ctx context.Context, id string) (_ *ProductImage, err error) {
+	const query = "DELETE FROM product_images WHERE id = $1 RETURNING " + productImageColumns
+	ctx, span := tracing.StartDBSpan(ctx, "product_images", "DeleteProductImage", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	img, err := scanProductImage(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImageNotFound
+	}
+	return img, err
+}
+
+// GetProductImages fetches the images of a batch of products in one round-trip.
+func (r *postgresRepositry) GetProductImages(ctx context.Context, productIDs []string) (_ []ProductImage, err error) {
+	const query = "SELECT " + productImageColumns + " FROM product_images WHERE product_id = ANY($1) ORDER BY product_id, position, created_at, id"
+	ctx, span := tracing.StartDBSpan(ctx, "product_images", "GetProductImages", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []ProductImage{}
+	for rows.Next() {
+		img, err := scanProductImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *img)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// scanProductImage reads the productImageColumns of one row.
+func scanProductImage(row interface{ Scan(...any) error }) (*ProductImage, error) {
+	img := &ProductImage{}
+	var thumbnails []byte
+	err := row.Scan(&img.ID, &img.ProductID, &img.Position, &img.AltText, &img.ContentType,
+		&img.Width, &img.Height, &img.Size, &img.Key, &thumbnails, &img.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(thumbnails, &img.Thumbnails); err != nil {
+		return nil, err
+	}
+	return img, nil
 }
 
-type postgresRepositry struct{
-	db *spl.DB
-}
\ No newline at end of file
+// priceChangeColumns are scanned by scanPriceChange.
+const priceChangeColumns = "id, product_id, price, currency, effective_from, effective_until, scheduled, label, created_at"
+
+// insertPriceChangeQuery records a price change, effective at the time of
+// the database unless it is scheduled to start later.
+const insertPriceChangeQuery = `INSERT INTO product_prices (` + priceChangeColumns + `)
+VALUES ($1, $2, $3, $4, COALESCE($5, now()), $6, $7, $8, now())
+RETURNING effective_from, created_at`
+
+// insertPriceChange runs insertPriceChangeQuery on q, a database or a
+// transaction, and sets the times of c that the database chose.
+func insertPriceChange(ctx context.Context, q interface {
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}, c *PriceChange) error {
+	var from interface{}
+	if !c.EffectiveFrom.IsZero() {
+		from = c.EffectiveFrom
+	}
+	err := q.QueryRowContext(ctx, insertPriceChangeQuery, c.ID, c.ProductID, c.Price.Amount, c.Price.Currency,
+		from, c.EffectiveUntil, c.Scheduled, c.Label).Scan(&c.EffectiveFrom, &c.CreatedAt)
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrProductNotFound
+	}
+	if _, ok := violation(err, checkViolation); ok {
+		return ErrInvalidSchedule
+	}
+	return err
+}
+
+// PutPriceChange records a scheduled price.
+func (r *postgresRepositry) PutPriceChange(ctx context.Context, c *PriceChange) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "product_prices", "PutPriceChange", insertPriceChangeQuery)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return insertPriceChange(ctx, r.db, c)
+}
+
+// CancelScheduledPrice deletes a scheduled price that has not started, or
+// ends a running one at the time of the database. Changes of the regular
+// price are not scheduled and cannot be cancelled.
+func (r *postgresRepositry) CancelScheduledPrice(ctx context.Context, id string) (productID string, running bool, err error) {
+	const (
+		selectChange = `SELECT product_id, effective_from < now(), effective_until IS NOT NULL AND effective_until <= now()
+		FROM product_prices WHERE id = $1 AND scheduled FOR UPDATE`
+		deleteChange = "DELETE FROM product_prices WHERE id = $1"
+		endChange    = "UPDATE product_prices SET effective_until = now() WHERE id = $1"
+	)
+	ctx, span := tracing.StartDBSpan(ctx, "product_prices", "CancelScheduledPrice", selectChange)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	var started, ended bool
+	err = tx.QueryRowContext(ctx, selectChange, id).Scan(&productID, &started, &ended)
+	if err == sql.ErrNoRows {
+		return "", false, ErrScheduledPriceNotFound
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	switch {
+	case ended:
+		return "", false, ErrScheduledPriceEnded
+	case started:
+		_, err = tx.ExecContext(ctx, endChange, id)
+	default:
+		_, err = tx.ExecContext(ctx, deleteChange, id)
+	}
+	return productID, started, err
+}
+
+// GetPriceTransitions looks for scheduled prices whose start or end lies
+// between since and the time of the database.
+func (r *postgresRepositry) GetPriceTransitions(ctx context.Context, since time.Time) (_ []string, _ time.Time, err error) {
+	const query = `WITH clock AS (SELECT now() AS now)
+	SELECT clock.now, pp.product_id FROM clock LEFT JOIN product_prices pp ON pp.scheduled AND (
+		(pp.effective_from > $1 AND pp.effective_from <= clock.now) OR
+		(pp.effective_until > $1 AND pp.effective_until <= clock.now)
+	) GROUP BY clock.now, pp.product_id`
+	ctx, span := tracing.StartDBSpan(ctx, "product_prices", "GetPriceTransitions", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	var until time.Time
+	for rows.Next() {
+		var id sql.NullString
+		if err := rows.Scan(&until, &id); err != nil {
+			return nil, time.Time{}, err
+		}
+		if id.Valid {
+			ids = append(ids, id.String)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	return ids, until, nil
+}
+
+// GetPriceHistory fetches the price history of a batch of products in one
+// round-trip, the latest effective first.
+func (r *postgresRepositry) GetPriceHistory(ctx context.Context, productIDs []string) (_ []PriceChange, err error) {
+	const query = "SELECT " + priceChangeColumns + " FROM product_prices WHERE product_id = ANY($1) ORDER BY product_id, effective_from DESC, created_at DESC"
+	ctx, span := tracing.StartDBSpan(ctx, "product_prices", "GetPriceHistory", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []PriceChange{}
+	for rows.Next() {
+		c, err := scanPriceChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// scanPriceChange reads the priceChangeColumns of one row.
+func scanPriceChange(row interface{ Scan(...any) error }) (*PriceChange, error) {
+	c := &PriceChange{}
+	var until sql.NullTime
+	err := row.Scan(&c.ID, &c.ProductID, &c.Price.Amount, &c.Price.Currency,
+		&c.EffectiveFrom, &until, &c.Scheduled, &c.Label, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if until.Valid {
+		c.EffectiveUntil = &until.Time
+	}
+	return c, nil
+}
+
+// reviewColumns are scanned by scanReview.
+const reviewColumns = "id, product_id, account_id, rating, title, body, verified_purchase, status, created_at, updated_at"
+
+// PutReview inserts a review. The unique key on product and account keeps
+// to one review per account.
+func (r *postgresRepositry) PutReview(ctx context.Context, rev Review) (err error) {
+	const query = "INSERT INTO product_reviews (" + reviewColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+	ctx, span := tracing.StartDBSpan(ctx, "product_reviews", "PutReview", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, rev.ID, rev.ProductID, rev.AccountID, rev.Rating, rev.Title, rev.Body,
+		rev.VerifiedPurchase, rev.Status, rev.CreatedAt, rev.UpdatedAt)
+	if _, ok := violation(err, uniqueViolation); ok {
+		return ErrDuplicateReview
+	}
+	if _, ok := violation(err, foreignKeyViolation); ok {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// ModerateReview locks the review, so that concurrent moderations of it
+// adjust the rating of the product once, and applies the difference its
+// new status makes to the count and sum of the product in the same
+// transaction.
+func (r *postgresRepositry) ModerateReview(ctx context.Context, id string, status ReviewStatus) (_ *Review, err error) {
+	const (
+		selectReview  = "SELECT " + reviewColumns + " FROM product_reviews WHERE id = $1 FOR UPDATE"
+		updateReview  = "UPDATE product_reviews SET status = $2, updated_at = now() WHERE id = $1 RETURNING updated_at"
+		updateRatings = "UPDATE products SET rating_count = rating_count + $2, rating_sum = rating_sum + $3 WHERE id = $1"
+	)
+	ctx, span := tracing.StartDBSpan(ctx, "product_reviews", "ModerateReview", updateReview)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	rev, err := scanReview(tx.QueryRowContext(ctx, selectReview, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rev.Status == status {
+		return rev, nil
+	}
+
+	var count, sum int64
+	if rev.Status == ReviewApproved {
+		count, sum = -1, -int64(rev.Rating)
+	}
+	if status == ReviewApproved {
+		count, sum = 1, int64(rev.Rating)
+	}
+	if err = tx.QueryRowContext(ctx, updateReview, id, status).Scan(&rev.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if count != 0 {
+		if _, err = tx.ExecContext(ctx, updateRatings, rev.ProductID, count, sum); err != nil {
+			return nil, err
+		}
+	}
+	rev.Status = status
+	return rev, nil
+}
+
+// GetProductReviews numbers the approved reviews of each product newest
+// first, and keeps the numbers of the page, so a batch of products is one
+// round-trip.
+func (r *postgresRepositry) GetProductReviews(ctx context.Context, productIDs []string, skip uint64, take uint64) (_ []Review, err error) {
+	const query = `SELECT ` + reviewColumns + ` FROM (
+		SELECT ` + reviewColumns + `, row_number() OVER (PARTITION BY product_id ORDER BY created_at DESC, id DESC) AS n
+		FROM product_reviews WHERE product_id = ANY($1) AND status = $2
+	) numbered
+	WHERE n > $3 AND n <= $3 + $4
+	ORDER BY product_id, n`
+	ctx, span := tracing.StartDBSpan(ctx, "product_reviews", "GetProductReviews", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs), ReviewApproved, skip, take)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviews(rows)
+}
+
+// ListReviews returns the reviews of every product, newest first.
+func (r *postgresRepositry) ListReviews(ctx context.Context, status ReviewStatus, skip uint64, take uint64) (_ []Review, err error) {
+	const query = "SELECT " + reviewColumns + " FROM product_reviews WHERE ($1::text = '' OR status = $1) ORDER BY created_at DESC, id DESC OFFSET $2 LIMIT $3"
+	ctx, span := tracing.StartDBSpan(ctx, "product_reviews", "ListReviews", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, status, skip, take)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviews(rows)
+}
+
+// scanReviews reads every row of rows with scanReview.
+func scanReviews(rows *sql.Rows) ([]Review, error) {
+	reviews := []Review{}
+	for rows.Next() {
+		rev, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// scanReview reads the reviewColumns of one row.
+func scanReview(row interface{ Scan(...any) error }) (*Review, error) {
+	rev := &Review{}
+	err := row.Scan(&rev.ID, &rev.ProductID, &rev.AccountID, &rev.Rating, &rev.Title, &rev.Body,
+		&rev.VerifiedPurchase, &rev.Status, &rev.CreatedAt, &rev.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
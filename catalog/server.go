@@ -0,0 +1,1085 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// grpcServer wraps the business logic service and implements gRPC methods
+type grpcServer struct {
+	pb.UnimplementedCatalogServiceServer
+	service Service
+}
+
+// ListenGRPCServer starts a gRPC server on the specified port
+// opts: extra server options (e.g. rate limiting) appended after the built-in ones
+func ListenGRPCServer(service Service, port int, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	// Record RPC latency and status codes for every call and continue
+	// the caller's trace
+	grpcSrv := grpc.NewServer(append([]grpc.ServerOption{
+		tracing.ServerOption(),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor()),
+	}, opts...)...)
+
+	pb.RegisterCatalogServiceServer(grpcSrv, &grpcServer{service: service})
+
+	// Report SERVING on the standard gRPC health service, probed by the
+	// gateway's /readyz and by load balancers
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pb.CatalogService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+
+	// Enable gRPC reflection - allows tools like grpcurl to discover API
+	reflection.Register(grpcSrv)
+
+	return grpcSrv.Serve(lis)
+}
+
+// PostProduct handles product creation requests via gRPC
+func (s *grpcServer) PostProduct(ctx context.Context, req *pb.PostProductRequest) (*pb.PostProductResponse, error) {
+	p, err := s.service.PostProduct(ctx, req.Name, req.Description, moneyFromProto(req.Price))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.PostProductResponse{Product: toProto(p)}, nil
+}
+
+// PutProduct handles product update requests via gRPC
+func (s *grpcServer) PutProduct(ctx context.Context, req *pb.PutProductRequest) (*pb.PutProductResponse, error) {
+	p, err := s.service.PutProduct(ctx, req.Id, req.Name, req.Description, moneyFromProto(req.Price))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.PutProductResponse{Product: toProto(p)}, nil
+}
+
+// GetProduct handles single product retrieval requests via gRPC
+func (s *grpcServer) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.GetProductResponse, error) {
+	p, err := s.service.GetProduct(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", req.Id)
+	}
+	return &pb.GetProductResponse{Product: toProto(p)}, nil
+}
+
+// ListProducts handles paginated product listing requests via gRPC
+func (s *grpcServer) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	products, err := s.service.ListProducts(ctx, req.Skip, req.Take)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListProductsResponse{}
+	for i := range products {
+		resp.Products = append(resp.Products, toProto(&products[i]))
+	}
+	return resp, nil
+}
+
+// GetProductsByIDs handles batch product lookups via gRPC.
+// Every requested ID ends up either in products or in not_found_ids.
+func (s *grpcServer) GetProductsByIDs(ctx context.Context, req *pb.GetProductsByIDsRequest) (*pb.GetProductsByIDsResponse, error) {
+	products, err := s.service.GetProductsByIDs(ctx, req.Ids)
+	if errors.Is(err, ErrTooManyIDs) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetProductsByIDsResponse{Products: map[string]*pb.Product{}}
+	for id, p := range products {
+		resp.Products[id] = toProto(&p)
+	}
+	for _, id := range uniqueIDs(req.Ids) {
+		if _, ok := products[id]; !ok {
+			resp.NotFoundIds = append(resp.NotFoundIds, id)
+		}
+	}
+	return resp, nil
+}
+
+// SearchProducts handles product searches via gRPC.
+// Invalid queries and price ranges are rejected with INVALID_ARGUMENT.
+func (s *grpcServer) SearchProducts(ctx context.Context, req *pb.SearchProductsRequest) (*pb.SearchProductsResponse, error) {
+	var filters SearchFilters
+	if f := req.Filters; f != nil {
+		filters.MinPrice, filters.MaxPrice = optionalMoneyFromProto(f.MinPrice), optionalMoneyFromProto(f.MaxPrice)
+	}
+	res, err := s.service.SearchProducts(ctx, req.Query, filters, req.Skip, req.Take)
+	if errors.Is(err, ErrInvalidQuery) || errors.Is(err, ErrQueryTooLong) || errors.Is(err, ErrInvalidPriceRange) ||
+		errors.Is(err, ErrPriceCurrency) || errors.Is(err, money.ErrInvalidCurrency) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.SearchProductsResponse{Total: res.Total, Fuzzy: res.Fuzzy}
+	for i := range res.Hits {
+		h := &res.Hits[i]
+		resp.Hits = append(resp.Hits, &pb.ProductSearchHit{
+			Product:              toProto(&h.Product),
+			Rank:                 h.Rank,
+			NameHighlight:        h.NameHighlight,
+			DescriptionHighlight: h.DescriptionHighlight,
+		})
+	}
+	return resp, nil
+}
+
+// WatchProductPrice streams a product every time its price changes, until
+// the client goes away. A client that cannot keep up is disconnected with
+// UNAVAILABLE and should resubscribe.
+func (s *grpcServer) WatchProductPrice(req *pb.WatchProductPriceRequest, stream pb.CatalogService_WatchProductPriceServer) error {
+	if req.ProductId == "" {
+		return status.Error(codes.InvalidArgument, "product_id is required")
+	}
+
+	ctx := stream.Context()
+	for p := range s.service.WatchProductPrice(ctx, req.ProductId) {
+		if err := stream.Send(toProto(&p)); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "watcher fell behind, resubscribe")
+}
+
+// DeleteProduct handles product deletion requests via gRPC
+func (s *grpcServer) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := s.service.DeleteProduct(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteProductResponse{
+		Success:          true,
+		DeletedProductId: req.Id,
+	}, nil
+}
+
+// CreateCategory handles category creation requests via gRPC
+func (s *grpcServer) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.CategoryResponse, error) {
+	c, err := s.service.CreateCategory(ctx, req.ParentId, req.Name, req.Slug, req.Position)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CategoryResponse{Category: categoryToProto(c)}, nil
+}
+
+// UpdateCategory handles category update and move requests via gRPC
+func (s *grpcServer) UpdateCategory(ctx context.Context, req *pb.UpdateCategoryRequest) (*pb.CategoryResponse, error) {
+	c, err := s.service.UpdateCategory(ctx, req.Id, req.ParentId, req.Name, req.Slug, req.Position)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CategoryResponse{Category: categoryToProto(c)}, nil
+}
+
+// GetCategory handles category retrieval by ID or slug via gRPC
+func (s *grpcServer) GetCategory(ctx context.Context, req *pb.GetCategoryRequest) (*pb.CategoryResponse, error) {
+	if (req.Id == "") == (req.Slug == "") {
+		return nil, status.Error(codes.InvalidArgument, "exactly one of id and slug is required")
+	}
+
+	var c *Category
+	var err error
+	if req.Id != "" {
+		c, err = s.service.GetCategory(ctx, req.Id)
+	} else {
+		c, err = s.service.GetCategoryBySlug(ctx, req.Slug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, status.Errorf(codes.NotFound, "category %s not found", req.Id+req.Slug)
+	}
+	return &pb.CategoryResponse{Category: categoryToProto(c)}, nil
+}
+
+// ListCategories handles listing one level of the category tree via gRPC
+func (s *grpcServer) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.CategoryList, error) {
+	categories, err := s.service.ListCategories(ctx, req.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	return categoriesToProto(categories), nil
+}
+
+// GetCategoryPaths handles batch breadcrumb lookups via gRPC
+func (s *grpcServer) GetCategoryPaths(ctx context.Context, req *pb.GetCategoryPathsRequest) (*pb.GetCategoryPathsResponse, error) {
+	paths, err := s.service.GetCategoryPaths(ctx, req.Ids)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetCategoryPathsResponse{Paths: make(map[string]*pb.CategoryList, len(paths))}
+	for id, path := range paths {
+		resp.Paths[id] = categoriesToProto(path)
+	}
+	return resp, nil
+}
+
+// DeleteCategory handles category deletion requests via gRPC
+func (s *grpcServer) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryRequest) (*pb.DeleteCategoryResponse, error) {
+	if err := s.service.DeleteCategory(ctx, req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteCategoryResponse{Success: true}, nil
+}
+
+// SetProductCategories handles category assignment requests via gRPC and
+// answers with the categories the product now has
+func (s *grpcServer) SetProductCategories(ctx context.Context, req *pb.SetProductCategoriesRequest) (*pb.SetProductCategoriesResponse, error) {
+	if err := s.service.SetProductCategories(ctx, req.ProductId, req.CategoryIds); err != nil {
+		return nil, toStatus(err)
+	}
+
+	categories, err := s.service.GetProductCategories(ctx, []string{req.ProductId})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SetProductCategoriesResponse{
+		Categories: categoriesToProto(categories[req.ProductId]).Categories,
+	}, nil
+}
+
+// GetProductCategories handles batch lookups of product categories via gRPC
+func (s *grpcServer) GetProductCategories(ctx context.Context, req *pb.GetProductCategoriesRequest) (*pb.GetProductCategoriesResponse, error) {
+	categories, err := s.service.GetProductCategories(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetProductCategoriesResponse{Categories: make(map[string]*pb.CategoryList, len(categories))}
+	for id, list := range categories {
+		resp.Categories[id] = categoriesToProto(list)
+	}
+	return resp, nil
+}
+
+// ListProductsByCategory handles paginated listing of the products below a
+// category via gRPC
+func (s *grpcServer) ListProductsByCategory(ctx context.Context, req *pb.ListProductsByCategoryRequest) (*pb.ListProductsResponse, error) {
+	products, err := s.service.ListProductsByCategory(ctx, req.CategoryId, req.Skip, req.Take)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListProductsResponse{}
+	for i := range products {
+		resp.Products = append(resp.Products, toProto(&products[i]))
+	}
+	return resp, nil
+}
+
+// CreateVariant handles variant creation requests via gRPC
+func (s *grpcServer) CreateVariant(ctx context.Context, req *pb.CreateVariantRequest) (*pb.VariantResponse, error) {
+	v, err := s.service.CreateVariant(ctx, Variant{
+		ProductID:     req.ProductId,
+		SKU:           req.Sku,
+		Options:       req.Options,
+		PriceOverride: optionalMoneyFromProto(req.PriceOverride),
+		Barcode:       req.Barcode,
+		Position:      req.Position,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VariantResponse{Variant: variantToProto(v)}, nil
+}
+
+// UpdateVariant handles variant update requests via gRPC
+func (s *grpcServer) UpdateVariant(ctx context.Context, req *pb.UpdateVariantRequest) (*pb.VariantResponse, error) {
+	v, err := s.service.UpdateVariant(ctx, Variant{
+		ID:            req.Id,
+		SKU:           req.Sku,
+		Options:       req.Options,
+		PriceOverride: optionalMoneyFromProto(req.PriceOverride),
+		Barcode:       req.Barcode,
+		Position:      req.Position,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.VariantResponse{Variant: variantToProto(v)}, nil
+}
+
+// GetVariants handles batch variant lookups by ID and by SKU via gRPC.
+// Requested IDs and SKUs that do not exist are reported in NotFound.
+func (s *grpcServer) GetVariants(ctx context.Context, req *pb.GetVariantsRequest) (*pb.GetVariantsResponse, error) {
+	byID, err := s.service.GetVariantsByIDs(ctx, req.Ids)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	bySKU, err := s.service.GetVariantsBySKUs(ctx, req.Skus)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetVariantsResponse{}
+	seen := map[string]bool{}
+	add := func(v Variant) {
+		if !seen[v.ID] {
+			seen[v.ID] = true
+			resp.Variants = append(resp.Variants, variantToProto(&v))
+		}
+	}
+	for _, id := range req.Ids {
+		if v, ok := byID[id]; ok {
+			add(v)
+		} else {
+			resp.NotFound = append(resp.NotFound, id)
+		}
+	}
+	for _, sku := range req.Skus {
+		if v, ok := bySKU[NormalizeSKU(sku)]; ok {
+			add(v)
+		} else {
+			resp.NotFound = append(resp.NotFound, sku)
+		}
+	}
+	return resp, nil
+}
+
+// GetProductVariants handles batch lookups of product variants via gRPC
+func (s *grpcServer) GetProductVariants(ctx context.Context, req *pb.GetProductVariantsRequest) (*pb.GetProductVariantsResponse, error) {
+	variants, err := s.service.GetProductVariants(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetProductVariantsResponse{Variants: make(map[string]*pb.VariantList, len(variants))}
+	for id, list := range variants {
+		resp.Variants[id] = variantsToProto(list)
+	}
+	return resp, nil
+}
+
+// DeleteVariant handles variant deletion requests via gRPC
+func (s *grpcServer) DeleteVariant(ctx context.Context, req *pb.DeleteVariantRequest) (*pb.DeleteVariantResponse, error) {
+	if err := s.service.DeleteVariant(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteVariantResponse{Success: true}, nil
+}
+
+// SetStock handles on-hand quantity updates via gRPC
+func (s *grpcServer) SetStock(ctx context.Context, req *pb.SetStockRequest) (*pb.StockLevelResponse, error) {
+	l, err := s.service.SetStock(ctx, req.ProductId, req.VariantId, int64(req.OnHand))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.StockLevelResponse{Level: stockLevelToProto(l)}, nil
+}
+
+// AdjustStock handles relative on-hand quantity changes via gRPC
+func (s *grpcServer) AdjustStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.StockLevelResponse, error) {
+	l, err := s.service.AdjustStock(ctx, req.ProductId, req.VariantId, int64(req.Delta))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.StockLevelResponse{Level: stockLevelToProto(l)}, nil
+}
+
+// GetStockLevels handles batch stock lookups via gRPC
+func (s *grpcServer) GetStockLevels(ctx context.Context, req *pb.GetStockLevelsRequest) (*pb.GetStockLevelsResponse, error) {
+	levels, err := s.service.GetStockLevels(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetStockLevelsResponse{Levels: make(map[string]*pb.StockLevelList, len(levels))}
+	for id, list := range levels {
+		out := &pb.StockLevelList{Levels: []*pb.StockLevel{}}
+		for i := range list {
+			out.Levels = append(out.Levels, stockLevelToProto(&list[i]))
+		}
+		resp.Levels[id] = out
+	}
+	return resp, nil
+}
+
+// ReserveStock handles stock reservations via gRPC
+func (s *grpcServer) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.ReservationResponse, error) {
+	items := make([]StockItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, StockItem{
+			ProductID: item.ProductId,
+			VariantID: item.VariantId,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	res, err := s.service.ReserveStock(ctx, items, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReservationResponse{Reservation: reservationToProto(res)}, nil
+}
+
+// CommitReservation handles reservation commits via gRPC
+func (s *grpcServer) CommitReservation(ctx context.Context, req *pb.CommitReservationRequest) (*pb.ReservationResponse, error) {
+	res, err := s.service.CommitReservation(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReservationResponse{Reservation: reservationToProto(res)}, nil
+}
+
+// ReleaseReservation handles reservation releases via gRPC
+func (s *grpcServer) ReleaseReservation(ctx context.Context, req *pb.ReleaseReservationRequest) (*pb.ReservationResponse, error) {
+	res, err := s.service.ReleaseReservation(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReservationResponse{Reservation: reservationToProto(res)}, nil
+}
+
+// ReturnReservation handles returns of committed reservations via gRPC
+func (s *grpcServer) ReturnReservation(ctx context.Context, req *pb.ReturnReservationRequest) (*pb.ReservationResponse, error) {
+	res, err := s.service.ReturnReservation(ctx, req.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReservationResponse{Reservation: reservationToProto(res)}, nil
+}
+
+// SetListPrice handles explicit price updates in a foreign currency via gRPC
+func (s *grpcServer) SetListPrice(ctx context.Context, req *pb.SetListPriceRequest) (*pb.ListPriceResponse, error) {
+	p, err := s.service.SetListPrice(ctx, req.ProductId, req.VariantId, moneyFromProto(req.Price))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListPriceResponse{Price: listPriceToProto(p)}, nil
+}
+
+// DeleteListPrice handles list price removal via gRPC
+func (s *grpcServer) DeleteListPrice(ctx context.Context, req *pb.DeleteListPriceRequest) (*pb.DeleteListPriceResponse, error) {
+	if err := s.service.DeleteListPrice(ctx, req.ProductId, req.VariantId, req.Currency); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteListPriceResponse{Success: true}, nil
+}
+
+// GetListPrices handles batch list price lookups via gRPC
+func (s *grpcServer) GetListPrices(ctx context.Context, req *pb.GetListPricesRequest) (*pb.GetListPricesResponse, error) {
+	prices, err := s.service.GetListPrices(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetListPricesResponse{Prices: make(map[string]*pb.ListPriceList, len(prices))}
+	for id, list := range prices {
+		out := &pb.ListPriceList{Prices: []*pb.ListPrice{}}
+		for i := range list {
+			out.Prices = append(out.Prices, listPriceToProto(&list[i]))
+		}
+		resp.Prices[id] = out
+	}
+	return resp, nil
+}
+
+// GetPrices handles batch price resolution in a currency via gRPC
+func (s *grpcServer) GetPrices(ctx context.Context, req *pb.GetPricesRequest) (*pb.GetPricesResponse, error) {
+	items := make([]PriceItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, PriceItem{ProductID: item.ProductId, VariantID: item.VariantId})
+	}
+
+	prices, err := s.service.GetPrices(ctx, items, req.Currency)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetPricesResponse{Prices: make([]*pb.Price, 0, len(prices))}
+	for i := range prices {
+		resp.Prices = append(resp.Prices, priceToProto(&prices[i]))
+	}
+	return resp, nil
+}
+
+// ScheduleProductPrice handles price scheduling via gRPC. Times are RFC 3339.
+func (s *grpcServer) ScheduleProductPrice(ctx context.Context, req *pb.ScheduleProductPriceRequest) (*pb.PriceChangeResponse, error) {
+	var from time.Time
+	if req.EffectiveFrom != "" {
+		t, err := time.Parse(time.RFC3339, req.EffectiveFrom)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "effective_from must be an RFC 3339 time")
+		}
+		from = t
+	}
+	var until *time.Time
+	if req.EffectiveUntil != "" {
+		t, err := time.Parse(time.RFC3339, req.EffectiveUntil)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "effective_until must be an RFC 3339 time")
+		}
+		until = &t
+	}
+
+	c, err := s.service.ScheduleProductPrice(ctx, req.ProductId, moneyFromProto(req.Price), from, until, req.Label)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.PriceChangeResponse{Change: priceChangeToProto(c)}, nil
+}
+
+func (s *grpcServer) CancelScheduledPrice(ctx context.Context, req *pb.CancelScheduledPriceRequest) (*pb.CancelScheduledPriceResponse, error) {
+	if err := s.service.CancelScheduledPrice(ctx, req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.CancelScheduledPriceResponse{Success: true}, nil
+}
+
+func (s *grpcServer) GetPriceHistory(ctx context.Context, req *pb.GetPriceHistoryRequest) (*pb.GetPriceHistoryResponse, error) {
+	history, err := s.service.GetPriceHistory(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetPriceHistoryResponse{History: make(map[string]*pb.PriceChangeList, len(history))}
+	for id, changes := range history {
+		list := &pb.PriceChangeList{Changes: make([]*pb.PriceChange, 0, len(changes))}
+		for i := range changes {
+			list.Changes = append(list.Changes, priceChangeToProto(&changes[i]))
+		}
+		resp.History[id] = list
+	}
+	return resp, nil
+}
+
+// SetExchangeRates handles exchange rate updates via gRPC
+func (s *grpcServer) SetExchangeRates(ctx context.Context, req *pb.SetExchangeRatesRequest) (*pb.ExchangeRatesResponse, error) {
+	rates := make([]ExchangeRate, 0, len(req.Rates))
+	for _, r := range req.Rates {
+		rates = append(rates, ExchangeRate{Currency: r.Currency, Rate: r.Rate})
+	}
+
+	rates, err := s.service.SetExchangeRates(ctx, rates)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.exchangeRatesResponse(rates), nil
+}
+
+// ListExchangeRates handles exchange rate listing via gRPC
+func (s *grpcServer) ListExchangeRates(ctx context.Context, req *pb.ListExchangeRatesRequest) (*pb.ExchangeRatesResponse, error) {
+	rates, err := s.service.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.exchangeRatesResponse(rates), nil
+}
+
+func (s *grpcServer) exchangeRatesResponse(rates []ExchangeRate) *pb.ExchangeRatesResponse {
+	resp := &pb.ExchangeRatesResponse{
+		BaseCurrency: s.service.BaseCurrency(),
+		Rates:        make([]*pb.ExchangeRate, 0, len(rates)),
+	}
+	for _, r := range rates {
+		resp.Rates = append(resp.Rates, &pb.ExchangeRate{
+			Currency:  r.Currency,
+			Rate:      r.Rate,
+			UpdatedAt: r.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp
+}
+
+// UploadProductImage receives the metadata of an image, then its file in
+// chunks, and hands it to the service once the client closes the stream.
+// Uploads are cut off as soon as they exceed MaxImageSize.
+func (s *grpcServer) UploadProductImage(stream pb.CatalogService_UploadProductImageServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	meta := first.GetMetadata()
+	if meta == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the metadata")
+	}
+	if meta.ProductId == "" {
+		return status.Error(codes.InvalidArgument, "product_id is required")
+	}
+
+	var data []byte
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		chunk, ok := req.Data.(*pb.UploadProductImageRequest_Chunk)
+		if !ok {
+			return status.Error(codes.InvalidArgument, "metadata can only be sent once")
+		}
+		if len(data)+len(chunk.Chunk) > MaxImageSize {
+			return toStatus(ErrImageTooLarge)
+		}
+		data = append(data, chunk.Chunk...)
+	}
+
+	img, err := s.service.UploadProductImage(stream.Context(), meta.ProductId, meta.AltText, data)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendAndClose(&pb.ProductImageResponse{Image: productImageToProto(img)})
+}
+
+func (s *grpcServer) UpdateProductImage(ctx context.Context, req *pb.UpdateProductImageRequest) (*pb.ProductImageResponse, error) {
+	img, err := s.service.UpdateProductImage(ctx, req.Id, req.AltText)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ProductImageResponse{Image: productImageToProto(img)}, nil
+}
+
+func (s *grpcServer) ReorderProductImages(ctx context.Context, req *pb.ReorderProductImagesRequest) (*pb.ReorderProductImagesResponse, error) {
+	images, err := s.service.ReorderProductImages(ctx, req.ProductId, req.ImageIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReorderProductImagesResponse{Images: productImagesToProto(images).Images}, nil
+}
+
+func (s *grpcServer) DeleteProductImage(ctx context.Context, req *pb.DeleteProductImageRequest) (*pb.DeleteProductImageResponse, error) {
+	if err := s.service.DeleteProductImage(ctx, req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DeleteProductImageResponse{Success: true}, nil
+}
+
+func (s *grpcServer) GetProductImages(ctx context.Context, req *pb.GetProductImagesRequest) (*pb.GetProductImagesResponse, error) {
+	images, err := s.service.GetProductImages(ctx, req.ProductIds)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetProductImagesResponse{Images: make(map[string]*pb.ProductImageList, len(images))}
+	for id, list := range images {
+		resp.Images[id] = productImagesToProto(list)
+	}
+	return resp, nil
+}
+
+// ImportProducts hands the records of the stream to the service as they
+// arrive and answers with its report once the client closes the stream.
+func (s *grpcServer) ImportProducts(stream pb.CatalogService_ImportProductsServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return stream.SendAndClose(importReportToProto(&ImportReport{Issues: []ImportIssue{}}))
+	}
+	if err != nil {
+		return err
+	}
+
+	pending := first.Records
+	next := func() (*ImportRecord, error) {
+		for len(pending) == 0 {
+			req, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			pending = req.Records
+		}
+		rec := importRecordFromProto(pending[0])
+		pending = pending[1:]
+		return rec, nil
+	}
+
+	report, err := s.service.ImportProducts(stream.Context(), next, first.DryRun)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendAndClose(importReportToProto(report))
+}
+
+// ExportProducts streams the catalog a page of records at a time.
+func (s *grpcServer) ExportProducts(req *pb.ExportProductsRequest, stream pb.CatalogService_ExportProductsServer) error {
+	err := s.service.ExportProducts(stream.Context(), func(records []ProductRecord) error {
+		resp := &pb.ExportProductsResponse{Records: make([]*pb.ProductRecord, 0, len(records))}
+		for i := range records {
+			resp.Records = append(resp.Records, productRecordToProto(&records[i]))
+		}
+		return stream.Send(resp)
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+// CreateReview handles review creation via gRPC
+func (s *grpcServer) CreateReview(ctx context.Context, req *pb.CreateReviewRequest) (*pb.ReviewResponse, error) {
+	r, err := s.service.CreateReview(ctx, Review{
+		ProductID: req.ProductId,
+		AccountID: req.AccountId,
+		Rating:    req.Rating,
+		Title:     req.Title,
+		Body:      req.Body,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReviewResponse{Review: reviewToProto(r)}, nil
+}
+
+// ModerateReview handles review moderation via gRPC
+func (s *grpcServer) ModerateReview(ctx context.Context, req *pb.ModerateReviewRequest) (*pb.ReviewResponse, error) {
+	r, err := s.service.ModerateReview(ctx, req.Id, reviewStatusFromProto(req.Status))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ReviewResponse{Review: reviewToProto(r)}, nil
+}
+
+// GetProductReviews handles batched review lookups via gRPC
+func (s *grpcServer) GetProductReviews(ctx context.Context, req *pb.GetProductReviewsRequest) (*pb.GetProductReviewsResponse, error) {
+	reviews, err := s.service.GetProductReviews(ctx, req.ProductIds, req.Skip, req.Take)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.GetProductReviewsResponse{Reviews: make(map[string]*pb.ReviewList, len(reviews))}
+	for id, list := range reviews {
+		resp.Reviews[id] = &pb.ReviewList{Reviews: reviewsToProto(list)}
+	}
+	return resp, nil
+}
+
+// ListReviews handles review listings via gRPC
+func (s *grpcServer) ListReviews(ctx context.Context, req *pb.ListReviewsRequest) (*pb.ListReviewsResponse, error) {
+	reviews, err := s.service.ListReviews(ctx, reviewStatusFromProto(req.Status), req.Skip, req.Take)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.ListReviewsResponse{Reviews: reviewsToProto(reviews)}, nil
+}
+
+// toStatus maps the validation and state errors of the service to gRPC
+// status codes; other errors are returned as is.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidCategoryName), errors.Is(err, ErrInvalidSlug), errors.Is(err, ErrTooManyIDs),
+		errors.Is(err, ErrInvalidSKU), errors.Is(err, ErrInvalidBarcode), errors.Is(err, ErrInvalidOption), errors.Is(err, ErrInvalidPrice),
+		errors.Is(err, ErrInvalidStockItem), errors.Is(err, ErrInvalidStockLevel), errors.Is(err, ErrVariantMismatch),
+		errors.Is(err, ErrEmptyReservation), errors.Is(err, ErrInvalidTTL), errors.Is(err, ErrInvalidName),
+		errors.Is(err, ErrPriceRequired), errors.Is(err, ErrPriceCurrency), errors.Is(err, money.ErrInvalidCurrency),
+		errors.Is(err, ErrListPriceCurrency), errors.Is(err, ErrBaseCurrencyRate), errors.Is(err, money.ErrInvalidRate), errors.Is(err, money.ErrInvalidAmount),
+		errors.Is(err, ErrImageTooLarge), errors.Is(err, ErrInvalidImage), errors.Is(err, ErrInvalidAltText), errors.Is(err, ErrImageOrder),
+		errors.Is(err, ErrInvalidSchedule), errors.Is(err, ErrInvalidPriceLabel),
+		errors.Is(err, ErrInvalidRating), errors.Is(err, ErrReviewAccount), errors.Is(err, ErrInvalidReview), errors.Is(err, ErrInvalidModeration):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrSlugTaken), errors.Is(err, ErrSKUTaken), errors.Is(err, ErrBarcodeTaken), errors.Is(err, ErrDuplicateVariant),
+		errors.Is(err, ErrDuplicateReview):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrCategoryNotFound), errors.Is(err, ErrParentNotFound), errors.Is(err, ErrProductNotFound),
+		errors.Is(err, ErrVariantNotFound), errors.Is(err, ErrReservationNotFound), errors.Is(err, ErrImageNotFound),
+		errors.Is(err, ErrScheduledPriceNotFound), errors.Is(err, ErrReviewNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrCategoryCycle), errors.Is(err, ErrCategoryHasChildren), errors.Is(err, ErrStockPerVariant), errors.Is(err, ErrCurrencyChange),
+		errors.Is(err, ErrInsufficientStock), errors.Is(err, ErrReservationExpired),
+		errors.Is(err, ErrReservationCommitted), errors.Is(err, ErrReservationReleased), errors.Is(err, ErrNoExchangeRate),
+		errors.Is(err, ErrReservationReturned), errors.Is(err, ErrReservationPending),
+		errors.Is(err, ErrScheduledPriceEnded):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrMediaUnavailable):
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	return err
+}
+
+// categoryToProto maps an internal category to its gRPC representation
+func categoryToProto(c *Category) *pb.Category {
+	return &pb.Category{
+		Id:       c.ID,
+		ParentId: c.ParentID,
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Position: c.Position,
+	}
+}
+
+func categoriesToProto(categories []Category) *pb.CategoryList {
+	list := &pb.CategoryList{Categories: []*pb.Category{}}
+	for i := range categories {
+		list.Categories = append(list.Categories, categoryToProto(&categories[i]))
+	}
+	return list
+}
+
+// variantToProto maps an internal variant to its gRPC representation
+func variantToProto(v *Variant) *pb.Variant {
+	return &pb.Variant{
+		Id:            v.ID,
+		ProductId:     v.ProductID,
+		Sku:           v.SKU,
+		Options:       v.Options,
+		PriceOverride: optionalMoneyToProto(v.PriceOverride),
+		Price:         moneyToProto(v.Price),
+		Barcode:       v.Barcode,
+		Position:      v.Position,
+	}
+}
+
+func variantsToProto(variants []Variant) *pb.VariantList {
+	list := &pb.VariantList{Variants: []*pb.Variant{}}
+	for i := range variants {
+		list.Variants = append(list.Variants, variantToProto(&variants[i]))
+	}
+	return list
+}
+
+// stockLevelToProto maps an internal stock level to its gRPC representation
+func stockLevelToProto(l *StockLevel) *pb.StockLevel {
+	return &pb.StockLevel{
+		ProductId: l.ProductID,
+		VariantId: l.VariantID,
+		OnHand:    l.OnHand,
+		Reserved:  l.Reserved,
+		Available: l.Available(),
+	}
+}
+
+// reservationToProto maps an internal reservation to its gRPC representation
+func reservationToProto(r *Reservation) *pb.Reservation {
+	out := &pb.Reservation{
+		Id:        r.ID,
+		Status:    reservationStatusToProto(r.Status),
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: r.ExpiresAt.Format(time.RFC3339),
+		Items:     []*pb.StockItem{},
+	}
+	for _, item := range r.Items {
+		out.Items = append(out.Items, &pb.StockItem{
+			ProductId: item.ProductID,
+			VariantId: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return out
+}
+
+// reservationStatusToProto maps an internal reservation status to its gRPC enum
+func reservationStatusToProto(s ReservationStatus) pb.ReservationStatus {
+	switch s {
+	case ReservationPending:
+		return pb.ReservationStatus_RESERVATION_STATUS_PENDING
+	case ReservationCommitted:
+		return pb.ReservationStatus_RESERVATION_STATUS_COMMITTED
+	case ReservationReleased:
+		return pb.ReservationStatus_RESERVATION_STATUS_RELEASED
+	case ReservationExpired:
+		return pb.ReservationStatus_RESERVATION_STATUS_EXPIRED
+	case ReservationReturned:
+		return pb.ReservationStatus_RESERVATION_STATUS_RETURNED
+	}
+	return pb.ReservationStatus_RESERVATION_STATUS_UNSPECIFIED
+}
+
+// toProto maps an internal product to its gRPC representation
+func toProto(p *Product) *pb.Product {
+	return &pb.Product{
+		Id:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Price:         moneyToProto(p.Price),
+		RegularPrice:  optionalMoneyToProto(p.RegularPrice),
+		RatingCount:   p.RatingCount,
+		AverageRating: p.AverageRating,
+	}
+}
+
+// moneyToProto maps an amount to its gRPC representation
+func moneyToProto(m money.Money) *pb.Money {
+	return &pb.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+func optionalMoneyToProto(m *money.Money) *pb.Money {
+	if m == nil {
+		return nil
+	}
+	return moneyToProto(*m)
+}
+
+func listPriceToProto(p *ListPrice) *pb.ListPrice {
+	return &pb.ListPrice{
+		ProductId: p.ProductID,
+		VariantId: p.VariantID,
+		Price:     moneyToProto(p.Price),
+	}
+}
+
+func priceToProto(p *Price) *pb.Price {
+	return &pb.Price{
+		ProductId: p.ProductID,
+		VariantId: p.VariantID,
+		Amount:    moneyToProto(p.Amount),
+		Base:      moneyToProto(p.Base),
+		Rate:      p.Rate,
+	}
+}
+
+// priceChangeToProto maps an entry of a price history to its gRPC representation
+func priceChangeToProto(c *PriceChange) *pb.PriceChange {
+	out := &pb.PriceChange{
+		Id:            c.ID,
+		ProductId:     c.ProductID,
+		Price:         moneyToProto(c.Price),
+		EffectiveFrom: c.EffectiveFrom.Format(time.RFC3339),
+		Scheduled:     c.Scheduled,
+		Label:         c.Label,
+		CreatedAt:     c.CreatedAt.Format(time.RFC3339),
+	}
+	if c.EffectiveUntil != nil {
+		out.EffectiveUntil = c.EffectiveUntil.Format(time.RFC3339)
+	}
+	return out
+}
+
+// reviewToProto maps an internal review to its gRPC representation
+func reviewToProto(r *Review) *pb.Review {
+	return &pb.Review{
+		Id:               r.ID,
+		ProductId:        r.ProductID,
+		AccountId:        r.AccountID,
+		Rating:           r.Rating,
+		Title:            r.Title,
+		Body:             r.Body,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           reviewStatusToProto(r.Status),
+		CreatedAt:        r.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        r.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func reviewsToProto(reviews []Review) []*pb.Review {
+	out := make([]*pb.Review, 0, len(reviews))
+	for i := range reviews {
+		out = append(out, reviewToProto(&reviews[i]))
+	}
+	return out
+}
+
+// reviewStatusToProto maps an internal review status to its gRPC enum
+func reviewStatusToProto(s ReviewStatus) pb.ReviewStatus {
+	switch s {
+	case ReviewPending:
+		return pb.ReviewStatus_REVIEW_STATUS_PENDING
+	case ReviewApproved:
+		return pb.ReviewStatus_REVIEW_STATUS_APPROVED
+	case ReviewRejected:
+		return pb.ReviewStatus_REVIEW_STATUS_REJECTED
+	}
+	return pb.ReviewStatus_REVIEW_STATUS_UNSPECIFIED
+}
+
+// productImageToProto maps an internal product image to its gRPC representation
+func productImageToProto(img *ProductImage) *pb.ProductImage {
+	out := &pb.ProductImage{
+		Id:          img.ID,
+		ProductId:   img.ProductID,
+		Position:    img.Position,
+		AltText:     img.AltText,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Size:        img.Size,
+		Key:         img.Key,
+		Url:         img.URL,
+		Thumbnails:  make([]*pb.ImageThumbnail, 0, len(img.Thumbnails)),
+		CreatedAt:   img.CreatedAt.Format(time.RFC3339),
+	}
+	for _, t := range img.Thumbnails {
+		out.Thumbnails = append(out.Thumbnails, &pb.ImageThumbnail{
+			Size:        t.Size,
+			ContentType: t.ContentType,
+			Width:       t.Width,
+			Height:      t.Height,
+			Key:         t.Key,
+			Url:         t.URL,
+		})
+	}
+	return out
+}
+
+// productImagesToProto maps a list of internal product images to its gRPC representation
+func productImagesToProto(images []ProductImage) *pb.ProductImageList {
+	out := &pb.ProductImageList{Images: make([]*pb.ProductImage, 0, len(images))}
+	for i := range images {
+		out.Images = append(out.Images, productImageToProto(&images[i]))
+	}
+	return out
+}
+
+// productRecordToProto maps an internal product record to its gRPC representation
+func productRecordToProto(r *ProductRecord) *pb.ProductRecord {
+	return &pb.ProductRecord{
+		Sku:           r.SKU,
+		ProductId:     r.ProductID,
+		Name:          r.Name,
+		Description:   r.Description,
+		Price:         moneyToProto(r.Price),
+		Options:       r.Options,
+		PriceOverride: optionalMoneyToProto(r.PriceOverride),
+		Barcode:       r.Barcode,
+	}
+}
+
+// importRecordFromProto maps a gRPC import record to its internal representation
+func importRecordFromProto(r *pb.ImportRecord) *ImportRecord {
+	rec := &ImportRecord{Line: r.Line}
+	if r.Record != nil {
+		rec.ProductRecord = productRecordFromProto(r.Record)
+	}
+	return rec
+}
+
+// importReportToProto maps an internal import report to its gRPC representation
+func importReportToProto(r *ImportReport) *pb.ImportProductsResponse {
+	out := &pb.ImportProductsResponse{
+		DryRun:          r.DryRun,
+		Received:        r.Received,
+		Created:         r.Created,
+		Updated:         r.Updated,
+		Unchanged:       r.Unchanged,
+		Failed:          r.Failed,
+		ProductsCreated: r.ProductsCreated,
+		Issues:          make([]*pb.ImportIssue, 0, len(r.Issues)),
+	}
+	for _, issue := range r.Issues {
+		out.Issues = append(out.Issues, &pb.ImportIssue{Line: issue.Line, Sku: issue.SKU, Message: issue.Message})
+	}
+	return out
+}
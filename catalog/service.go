@@ -0,0 +1,2591 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/ksuid"
+
+	"github.com/olujimiAdebakin/ProtoGraph/blob"
+	"github.com/olujimiAdebakin/ProtoGraph/imaging"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/pubsub"
+)
+
+// Predefined errors for input validation
+var (
+	ErrInvalidName   = errors.New("product name cannot be empty")
+	ErrInvalidPrice  = errors.New("product price cannot be negative")
+	ErrPriceRequired = errors.New("product price needs an amount and a currency")
+	ErrTooManyIDs    = fmt.Errorf("at most %d IDs can be fetched at once", MaxBatchSize)
+
+	ErrInvalidQuery      = errors.New("search query cannot be empty")
+	ErrQueryTooLong      = fmt.Errorf("search query cannot be longer than %d characters", MaxQueryLength)
+	ErrInvalidPriceRange = errors.New("minimum price cannot be above maximum price")
+	ErrPriceCurrency     = errors.New("variant prices, scheduled prices and price filters must be in the currency of the product")
+	ErrCurrencyChange    = errors.New("the currency of a product cannot change while its variants override its price")
+
+	ErrProductNotFound     = errors.New("product not found")
+	ErrInvalidCategoryName = errors.New("category name cannot be empty")
+	ErrInvalidSlug         = errors.New("slug must be lowercase letters and digits separated by single dashes")
+	ErrSlugTaken           = errors.New("slug is already used by another category")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrCategoryCycle       = errors.New("category cannot be moved below itself or its descendants")
+	ErrCategoryHasChildren = errors.New("category has subcategories; move or delete them first")
+
+	ErrInvalidSKU       = errors.New("SKU must be 1 to 64 letters, digits, dots, dashes or underscores, starting with a letter or digit")
+	ErrSKUTaken         = errors.New("SKU is already used by another variant")
+	ErrInvalidBarcode   = errors.New("barcode must be a GTIN of 8, 12, 13 or 14 digits with a valid check digit")
+	ErrBarcodeTaken     = errors.New("barcode is already used by another variant")
+	ErrInvalidOption    = fmt.Errorf("a variant has at most %d options, each with a name and a value", MaxVariantOptions)
+	ErrDuplicateVariant = errors.New("the product already has a variant with these options")
+	ErrVariantNotFound  = errors.New("variant not found")
+
+	ErrInvalidStockItem     = errors.New("every stock item needs a product and a quantity above zero")
+	ErrInvalidStockLevel    = errors.New("on-hand quantity cannot be negative")
+	ErrVariantMismatch      = errors.New("variant does not belong to the product")
+	ErrStockPerVariant      = errors.New("the product has variants; its stock is tracked per variant")
+	ErrInsufficientStock    = errors.New("not enough stock")
+	ErrEmptyReservation     = errors.New("reservation must hold at least one item")
+	ErrInvalidTTL           = fmt.Errorf("reservations expire after at most %s", MaxReservationTTL)
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationExpired   = errors.New("reservation has expired")
+	ErrReservationCommitted = errors.New("reservation is already committed")
+	ErrReservationReleased  = errors.New("reservation is already released")
+	ErrReservationReturned  = errors.New("reservation is already returned")
+	ErrReservationPending   = errors.New("reservation is not committed")
+
+	ErrListPriceCurrency = errors.New("list prices are for currencies other than the product's own")
+	ErrBaseCurrencyRate  = errors.New("the base currency has no exchange rate; it is always 1")
+	ErrNoExchangeRate    = errors.New("no exchange rate to convert the price to the requested currency")
+
+	ErrImageNotFound    = errors.New("product image not found")
+	ErrImageTooLarge    = fmt.Errorf("images can be at most %d MiB and %d megapixels", MaxImageSize>>20, imaging.MaxPixels/1_000_000)
+	ErrInvalidImage     = errors.New("image must be a JPEG, PNG, GIF or WebP file")
+	ErrInvalidAltText   = fmt.Errorf("alt text cannot be longer than %d characters", MaxAltTextLength)
+	ErrImageOrder       = errors.New("the new order must list every image of the product exactly once")
+	ErrMediaUnavailable = errors.New("no blob store is configured for product media")
+
+	ErrInvalidSchedule        = errors.New("a scheduled price cannot start in the past and must end after it starts")
+	ErrInvalidPriceLabel      = fmt.Errorf("price labels cannot be longer than %d characters", MaxPriceLabelLength)
+	ErrScheduledPriceNotFound = errors.New("scheduled price not found")
+	ErrScheduledPriceEnded    = errors.New("the scheduled price has already ended")
+
+	ErrInvalidRating     = errors.New("rating must be from 1 to 5")
+	ErrReviewAccount     = errors.New("a review needs the ID of the account writing it")
+	ErrInvalidReview     = fmt.Errorf("a review needs a title of at most %d characters and a body of at most %d", MaxReviewTitleLength, MaxReviewBodyLength)
+	ErrDuplicateReview   = errors.New("the account has already reviewed this product")
+	ErrReviewNotFound    = errors.New("review not found")
+	ErrInvalidModeration = errors.New("review status must be PENDING, APPROVED or REJECTED")
+
+	ErrRecordProduct   = errors.New("a record without SKU needs the product_id of an existing product")
+	ErrSKUProduct      = errors.New("the SKU belongs to another product")
+	ErrDuplicateSKU    = errors.New("the SKU already appears in the import")
+	ErrProductConflict = errors.New("the product fields differ from an earlier record of the same product")
+)
+
+// MaxBatchSize caps how many products GetProductsByIDs fetches per call.
+const MaxBatchSize = 100
+
+// MaxQueryLength caps the length of a search query, in characters.
+const MaxQueryLength = 200
+
+// MaxVariantOptions caps the number of options of a variant.
+const MaxVariantOptions = 10
+
+// MaxImageSize caps the size of an uploaded image file, in bytes.
+const MaxImageSize = 20 << 20
+
+// MaxAltTextLength caps the alt text of an image, in characters.
+const MaxAltTextLength = 500
+
+// MaxPriceLabelLength caps the label of a scheduled price, in characters.
+const MaxPriceLabelLength = 200
+
+// Caps of the title and of the body of a review, in characters.
+const (
+	MaxReviewTitleLength = 200
+	MaxReviewBodyLength  = 10_000
+)
+
+// MaxImportIssues caps the issues listed in an ImportReport; the records
+// beyond it are still counted as failed.
+const MaxImportIssues = 1000
+
+// ThumbnailSizes are the bounding squares, in pixels, of the thumbnails
+// made of every uploaded image. Images that already fit get no thumbnail
+// of that size.
+var ThumbnailSizes = []int{160, 640}
+
+// Lifetime of a stock reservation that is neither committed nor released,
+// when the caller does not choose one, and the longest allowed.
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+)
+
+// Service defines the business operations related to the product catalog.
+type Service interface {
+	// PostProduct creates a new product and returns it with its generated ID.
+	PostProduct(ctx context.Context, name, description string, price money.Money) (*Product, error)
+
+	// PutProduct updates an existing product.
+	PutProduct(ctx context.Context, id, name, description string, price money.Money) (*Product, error)
+
+	// GetProduct fetches a product by its unique ID.
+	GetProduct(ctx context.Context, id string) (*Product, error)
+
+	// GetProductsByIDs fetches up to MaxBatchSize products keyed by ID.
+	// IDs that do not exist are absent from the map.
+	GetProductsByIDs(ctx context.Context, ids []string) (map[string]Product, error)
+
+	// ListProducts returns a paginated list of products.
+	ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
+
+	// SearchProducts returns a page of the products matching query, most
+	// relevant first. When no product matches the words of query, products
+	// with a similar name are returned instead and the result is Fuzzy.
+	SearchProducts(ctx context.Context, query string, filters SearchFilters, skip uint64, take uint64) (*SearchResult, error)
+
+	// WatchProductPrice streams the product every time its price changes,
+	// until ctx is done. The channel is also closed when the watcher falls
+	// too far behind; ctx.Err() is nil in that case.
+	WatchProductPrice(ctx context.Context, productID string) <-chan Product
+
+	// DeleteProduct removes a product by ID.
+	DeleteProduct(ctx context.Context, id string) error
+
+	// CreateCategory adds a category below parentID, or a root category when
+	// parentID is empty. An empty slug is derived from the name.
+	CreateCategory(ctx context.Context, parentID, name, slug string, position int32) (*Category, error)
+
+	// UpdateCategory renames, reorders or moves a category with its subtree.
+	UpdateCategory(ctx context.Context, id, parentID, name, slug string, position int32) (*Category, error)
+
+	// GetCategory fetches a category by ID; (nil, nil) when it does not exist.
+	GetCategory(ctx context.Context, id string) (*Category, error)
+
+	// GetCategoryBySlug fetches a category by slug; (nil, nil) when it does not exist.
+	GetCategoryBySlug(ctx context.Context, slug string) (*Category, error)
+
+	// ListCategories returns the children of parentID in order, or the root
+	// categories when parentID is empty.
+	ListCategories(ctx context.Context, parentID string) ([]Category, error)
+
+	// GetCategoryPaths returns the breadcrumbs of up to MaxBatchSize
+	// categories: the path from the root down to the category itself.
+	// Unknown IDs are absent from the map.
+	GetCategoryPaths(ctx context.Context, ids []string) (map[string][]Category, error)
+
+	// DeleteCategory removes a category without subcategories. Its products
+	// stay in the catalog.
+	DeleteCategory(ctx context.Context, id string) error
+
+	// SetProductCategories replaces the categories of a product.
+	SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error
+
+	// GetProductCategories returns the categories of up to MaxBatchSize
+	// products. Products without categories map to an empty list.
+	GetProductCategories(ctx context.Context, productIDs []string) (map[string][]Category, error)
+
+	// ListProductsByCategory returns a page of the products of a category
+	// and of all its descendants.
+	ListProductsByCategory(ctx context.Context, categoryID string, skip uint64, take uint64) ([]Product, error)
+
+	// CreateVariant adds a variant to the product v.ProductID; v.ID is ignored.
+	CreateVariant(ctx context.Context, v Variant) (*Variant, error)
+
+	// UpdateVariant changes the SKU, options, price, barcode and position
+	// of the variant v.ID; its product cannot change.
+	UpdateVariant(ctx context.Context, v Variant) (*Variant, error)
+
+	// GetVariantsByIDs fetches up to MaxBatchSize variants keyed by ID.
+	// IDs that do not exist are absent from the map.
+	GetVariantsByIDs(ctx context.Context, ids []string) (map[string]Variant, error)
+
+	// GetVariantsBySKUs fetches up to MaxBatchSize variants keyed by their
+	// normalized SKU (see NormalizeSKU). SKUs that do not exist are absent
+	// from the map.
+	GetVariantsBySKUs(ctx context.Context, skus []string) (map[string]Variant, error)
+
+	// GetProductVariants returns the variants of up to MaxBatchSize
+	// products in order. Products without variants map to an empty list.
+	GetProductVariants(ctx context.Context, productIDs []string) (map[string][]Variant, error)
+
+	// DeleteVariant removes a variant by ID.
+	DeleteVariant(ctx context.Context, id string) error
+
+	// SetStock sets the on-hand quantity of a variant, or of a product sold
+	// without variants when variantID is empty, and starts tracking it.
+	SetStock(ctx context.Context, productID, variantID string, onHand int64) (*StockLevel, error)
+
+	// AdjustStock adds delta to the on-hand quantity of an item, e.g. when
+	// goods are received or written off. An untracked item starts at zero.
+	AdjustStock(ctx context.Context, productID, variantID string, delta int64) (*StockLevel, error)
+
+	// GetStockLevels returns the stock of the tracked items of up to
+	// MaxBatchSize products. Untracked products map to an empty list.
+	GetStockLevels(ctx context.Context, productIDs []string) (map[string][]StockLevel, error)
+
+	// ReserveStock holds items for ttl, or DefaultReservationTTL when ttl is
+	// zero, all of them or none. It fails with ErrInsufficientStock when a
+	// tracked item has less available; untracked items are not held.
+	ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error)
+
+	// CommitReservation takes the items of a pending reservation out of
+	// stock for good. Committing it again has no effect.
+	CommitReservation(ctx context.Context, id string) (*Reservation, error)
+
+	// ReleaseReservation gives the items of a pending reservation back.
+	// Releasing it again, or once it has expired, has no effect.
+	ReleaseReservation(ctx context.Context, id string) (*Reservation, error)
+
+	// ReturnReservation puts the items of a committed reservation back into
+	// stock, e.g. when its order is cancelled. Returning it again has no
+	// effect.
+	ReturnReservation(ctx context.Context, id string) (*Reservation, error)
+
+	// SetListPrice sets the explicit price of a product, or of one of its
+	// variants when variantID is set, in the currency of price. The
+	// currency must not be the product's own.
+	SetListPrice(ctx context.Context, productID, variantID string, price money.Money) (*ListPrice, error)
+
+	// DeleteListPrice removes a list price; the currency falls back to
+	// conversion. Deleting a missing one has no effect.
+	DeleteListPrice(ctx context.Context, productID, variantID, currency string) error
+
+	// GetListPrices returns the list prices of up to MaxBatchSize products
+	// and of their variants. Products without any map to an empty list.
+	GetListPrices(ctx context.Context, productIDs []string) (map[string][]ListPrice, error)
+
+	// BaseCurrency is the currency exchange rates are quoted against.
+	BaseCurrency() string
+
+	// SetExchangeRates stores rates against BaseCurrency; currencies not
+	// given keep their rate.
+	SetExchangeRates(ctx context.Context, rates []ExchangeRate) ([]ExchangeRate, error)
+
+	// ListExchangeRates returns every rate against BaseCurrency, ordered
+	// by currency.
+	ListExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+
+	// GetPrices prices up to MaxBatchSize items in currency, in order: at
+	// their list price in currency when they have one, otherwise at their
+	// own price converted with the exchange rates. An empty currency
+	// prices every item in its own currency.
+	GetPrices(ctx context.Context, items []PriceItem, currency string) ([]Price, error)
+
+	// UploadProductImage stores data as the last image of a product, with
+	// its thumbnails, and returns its record.
+	UploadProductImage(ctx context.Context, productID, altText string, data []byte) (*ProductImage, error)
+
+	// UpdateProductImage changes the alt text of an image.
+	UpdateProductImage(ctx context.Context, id, altText string) (*ProductImage, error)
+
+	// ReorderProductImages puts the images of a product in the order of
+	// imageIDs, which must list each of them once.
+	ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error)
+
+	// DeleteProductImage removes an image and its files.
+	DeleteProductImage(ctx context.Context, id string) error
+
+	// GetProductImages returns the images of up to MaxBatchSize products in
+	// order. Products without images map to an empty list.
+	GetProductImages(ctx context.Context, productIDs []string) (map[string][]ProductImage, error)
+
+	// ImportProducts upserts the records returned by next until it returns
+	// io.EOF. Records are matched to variants by SKU, so importing the same
+	// records again changes nothing. Invalid records are reported and
+	// skipped; with dryRun they are only validated and nothing is written.
+	ImportProducts(ctx context.Context, next func() (*ImportRecord, error), dryRun bool) (*ImportReport, error)
+
+	// ExportProducts passes the whole catalog to emit, a page of records at
+	// a time: a record per variant, or one without SKU for a product sold
+	// without variants, ordered by product and position.
+	ExportProducts(ctx context.Context, emit func([]ProductRecord) error) error
+
+	// ScheduleProductPrice records a price of productID, in its currency,
+	// that takes effect at from, or now when from is zero. With until it is
+	// a sale that ends then; without, it becomes the regular price.
+	ScheduleProductPrice(ctx context.Context, productID string, price money.Money, from time.Time, until *time.Time, label string) (*PriceChange, error)
+
+	// CancelScheduledPrice removes a scheduled price that has not started,
+	// or ends a running one now, so that its history is kept.
+	CancelScheduledPrice(ctx context.Context, id string) error
+
+	// PublishPriceSchedules notifies the watchers of products whose
+	// scheduled prices started or ended, checking every interval until ctx
+	// is done.
+	PublishPriceSchedules(ctx context.Context, interval time.Duration)
+
+	// GetPriceHistory fetches the price history of products keyed by
+	// product ID, the latest effective first, scheduled prices to come
+	// included. Products without history map to an empty list.
+	GetPriceHistory(ctx context.Context, productIDs []string) (map[string][]PriceChange, error)
+
+	// CreateReview stores the review of r.AccountID for r.ProductID,
+	// PENDING until it is moderated, and flags it a verified purchase when
+	// the account ordered the product. An account reviews a product once.
+	CreateReview(ctx context.Context, r Review) (*Review, error)
+
+	// ModerateReview sets the status of a review. The rating of the product
+	// counts the reviews while they are APPROVED.
+	ModerateReview(ctx context.Context, id string, status ReviewStatus) (*Review, error)
+
+	// GetProductReviews returns a page of the APPROVED reviews of each of up
+	// to MaxBatchSize products, newest first. Products without reviews map
+	// to an empty list.
+	GetProductReviews(ctx context.Context, productIDs []string, skip uint64, take uint64) (map[string][]Review, error)
+
+	// ListReviews lists the reviews of every product in status, or in any
+	// status when it is empty, newest first, e.g. those PENDING moderation.
+	ListReviews(ctx context.Context, status ReviewStatus, skip uint64, take uint64) ([]Review, error)
+}
+
+// PurchaseVerifier tells whether an account has bought a product; the
+// order service implements it.
+type PurchaseVerifier interface {
+	HasPurchased(ctx context.Context, accountID, productID string) (bool, error)
+}
+
+// Product represents an item that can be ordered.
+// Price is exact, in minor units of its currency. It is the price in effect
+// when the product was read, which a running sale may set.
+type Product struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+
+	// RegularPrice is the price without the running sale; nil while no
+	// sale runs. Ignored when the product is stored.
+	RegularPrice *money.Money `json:"regularPrice,omitempty"`
+
+	// RatingCount is the number of APPROVED reviews and AverageRating the
+	// mean of their ratings, 0 without any. Both are kept up to date as
+	// reviews are moderated and ignored when the product is stored.
+	RatingCount   int64   `json:"ratingCount"`
+	AverageRating float64 `json:"averageRating,omitempty"`
+}
+
+// regular returns the fields of p that PutProduct takes, with its regular
+// price as Price.
+func (p Product) regular() Product {
+	if p.RegularPrice != nil {
+		p.Price, p.RegularPrice = *p.RegularPrice, nil
+	}
+	p.RatingCount, p.AverageRating = 0, 0
+	return p
+}
+
+// ReviewStatus is the moderation stage of a review. Reviews are PENDING
+// when written, and only APPROVED ones are shown and rate the product.
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "PENDING"
+	ReviewApproved ReviewStatus = "APPROVED"
+	ReviewRejected ReviewStatus = "REJECTED"
+)
+
+// Valid reports whether s is a known status.
+func (s ReviewStatus) Valid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// Review is the opinion of a customer about a product. VerifiedPurchase is
+// set when the account had ordered the product when the review was written.
+type Review struct {
+	ID               string       `json:"id"`
+	ProductID        string       `json:"productId"`
+	AccountID        string       `json:"accountId"`
+	Rating           int32        `json:"rating"`
+	Title            string       `json:"title"`
+	Body             string       `json:"body"`
+	VerifiedPurchase bool         `json:"verifiedPurchase"`
+	Status           ReviewStatus `json:"status"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	UpdatedAt        time.Time    `json:"updatedAt"`
+}
+
+// Category is a node of the product taxonomy. Root categories have no
+// ParentID. Siblings are ordered by Position, then by name. Slug is unique
+// over the whole tree, so storefront URLs can use it alone.
+type Category struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parentId,omitempty"`
+	Name     string `json:"name"`
+	Slug     string `json:"slug"`
+	Position int32  `json:"position"`
+}
+
+// Variant is a purchasable variation of a product, e.g. size M in red,
+// identified by its SKU. Options maps option names to values and is unique
+// among the variants of a product.
+type Variant struct {
+	ID        string            `json:"id"`
+	ProductID string            `json:"productId"`
+	SKU       string            `json:"sku"`
+	Options   map[string]string `json:"options"`
+
+	// PriceOverride replaces the price of the product when set; it is in
+	// the currency of the product
+	PriceOverride *money.Money `json:"priceOverride,omitempty"`
+
+	// Price is PriceOverride, or the price of the product; computed when
+	// the variant is read and ignored when it is stored
+	Price money.Money `json:"price"`
+
+	// Barcode is a GTIN (EAN-8, UPC-A, EAN-13 or GTIN-14), empty when unknown
+	Barcode  string `json:"barcode,omitempty"`
+	Position int32  `json:"position"`
+}
+
+// StockItem is a quantity of a variant, or of a product sold without
+// variants when VariantID is empty.
+type StockItem struct {
+	ProductID string `json:"productId"`
+	VariantID string `json:"variantId,omitempty"`
+	Quantity  uint32 `json:"quantity"`
+}
+
+// itemID is the key of the stock of the item: its variant, or its product.
+func (i StockItem) itemID() string {
+	if i.VariantID != "" {
+		return i.VariantID
+	}
+	return i.ProductID
+}
+
+// StockLevel is the stock of a tracked item.
+type StockLevel struct {
+	ProductID string `json:"productId"`
+	VariantID string `json:"variantId,omitempty"`
+	OnHand    uint32 `json:"onHand"`
+
+	// Reserved is held by pending reservations that have not expired
+	Reserved uint32 `json:"reserved"`
+}
+
+// Available is the quantity that can still be reserved. It is zero rather
+// than negative when on-hand stock was counted below the reserved quantity.
+func (l StockLevel) Available() uint32 {
+	if l.Reserved >= l.OnHand {
+		return 0
+	}
+	return l.OnHand - l.Reserved
+}
+
+// ReservationStatus is the lifecycle stage of a stock reservation.
+// EXPIRED is never stored: it is how a PENDING reservation past its expiry
+// is reported. RETURNED is a COMMITTED one whose items went back to stock.
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "PENDING"
+	ReservationCommitted ReservationStatus = "COMMITTED"
+	ReservationReleased  ReservationStatus = "RELEASED"
+	ReservationExpired   ReservationStatus = "EXPIRED"
+	ReservationReturned  ReservationStatus = "RETURNED"
+)
+
+// Reservation holds stock for a checkout until it is committed, released
+// or expires. Items are the tracked items it holds.
+type Reservation struct {
+	ID        string            `json:"id"`
+	Status    ReservationStatus `json:"status"`
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Items     []StockItem       `json:"items"`
+}
+
+// ListPrice is the explicit price of a product, or of one of its variants
+// when VariantID is set, in a currency other than the product's own.
+// Variants without one inherit the list price of their product unless they
+// override the price of the product.
+type ListPrice struct {
+	ProductID string      `json:"productId"`
+	VariantID string      `json:"variantId,omitempty"`
+	Price     money.Money `json:"price"`
+}
+
+// itemID is the key of the list price: its variant, or its product.
+func (l ListPrice) itemID() string {
+	return StockItem{ProductID: l.ProductID, VariantID: l.VariantID}.itemID()
+}
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// buys, as a decimal string (see money.ParseRate).
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PriceItem is a product, or one of its variants when VariantID is set,
+// to be priced by GetPrices.
+type PriceItem struct {
+	ProductID string `json:"productId"`
+	VariantID string `json:"variantId,omitempty"`
+}
+
+// Price is what an item costs in a requested currency. Base is the own
+// price of the item, in the currency of its product. Amount is a list
+// price, Base itself, or Base converted at Rate; Rate is empty unless the
+// price was converted.
+type Price struct {
+	ProductID string      `json:"productId"`
+	VariantID string      `json:"variantId,omitempty"`
+	Amount    money.Money `json:"amount"`
+	Base      money.Money `json:"base"`
+	Rate      string      `json:"rate,omitempty"`
+}
+
+// ProductImage is a picture of a product. Images are shown in the order of
+// Position. Key names the original file in the blob store and URL is where
+// clients download it; thumbnails are smaller copies of it.
+type ProductImage struct {
+	ID          string      `json:"id"`
+	ProductID   string      `json:"productId"`
+	Position    int32       `json:"position"`
+	AltText     string      `json:"altText"`
+	ContentType string      `json:"contentType"`
+	Width       int32       `json:"width"`
+	Height      int32       `json:"height"`
+	Size        int64       `json:"size"`
+	Key         string      `json:"key"`
+	URL         string      `json:"url"`
+	Thumbnails  []Thumbnail `json:"thumbnails"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+// Thumbnail is a copy of a product image scaled down to fit a square of
+// Size pixels.
+type Thumbnail struct {
+	Size        int32  `json:"size"`
+	ContentType string `json:"contentType"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	Key         string `json:"key"`
+	URL         string `json:"url,omitempty"`
+}
+
+// keys lists the blobs of the image, the original first.
+func (img *ProductImage) keys() []string {
+	keys := []string{img.Key}
+	for _, t := range img.Thumbnails {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// PriceChange is an entry of the price history of a product. Changes of
+// the regular price are recorded as PutProduct makes them and last until
+// the next one. Scheduled prices are recorded ahead of time: without
+// EffectiveUntil they become the regular price at EffectiveFrom, with it
+// they are sales, which win over the regular price while they run.
+type PriceChange struct {
+	ID             string      `json:"id"`
+	ProductID      string      `json:"productId"`
+	Price          money.Money `json:"price"`
+	EffectiveFrom  time.Time   `json:"effectiveFrom"`
+	EffectiveUntil *time.Time  `json:"effectiveUntil,omitempty"`
+	Scheduled      bool        `json:"scheduled"`
+
+	// Label names the promotion of a scheduled price, e.g. "Summer sale"
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProductRecord is a row of a bulk import or export: a variant, keyed by
+// its SKU, with the fields of its product. Without a SKU it stands for the
+// product ProductID itself, e.g. one sold without variants.
+type ProductRecord struct {
+	SKU         string            `json:"sku"`
+	ProductID   string            `json:"productId,omitempty"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       money.Money       `json:"price"`
+	Options     map[string]string `json:"options,omitempty"`
+
+	// PriceOverride is in the currency of Price
+	PriceOverride *money.Money `json:"priceOverride,omitempty"`
+	Barcode       string       `json:"barcode,omitempty"`
+}
+
+// ImportRecord is a ProductRecord read from line Line of an imported file,
+// which issues refer to.
+type ImportRecord struct {
+	Line int64 `json:"line"`
+	ProductRecord
+}
+
+// ImportReport sums up an import: every record received was either
+// created (a new variant), updated, unchanged or failed.
+type ImportReport struct {
+	DryRun          bool          `json:"dryRun"`
+	Received        int64         `json:"received"`
+	Created         int64         `json:"created"`
+	Updated         int64         `json:"updated"`
+	Unchanged       int64         `json:"unchanged"`
+	Failed          int64         `json:"failed"`
+	ProductsCreated int64         `json:"productsCreated"`
+	Issues          []ImportIssue `json:"issues"`
+}
+
+// ImportIssue tells why the record of a line failed.
+type ImportIssue struct {
+	Line    int64  `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// SearchFilters narrows a product search. Nil bounds are not applied;
+// both bounds are inclusive and must be of the same currency. Products
+// priced in another currency than the bounds do not match.
+type SearchFilters struct {
+	MinPrice *money.Money `json:"minPrice,omitempty"`
+	MaxPrice *money.Money `json:"maxPrice,omitempty"`
+}
+
+// currency is the currency of the price bounds, empty when there are none.
+func (f SearchFilters) currency() string {
+	if f.MinPrice != nil {
+		return f.MinPrice.Currency
+	}
+	if f.MaxPrice != nil {
+		return f.MaxPrice.Currency
+	}
+	return ""
+}
+
+// SearchQuery is a validated search, as passed to the repository.
+type SearchQuery struct {
+	Text    string
+	Filters SearchFilters
+	Skip    uint64
+	Take    uint64
+}
+
+// SearchHit is a product found by a search. NameHighlight and
+// DescriptionHighlight are the name and the best matching fragments of the
+// description, with the matched words wrapped in <mark></mark>. The text
+// around the marks is not HTML-escaped.
+type SearchHit struct {
+	Product              Product `json:"product"`
+	Rank                 float64 `json:"rank"`
+	NameHighlight        string  `json:"nameHighlight"`
+	DescriptionHighlight string  `json:"descriptionHighlight"`
+}
+
+// SearchResult is one page of search hits. Total counts the hits over all
+// pages. Fuzzy is set when the hits are products with a name similar to the
+// query rather than full-text matches; they are ranked by similarity and
+// not highlighted.
+type SearchResult struct {
+	Hits  []SearchHit `json:"hits"`
+	Total uint64      `json:"total"`
+	Fuzzy bool        `json:"fuzzy"`
+}
+
+// catalogService implements the Service interface by interacting with a repository.
+// Price changes are fanned out to the watchers connected to this instance.
+type catalogService struct {
+	repository   Repository
+	events       *pubsub.Broker[Product]
+	baseCurrency string
+	media        blob.BlobStore
+	purchases    PurchaseVerifier
+}
+
+// NewService constructs a new Service implementation backed by a
+// repository. Exchange rates are quoted against baseCurrency, and product
+// images are kept in media; without one, uploads fail. Reviews are checked
+// against the orders of purchases; without one, none is verified.
+func NewService(r Repository, baseCurrency string, media blob.BlobStore, purchases PurchaseVerifier) Service {
+	return &catalogService{
+		repository:   r,
+		events:       pubsub.NewBroker[Product](pubsub.DefaultBuffer),
+		baseCurrency: baseCurrency,
+		media:        media,
+		purchases:    purchases,
+	}
+}
+
+// PostProduct validates input and stores the new product.
+func (s *catalogService) PostProduct(ctx context.Context, name, description string, price money.Money) (*Product, error) {
+	if err := validateProduct(name, price); err != nil {
+		return nil, err
+	}
+	return s.storeProduct(ctx, nil, ksuid.New().String(), name, description, price)
+}
+
+// PutProduct validates input and upserts the product. price is its regular
+// price; a running sale keeps its own. Watchers of the product are
+// notified when its price changed. Its currency can only change while no
+// variant overrides its price, since overrides are in the currency of the
+// product.
+func (s *catalogService) PutProduct(ctx context.Context, id, name, description string, price money.Money) (*Product, error) {
+	if err := validateProduct(name, price); err != nil {
+		return nil, err
+	}
+
+	previous, err := s.repository.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.Price.Currency != price.Currency {
+		variants, err := s.repository.GetProductVariants(ctx, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range variants {
+			if v.PriceOverride != nil {
+				return nil, ErrCurrencyChange
+			}
+		}
+	}
+
+	p, err := s.storeProduct(ctx, previous, id, name, description, price)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.RegularPrice != nil {
+		// Read the price back, which the sale still sets unless the
+		// currency changed
+		if p, err = s.repository.GetProductByID(ctx, id); err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, ErrProductNotFound
+		}
+	}
+
+	if previous != nil && previous.Price != p.Price {
+		s.events.Publish(*p)
+	}
+	return p, nil
+}
+
+// WatchProductPrice subscribes to the price changes of productID.
+func (s *catalogService) WatchProductPrice(ctx context.Context, productID string) <-chan Product {
+	return s.events.Subscribe(ctx, func(p Product) bool {
+		return p.ID == productID
+	})
+}
+
+func validateProduct(name string, price money.Money) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+	return validatePrice(price)
+}
+
+// validatePrice checks that price is a non-negative amount of a currency.
+func validatePrice(price money.Money) error {
+	if price.Currency == "" {
+		return ErrPriceRequired
+	}
+	if !money.ValidCurrency(price.Currency) {
+		return money.ErrInvalidCurrency
+	}
+	if price.IsNegative() {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// storeProduct upserts an already validated product. Its price is recorded
+// in the price history unless it is the regular price of previous, the
+// stored product if there is one.
+func (s *catalogService) storeProduct(ctx context.Context, previous *Product, id, name, description string, price money.Money) (*Product, error) {
+	p := &Product{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		Price:       price,
+	}
+	if previous != nil {
+		p.RatingCount, p.AverageRating = previous.RatingCount, previous.AverageRating
+	}
+	var change *PriceChange
+	if previous == nil || previous.regular().Price != price {
+		change = &PriceChange{ID: ksuid.New().String(), ProductID: id, Price: price}
+	}
+	if err := s.repository.PutProduct(ctx, *p, change); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetProduct retrieves a product by ID via the repository.
+func (s *catalogService) GetProduct(ctx context.Context, id string) (*Product, error) {
+	return s.repository.GetProductByID(ctx, id)
+}
+
+// GetProductsByIDs de-duplicates ids and fetches them in one query.
+func (s *catalogService) GetProductsByIDs(ctx context.Context, ids []string) (map[string]Product, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	products := map[string]Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	found, err := s.repository.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range found {
+		products[p.ID] = p
+	}
+	return products, nil
+}
+
+// ListProducts provides a paginated list of products.
+// Caps the page size to 100 to prevent overloading.
+func (s *catalogService) ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
+	if take > 100 || (skip == 0 && take == 0) {
+		take = 100
+	}
+	return s.repository.ListProducts(ctx, skip, take)
+}
+
+// SearchProducts runs a full-text search, and falls back to a trigram
+// search when the full-text search matches nothing at all. Page sizes are
+// capped like those of ListProducts.
+func (s *catalogService) SearchProducts(ctx context.Context, query string, filters SearchFilters, skip uint64, take uint64) (*SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrInvalidQuery
+	}
+	if len([]rune(query)) > MaxQueryLength {
+		return nil, ErrQueryTooLong
+	}
+	if filters.MinPrice != nil && filters.MaxPrice != nil {
+		if filters.MinPrice.Currency != filters.MaxPrice.Currency {
+			return nil, ErrPriceCurrency
+		}
+		if filters.MinPrice.Amount > filters.MaxPrice.Amount {
+			return nil, ErrInvalidPriceRange
+		}
+	}
+	if c := filters.currency(); c != "" && !money.ValidCurrency(c) {
+		return nil, money.ErrInvalidCurrency
+	}
+	if take > 100 || take == 0 {
+		take = 100
+	}
+
+	q := SearchQuery{Text: query, Filters: filters, Skip: skip, Take: take}
+	hits, total, err := s.repository.SearchProducts(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		return &SearchResult{Hits: hits, Total: total}, nil
+	}
+
+	hits, total, err = s.repository.SearchProductsFuzzy(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Hits: hits, Total: total, Fuzzy: total > 0}, nil
+}
+
+// DeleteProduct removes a product by ID.
+func (s *catalogService) DeleteProduct(ctx context.Context, id string) error {
+	// The image records go with the product; their files are removed after
+	images, err := s.repository.GetProductImages(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if err := s.repository.DeleteProduct(ctx, id); err != nil {
+		return err
+	}
+	for i := range images {
+		s.deleteBlobs(ctx, images[i].keys())
+	}
+	return nil
+}
+
+// CreateCategory validates input and stores the new category.
+func (s *catalogService) CreateCategory(ctx context.Context, parentID, name, slug string, position int32) (*Category, error) {
+	c, err := newCategory(ksuid.New().String(), parentID, name, slug, position)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(ctx, c); err != nil {
+		return nil, err
+	}
+	if err := s.repository.PutCategory(ctx, *c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateCategory validates input and updates an existing category. Moves
+// below the category itself or one of its descendants are refused.
+func (s *catalogService) UpdateCategory(ctx context.Context, id, parentID, name, slug string, position int32) (*Category, error) {
+	existing, err := s.repository.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	c, err := newCategory(id, parentID, name, slug, position)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkParent(ctx, c); err != nil {
+		return nil, err
+	}
+	if err := s.repository.PutCategory(ctx, *c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newCategory validates the fields of a category and derives a missing slug.
+func newCategory(id, parentID, name, slug string, position int32) (*Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidCategoryName
+	}
+	if slug == "" {
+		slug = slugify(name)
+	}
+	if !validSlug(slug) {
+		return nil, ErrInvalidSlug
+	}
+	return &Category{
+		ID:       id,
+		ParentID: parentID,
+		Name:     name,
+		Slug:     slug,
+		Position: position,
+	}, nil
+}
+
+// checkParent makes sure the parent of c exists and is not c or one of its
+// descendants, i.e. that c does not appear on the path to the parent.
+func (s *catalogService) checkParent(ctx context.Context, c *Category) error {
+	if c.ParentID == "" {
+		return nil
+	}
+	paths, err := s.repository.GetCategoryPaths(ctx, []string{c.ParentID})
+	if err != nil {
+		return err
+	}
+	path, ok := paths[c.ParentID]
+	if !ok {
+		return ErrParentNotFound
+	}
+	for _, ancestor := range path {
+		if ancestor.ID == c.ID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
+// slugify derives a slug from a name: "Men's Shoes & Boots" becomes
+// "men-s-shoes-boots". Characters other than ASCII letters and digits
+// separate words.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// validSlug reports whether slug is lowercase ASCII letters and digits
+// separated by single dashes, at most 240 characters.
+func validSlug(slug string) bool {
+	if slug == "" || len(slug) > 240 || slug[0] == '-' || slug[len(slug)-1] == '-' || strings.Contains(slug, "--") {
+		return false
+	}
+	for _, r := range slug {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCategory retrieves a category by ID via the repository.
+func (s *catalogService) GetCategory(ctx context.Context, id string) (*Category, error) {
+	return s.repository.GetCategoryByID(ctx, id)
+}
+
+// GetCategoryBySlug retrieves a category by slug via the repository.
+func (s *catalogService) GetCategoryBySlug(ctx context.Context, slug string) (*Category, error) {
+	return s.repository.GetCategoryBySlug(ctx, slug)
+}
+
+// ListCategories lists one level of the tree.
+func (s *catalogService) ListCategories(ctx context.Context, parentID string) ([]Category, error) {
+	return s.repository.ListCategories(ctx, parentID)
+}
+
+// GetCategoryPaths de-duplicates ids and walks them up in one query.
+func (s *catalogService) GetCategoryPaths(ctx context.Context, ids []string) (map[string][]Category, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+	if len(ids) == 0 {
+		return map[string][]Category{}, nil
+	}
+	return s.repository.GetCategoryPaths(ctx, ids)
+}
+
+// DeleteCategory removes a category by ID.
+func (s *catalogService) DeleteCategory(ctx context.Context, id string) error {
+	return s.repository.DeleteCategory(ctx, id)
+}
+
+// SetProductCategories de-duplicates categoryIDs and replaces the
+// assignments of the product with them.
+func (s *catalogService) SetProductCategories(ctx context.Context, productID string, categoryIDs []string) error {
+	categoryIDs = uniqueIDs(categoryIDs)
+	if len(categoryIDs) > MaxBatchSize {
+		return ErrTooManyIDs
+	}
+	return s.repository.SetProductCategories(ctx, productID, categoryIDs)
+}
+
+// GetProductCategories de-duplicates productIDs and fetches their
+// categories in one query.
+func (s *catalogService) GetProductCategories(ctx context.Context, productIDs []string) (map[string][]Category, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	categories := make(map[string][]Category, len(productIDs))
+	if len(productIDs) == 0 {
+		return categories, nil
+	}
+
+	found, err := s.repository.GetProductCategories(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range productIDs {
+		categories[id] = found[id]
+		if categories[id] == nil {
+			categories[id] = []Category{}
+		}
+	}
+	return categories, nil
+}
+
+// ListProductsByCategory lists the products below a category, with page
+// sizes capped like those of ListProducts.
+func (s *catalogService) ListProductsByCategory(ctx context.Context, categoryID string, skip uint64, take uint64) ([]Product, error) {
+	if take > 100 || take == 0 {
+		take = 100
+	}
+	return s.repository.ListProductsByCategory(ctx, categoryID, skip, take)
+}
+
+// CreateVariant validates input and stores the new variant.
+func (s *catalogService) CreateVariant(ctx context.Context, v Variant) (*Variant, error) {
+	v.ID = ksuid.New().String()
+	return s.storeVariant(ctx, v)
+}
+
+// UpdateVariant validates input and updates an existing variant.
+func (s *catalogService) UpdateVariant(ctx context.Context, v Variant) (*Variant, error) {
+	existing, err := s.repository.GetVariantsByIDs(ctx, []string{v.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		return nil, ErrVariantNotFound
+	}
+
+	v.ProductID = existing[0].ProductID
+	return s.storeVariant(ctx, v)
+}
+
+// storeVariant normalizes and validates v, stores it and reads it back
+// with its effective price. A price override must be in the currency of
+// the product.
+func (s *catalogService) storeVariant(ctx context.Context, v Variant) (*Variant, error) {
+	v.SKU = NormalizeSKU(v.SKU)
+	if !validSKU(v.SKU) {
+		return nil, ErrInvalidSKU
+	}
+	if v.Barcode != "" && !validGTIN(v.Barcode) {
+		return nil, ErrInvalidBarcode
+	}
+	if v.PriceOverride != nil {
+		if err := validatePrice(*v.PriceOverride); err != nil {
+			return nil, err
+		}
+		p, err := s.repository.GetProductByID(ctx, v.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, ErrProductNotFound
+		}
+		if p.Price.Currency != v.PriceOverride.Currency {
+			return nil, ErrPriceCurrency
+		}
+	}
+
+	options, err := normalizeOptions(v.Options)
+	if err != nil {
+		return nil, err
+	}
+	v.Options = options
+
+	if err := s.repository.PutVariant(ctx, v); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.repository.GetVariantsByIDs(ctx, []string{v.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		// Deleted, with its product, right after it was stored
+		return nil, ErrVariantNotFound
+	}
+	return &stored[0], nil
+}
+
+// NormalizeSKU returns the canonical form of a SKU. SKUs are matched case
+// insensitively and stored uppercase.
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
+
+// validSKU reports whether sku is 1 to 64 uppercase ASCII letters, digits,
+// dots, dashes or underscores, starting with a letter or digit.
+func validSKU(sku string) bool {
+	if sku == "" || len(sku) > 64 {
+		return false
+	}
+	for i, r := range sku {
+		alnum := (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if i == 0 && !alnum {
+			return false
+		}
+		if !alnum && r != '.' && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// validGTIN reports whether code is an 8, 12, 13 or 14 digit GTIN whose
+// last digit is the GS1 check digit of the others.
+func validGTIN(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		// Weights alternate 3, 1, 3, ... from the digit left of the check digit
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	check := int(code[len(code)-1] - '0')
+	return check >= 0 && check <= 9 && (10-sum%10)%10 == check
+}
+
+// normalizeOptions trims the names and values of options and checks that
+// there are at most MaxVariantOptions of them, none empty. Names differing
+// only in surrounding space are rejected as duplicates.
+func normalizeOptions(options map[string]string) (map[string]string, error) {
+	if len(options) > MaxVariantOptions {
+		return nil, ErrInvalidOption
+	}
+
+	out := make(map[string]string, len(options))
+	for name, value := range options {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return nil, ErrInvalidOption
+		}
+		if _, ok := out[name]; ok {
+			return nil, ErrInvalidOption
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// GetVariantsByIDs de-duplicates ids and fetches them in one query.
+func (s *catalogService) GetVariantsByIDs(ctx context.Context, ids []string) (map[string]Variant, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byID := make(map[string]Variant, len(ids))
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	variants, err := s.repository.GetVariantsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		byID[v.ID] = v
+	}
+	return byID, nil
+}
+
+// GetVariantsBySKUs normalizes and de-duplicates skus and fetches them in one query.
+func (s *catalogService) GetVariantsBySKUs(ctx context.Context, skus []string) (map[string]Variant, error) {
+	normalized := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		normalized = append(normalized, NormalizeSKU(sku))
+	}
+	skus = uniqueIDs(normalized)
+	if len(skus) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	bySKU := make(map[string]Variant, len(skus))
+	if len(skus) == 0 {
+		return bySKU, nil
+	}
+
+	variants, err := s.repository.GetVariantsBySKUs(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		bySKU[v.SKU] = v
+	}
+	return bySKU, nil
+}
+
+// GetProductVariants de-duplicates productIDs and fetches their variants
+// in one query.
+func (s *catalogService) GetProductVariants(ctx context.Context, productIDs []string) (map[string][]Variant, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byProduct := make(map[string][]Variant, len(productIDs))
+	if len(productIDs) == 0 {
+		return byProduct, nil
+	}
+	for _, id := range productIDs {
+		byProduct[id] = []Variant{}
+	}
+
+	variants, err := s.repository.GetProductVariants(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		byProduct[v.ProductID] = append(byProduct[v.ProductID], v)
+	}
+	return byProduct, nil
+}
+
+// DeleteVariant removes a variant by ID.
+func (s *catalogService) DeleteVariant(ctx context.Context, id string) error {
+	return s.repository.DeleteVariant(ctx, id)
+}
+
+// SetStock validates the item and stores its on-hand quantity.
+func (s *catalogService) SetStock(ctx context.Context, productID, variantID string, onHand int64) (*StockLevel, error) {
+	if onHand < 0 || onHand > math.MaxInt32 {
+		return nil, ErrInvalidStockLevel
+	}
+	item := StockItem{ProductID: productID, VariantID: variantID}
+	if err := s.checkStockItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if err := s.repository.SetStock(ctx, item, onHand); err != nil {
+		return nil, err
+	}
+	return s.stockLevel(ctx, item)
+}
+
+// AdjustStock validates the item and changes its on-hand quantity by delta.
+func (s *catalogService) AdjustStock(ctx context.Context, productID, variantID string, delta int64) (*StockLevel, error) {
+	if delta < math.MinInt32 || delta > math.MaxInt32 {
+		return nil, ErrInvalidStockLevel
+	}
+	item := StockItem{ProductID: productID, VariantID: variantID}
+	if err := s.checkStockItem(ctx, item); err != nil {
+		return nil, err
+	}
+	if err := s.repository.AdjustStock(ctx, item, delta); err != nil {
+		return nil, err
+	}
+	return s.stockLevel(ctx, item)
+}
+
+// checkStockItem makes sure the variant of item belongs to its product, and
+// that a product with variants is not stocked as a whole.
+func (s *catalogService) checkStockItem(ctx context.Context, item StockItem) error {
+	if item.ProductID == "" {
+		return ErrInvalidStockItem
+	}
+
+	if item.VariantID != "" {
+		variants, err := s.repository.GetVariantsByIDs(ctx, []string{item.VariantID})
+		if err != nil {
+			return err
+		}
+		if len(variants) == 0 {
+			return ErrVariantNotFound
+		}
+		if variants[0].ProductID != item.ProductID {
+			return ErrVariantMismatch
+		}
+		return nil
+	}
+
+	variants, err := s.repository.GetProductVariants(ctx, []string{item.ProductID})
+	if err != nil {
+		return err
+	}
+	if len(variants) > 0 {
+		return ErrStockPerVariant
+	}
+	return nil
+}
+
+// stockLevel reads back the level of a single item.
+func (s *catalogService) stockLevel(ctx context.Context, item StockItem) (*StockLevel, error) {
+	levels, err := s.repository.GetStockLevels(ctx, []string{item.ProductID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		if levels[i].VariantID == item.VariantID {
+			return &levels[i], nil
+		}
+	}
+	// Deleted, with its product or variant, right after it was stored
+	return nil, ErrProductNotFound
+}
+
+// GetStockLevels de-duplicates productIDs and fetches their stock in one query.
+func (s *catalogService) GetStockLevels(ctx context.Context, productIDs []string) (map[string][]StockLevel, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byProduct := make(map[string][]StockLevel, len(productIDs))
+	if len(productIDs) == 0 {
+		return byProduct, nil
+	}
+	for _, id := range productIDs {
+		byProduct[id] = []StockLevel{}
+	}
+
+	levels, err := s.repository.GetStockLevels(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range levels {
+		byProduct[l.ProductID] = append(byProduct[l.ProductID], l)
+	}
+	return byProduct, nil
+}
+
+// ReserveStock validates the items, adds up the quantities of repeated
+// ones and holds them in one transaction.
+func (s *catalogService) ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error) {
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl < 0 || ttl > MaxReservationTTL {
+		return nil, ErrInvalidTTL
+	}
+	if len(items) == 0 {
+		return nil, ErrEmptyReservation
+	}
+
+	merged := []StockItem{}
+	index := map[string]int{}
+	for _, item := range items {
+		if item.ProductID == "" || item.Quantity == 0 || item.Quantity > math.MaxInt32 {
+			return nil, ErrInvalidStockItem
+		}
+		if i, ok := index[item.itemID()]; ok {
+			if merged[i].ProductID != item.ProductID {
+				return nil, ErrVariantMismatch
+			}
+			if uint64(merged[i].Quantity)+uint64(item.Quantity) > math.MaxInt32 {
+				return nil, ErrInvalidStockItem
+			}
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.itemID()] = len(merged)
+		merged = append(merged, item)
+	}
+	if len(merged) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	return s.repository.ReserveStock(ctx, ksuid.New().String(), merged, ttl)
+}
+
+// CommitReservation commits a pending reservation via the repository.
+func (s *catalogService) CommitReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repository.CommitReservation(ctx, id)
+}
+
+// ReleaseReservation releases a pending reservation via the repository.
+func (s *catalogService) ReleaseReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repository.ReleaseReservation(ctx, id)
+}
+
+// ReturnReservation returns a committed reservation via the repository.
+func (s *catalogService) ReturnReservation(ctx context.Context, id string) (*Reservation, error) {
+	return s.repository.ReturnReservation(ctx, id)
+}
+
+// SetListPrice validates the item and the price and stores it.
+func (s *catalogService) SetListPrice(ctx context.Context, productID, variantID string, price money.Money) (*ListPrice, error) {
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	l := ListPrice{ProductID: productID, VariantID: variantID, Price: price}
+	if err := s.checkPriceItem(ctx, l); err != nil {
+		return nil, err
+	}
+	if err := s.repository.PutListPrice(ctx, l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// checkPriceItem makes sure the variant of l belongs to its product and
+// that l is not in the currency of the product.
+func (s *catalogService) checkPriceItem(ctx context.Context, l ListPrice) error {
+	p, err := s.repository.GetProductByID(ctx, l.ProductID)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrProductNotFound
+	}
+	if p.Price.Currency == l.Price.Currency {
+		return ErrListPriceCurrency
+	}
+
+	if l.VariantID != "" {
+		variants, err := s.repository.GetVariantsByIDs(ctx, []string{l.VariantID})
+		if err != nil {
+			return err
+		}
+		if len(variants) == 0 {
+			return ErrVariantNotFound
+		}
+		if variants[0].ProductID != l.ProductID {
+			return ErrVariantMismatch
+		}
+	}
+	return nil
+}
+
+// DeleteListPrice removes the list price of an item in one currency.
+func (s *catalogService) DeleteListPrice(ctx context.Context, productID, variantID, currency string) error {
+	l := ListPrice{ProductID: productID, VariantID: variantID}
+	return s.repository.DeleteListPrice(ctx, l.itemID(), money.NormalizeCurrency(currency))
+}
+
+// GetListPrices de-duplicates productIDs and fetches their list prices in one query.
+func (s *catalogService) GetListPrices(ctx context.Context, productIDs []string) (map[string][]ListPrice, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byProduct := make(map[string][]ListPrice, len(productIDs))
+	if len(productIDs) == 0 {
+		return byProduct, nil
+	}
+	for _, id := range productIDs {
+		byProduct[id] = []ListPrice{}
+	}
+
+	prices, err := s.repository.GetListPrices(ctx, productIDs, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range prices {
+		byProduct[l.ProductID] = append(byProduct[l.ProductID], l)
+	}
+	return byProduct, nil
+}
+
+// BaseCurrency returns the currency exchange rates are quoted against.
+func (s *catalogService) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// SetExchangeRates normalizes and validates rates, stores them in one
+// statement and returns every rate.
+func (s *catalogService) SetExchangeRates(ctx context.Context, rates []ExchangeRate) ([]ExchangeRate, error) {
+	normalized := make([]ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		r.Currency = money.NormalizeCurrency(r.Currency)
+		if !money.ValidCurrency(r.Currency) {
+			return nil, money.ErrInvalidCurrency
+		}
+		if r.Currency == s.baseCurrency {
+			return nil, ErrBaseCurrencyRate
+		}
+		rate, err := money.ParseRate(r.Rate)
+		if err != nil {
+			return nil, err
+		}
+		r.Rate = money.FormatRate(rate)
+		if r.Rate == "0" {
+			return nil, money.ErrInvalidRate
+		}
+		normalized = append(normalized, r)
+	}
+
+	if len(normalized) > 0 {
+		if err := s.repository.PutExchangeRates(ctx, normalized); err != nil {
+			return nil, err
+		}
+	}
+	return s.repository.GetExchangeRates(ctx)
+}
+
+// ListExchangeRates fetches every rate via the repository.
+func (s *catalogService) ListExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	return s.repository.GetExchangeRates(ctx)
+}
+
+// GetPrices loads the products, variants and list prices of items in one
+// batch each, and the exchange rates only when something is converted.
+func (s *catalogService) GetPrices(ctx context.Context, items []PriceItem, currency string) ([]Price, error) {
+	currency = money.NormalizeCurrency(currency)
+	if currency != "" && !money.ValidCurrency(currency) {
+		return nil, money.ErrInvalidCurrency
+	}
+	if len(items) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+	prices := make([]Price, 0, len(items))
+	if len(items) == 0 {
+		return prices, nil
+	}
+
+	productIDs := make([]string, 0, len(items))
+	variantIDs := []string{}
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+		if item.VariantID != "" {
+			variantIDs = append(variantIDs, item.VariantID)
+		}
+	}
+	productIDs = uniqueIDs(productIDs)
+
+	products, err := s.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := s.GetVariantsByIDs(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := map[string]money.Money{}
+	if currency != "" {
+		found, err := s.repository.GetListPrices(ctx, productIDs, currency)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range found {
+			lists[l.itemID()] = l.Price
+		}
+	}
+
+	var rates map[string]string
+	for _, item := range items {
+		p, ok := products[item.ProductID]
+		if !ok {
+			return nil, ErrProductNotFound
+		}
+		price := Price{ProductID: item.ProductID, VariantID: item.VariantID, Base: p.Price}
+
+		// A variant without its own price inherits the list prices of its product
+		inherits := true
+		if item.VariantID != "" {
+			v, ok := variants[item.VariantID]
+			if !ok {
+				return nil, ErrVariantNotFound
+			}
+			if v.ProductID != item.ProductID {
+				return nil, ErrVariantMismatch
+			}
+			price.Base = v.Price
+			inherits = v.PriceOverride == nil
+		}
+		price.Amount = price.Base
+
+		if currency == "" || currency == price.Base.Currency {
+			prices = append(prices, price)
+			continue
+		}
+
+		if l, ok := lists[item.itemID()]; ok {
+			price.Amount = l
+		} else if l, ok := lists[item.ProductID]; ok && inherits {
+			price.Amount = l
+		} else {
+			if rates == nil {
+				if rates, err = s.rates(ctx); err != nil {
+					return nil, err
+				}
+			}
+			if price.Rate, err = crossRate(rates, price.Base.Currency, currency); err != nil {
+				return nil, err
+			}
+			if price.Amount, err = price.Base.Convert(currency, price.Rate); err != nil {
+				return nil, err
+			}
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// itemID is the key of the item: its variant, or its product.
+func (i PriceItem) itemID() string {
+	return StockItem{ProductID: i.ProductID, VariantID: i.VariantID}.itemID()
+}
+
+// rates returns the exchange rates keyed by currency, the base currency
+// included at 1.
+func (s *catalogService) rates(ctx context.Context) (map[string]string, error) {
+	found, err := s.repository.GetExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]string, len(found)+1)
+	for _, r := range found {
+		rates[r.Currency] = r.Rate
+	}
+	rates[s.baseCurrency] = "1"
+	return rates, nil
+}
+
+// crossRate is the rate converting from one currency to another through
+// the base currency.
+func crossRate(rates map[string]string, from, to string) (string, error) {
+	fromRate, ok := rates[from]
+	if !ok {
+		return "", ErrNoExchangeRate
+	}
+	toRate, ok := rates[to]
+	if !ok {
+		return "", ErrNoExchangeRate
+	}
+	return money.CrossRate(fromRate, toRate)
+}
+
+// uniqueIDs drops empty and repeated IDs, keeping the first occurrence.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// UploadProductImage checks that data is an image of an acceptable size,
+// stores it and its thumbnails in the blob store, then records it. The
+// files of an image that cannot be recorded are removed again.
+func (s *catalogService) UploadProductImage(ctx context.Context, productID, altText string, data []byte) (*ProductImage, error) {
+	if s.media == nil {
+		return nil, ErrMediaUnavailable
+	}
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > MaxAltTextLength {
+		return nil, ErrInvalidAltText
+	}
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+
+	p, err := s.repository.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProductNotFound
+	}
+
+	info, err := imaging.Inspect(data)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, ErrImageTooLarge
+	}
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	img := &ProductImage{
+		ID:          ksuid.New().String(),
+		ProductID:   productID,
+		AltText:     altText,
+		ContentType: info.ContentType,
+		Width:       int32(info.Width),
+		Height:      int32(info.Height),
+		Size:        int64(len(data)),
+		Thumbnails:  []Thumbnail{},
+		CreatedAt:   time.Now().UTC(),
+	}
+	prefix := "products/" + productID + "/" + img.ID
+	img.Key = prefix + info.Extension
+
+	// Remove whatever was stored should a later step fail
+	stored := []string{}
+	defer func() {
+		if err != nil {
+			s.deleteBlobs(context.WithoutCancel(ctx), stored)
+		}
+	}()
+
+	if err = s.media.Put(ctx, img.Key, bytes.NewReader(data), img.Size, img.ContentType); err != nil {
+		return nil, err
+	}
+	stored = append(stored, img.Key)
+
+	if err = s.storeThumbnails(ctx, img, data, prefix, &stored); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.PutProductImage(ctx, img); err != nil {
+		return nil, err
+	}
+	s.setURLs(img)
+	return img, nil
+}
+
+// storeThumbnails decodes data once and stores a thumbnail of every
+// ThumbnailSizes the image does not already fit, appending their keys to
+// stored.
+func (s *catalogService) storeThumbnails(ctx context.Context, img *ProductImage, data []byte, prefix string, stored *[]string) error {
+	var decoded image.Image
+	for _, size := range ThumbnailSizes {
+		if int(img.Width) <= size && int(img.Height) <= size {
+			continue
+		}
+		if decoded == nil {
+			var err error
+			if decoded, err = imaging.Decode(data); err != nil {
+				return ErrInvalidImage
+			}
+		}
+
+		thumb, info, err := imaging.Thumbnail(decoded, size)
+		if err != nil {
+			return err
+		}
+		t := Thumbnail{
+			Size:        int32(size),
+			ContentType: info.ContentType,
+			Width:       int32(info.Width),
+			Height:      int32(info.Height),
+			Key:         fmt.Sprintf("%s_%d%s", prefix, size, info.Extension),
+		}
+		if err := s.media.Put(ctx, t.Key, bytes.NewReader(thumb), int64(len(thumb)), t.ContentType); err != nil {
+			return err
+		}
+		*stored = append(*stored, t.Key)
+		img.Thumbnails = append(img.Thumbnails, t)
+	}
+	return nil
+}
+
+// UpdateProductImage validates the alt text and stores it.
+func (s *catalogService) UpdateProductImage(ctx context.Context, id, altText string) (*ProductImage, error) {
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > MaxAltTextLength {
+		return nil, ErrInvalidAltText
+	}
+	img, err := s.repository.UpdateProductImage(ctx, id, altText)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(img)
+	return img, nil
+}
+
+// ReorderProductImages checks that imageIDs are exactly the images of the
+// product and stores their positions.
+func (s *catalogService) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error) {
+	images, err := s.repository.GetProductImages(ctx, []string{productID})
+	if err != nil {
+		return nil, err
+	}
+	if len(images) != len(imageIDs) {
+		return nil, ErrImageOrder
+	}
+	known := make(map[string]bool, len(images))
+	for _, img := range images {
+		known[img.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !known[id] {
+			return nil, ErrImageOrder
+		}
+		delete(known, id)
+	}
+
+	if err := s.repository.ReorderProductImages(ctx, productID, imageIDs); err != nil {
+		return nil, err
+	}
+	reordered, err := s.GetProductImages(ctx, []string{productID})
+	if err != nil {
+		return nil, err
+	}
+	return reordered[productID], nil
+}
+
+// DeleteProductImage removes the record of an image, then its files.
+func (s *catalogService) DeleteProductImage(ctx context.Context, id string) error {
+	img, err := s.repository.DeleteProductImage(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.deleteBlobs(ctx, img.keys())
+	return nil
+}
+
+// GetProductImages de-duplicates productIDs, fetches their images in one
+// query and links their files.
+func (s *catalogService) GetProductImages(ctx context.Context, productIDs []string) (map[string][]ProductImage, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byProduct := make(map[string][]ProductImage, len(productIDs))
+	for _, id := range productIDs {
+		byProduct[id] = []ProductImage{}
+	}
+	if len(productIDs) == 0 {
+		return byProduct, nil
+	}
+
+	images, err := s.repository.GetProductImages(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		s.setURLs(&images[i])
+		byProduct[images[i].ProductID] = append(byProduct[images[i].ProductID], images[i])
+	}
+	return byProduct, nil
+}
+
+// setURLs fills in where clients download the files of img.
+func (s *catalogService) setURLs(img *ProductImage) {
+	if s.media == nil {
+		return
+	}
+	img.URL = s.media.URL(img.Key)
+	for i := range img.Thumbnails {
+		img.Thumbnails[i].URL = s.media.URL(img.Thumbnails[i].Key)
+	}
+}
+
+// deleteBlobs removes the files of deleted images. Failures only leave
+// unreferenced files behind, so they are logged rather than returned.
+func (s *catalogService) deleteBlobs(ctx context.Context, keys []string) {
+	if s.media == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := s.media.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// importRecordErrors fail a single record of an import, which is reported
+// and skipped; any other error aborts the import.
+var importRecordErrors = []error{
+	ErrInvalidName, ErrInvalidPrice, ErrPriceRequired, ErrPriceCurrency, ErrCurrencyChange,
+	money.ErrInvalidCurrency, money.ErrInvalidAmount,
+	ErrInvalidSKU, ErrInvalidBarcode, ErrInvalidOption, ErrDuplicateVariant, ErrSKUTaken, ErrBarcodeTaken,
+	ErrProductNotFound, ErrVariantNotFound,
+	ErrRecordProduct, ErrSKUProduct, ErrDuplicateSKU, ErrProductConflict,
+}
+
+// importOutcome is what importing a record did.
+type importOutcome int
+
+const (
+	importUnchanged importOutcome = iota
+	importCreated
+	importUpdated
+)
+
+// importer is the state of one ImportProducts call.
+type importer struct {
+	s      *catalogService
+	dryRun bool
+	report *ImportReport
+
+	// skus maps the SKUs imported so far to their line
+	skus map[string]int64
+
+	// byName maps product names to the product of the last record with
+	// that name, which new SKUs without product_id join
+	byName map[string]string
+
+	// products holds the products the import has seen, as it left them
+	products map[string]*importedProduct
+}
+
+// importedProduct is a product, with its variants, as the import has left
+// it so far. In a dry run nothing is written, so this is the only place
+// the changes of earlier records are visible.
+type importedProduct struct {
+	Product
+	variants []Variant
+
+	// isNew is set for a product the import creates
+	isNew bool
+
+	// line of the record that set the fields of the product, 0 before
+	line int64
+}
+
+// ImportProducts reads the records in batches of MaxBatchSize, loading
+// what a batch refers to in a few queries, and imports them one by one.
+func (s *catalogService) ImportProducts(ctx context.Context, next func() (*ImportRecord, error), dryRun bool) (*ImportReport, error) {
+	imp := &importer{
+		s:        s,
+		dryRun:   dryRun,
+		report:   &ImportReport{DryRun: dryRun, Issues: []ImportIssue{}},
+		skus:     map[string]int64{},
+		byName:   map[string]string{},
+		products: map[string]*importedProduct{},
+	}
+
+	batch := make([]ImportRecord, 0, MaxBatchSize)
+	for {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, *rec)
+		if len(batch) == MaxBatchSize {
+			if err := imp.importBatch(ctx, batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := imp.importBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	return imp.report, nil
+}
+
+// importBatch loads the variants of the SKUs of batch and the products it
+// refers to, then imports its records in order.
+func (imp *importer) importBatch(ctx context.Context, batch []ImportRecord) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	skus := make([]string, 0, len(batch))
+	for i := range batch {
+		batch[i].SKU = NormalizeSKU(batch[i].SKU)
+		if batch[i].SKU != "" {
+			skus = append(skus, batch[i].SKU)
+		}
+	}
+	existing := map[string]Variant{}
+	if len(skus) > 0 {
+		variants, err := imp.s.repository.GetVariantsBySKUs(ctx, uniqueIDs(skus))
+		if err != nil {
+			return err
+		}
+		for _, v := range variants {
+			existing[v.SKU] = v
+		}
+	}
+
+	ids := []string{}
+	for i := range batch {
+		if id := batch[i].ProductID; id != "" && imp.products[id] == nil {
+			ids = append(ids, id)
+		}
+	}
+	for _, v := range existing {
+		if imp.products[v.ProductID] == nil {
+			ids = append(ids, v.ProductID)
+		}
+	}
+	if err := imp.loadProducts(ctx, uniqueIDs(ids)); err != nil {
+		return err
+	}
+
+	for i := range batch {
+		rec := &batch[i]
+		imp.report.Received++
+
+		outcome, err := imp.importRecord(ctx, rec, existing)
+		if err != nil {
+			if !isImportRecordError(err) {
+				return err
+			}
+			imp.report.Failed++
+			if len(imp.report.Issues) < MaxImportIssues {
+				imp.report.Issues = append(imp.report.Issues, ImportIssue{Line: rec.Line, SKU: rec.SKU, Message: err.Error()})
+			}
+			continue
+		}
+
+		switch outcome {
+		case importCreated:
+			imp.report.Created++
+		case importUpdated:
+			imp.report.Updated++
+		default:
+			imp.report.Unchanged++
+		}
+	}
+	return nil
+}
+
+// loadProducts adds the products of ids that exist to imp.products, with
+// their variants.
+func (imp *importer) loadProducts(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	products, err := imp.s.repository.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	variants, err := imp.s.repository.GetProductVariants(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	// Records carry the regular price, which running sales do not change
+	for _, p := range products {
+		imp.products[p.ID] = &importedProduct{Product: p.regular()}
+	}
+	for _, v := range variants {
+		if p := imp.products[v.ProductID]; p != nil {
+			p.variants = append(p.variants, v)
+		}
+	}
+	return nil
+}
+
+// importRecord validates rec against the catalog as the import has left
+// it, then writes what changed unless this is a dry run. A record belongs
+// to the product of its SKU when the SKU exists, else to product_id, else
+// to the product of an earlier record with the same name, else to a new
+// product. The first record of a product in the import sets its fields;
+// later ones must agree with it.
+func (imp *importer) importRecord(ctx context.Context, rec *ImportRecord, existing map[string]Variant) (importOutcome, error) {
+	if err := validateProduct(rec.Name, rec.Price); err != nil {
+		return 0, err
+	}
+	var v Variant
+	if rec.SKU != "" {
+		if line, ok := imp.skus[rec.SKU]; ok {
+			return 0, fmt.Errorf("%w on line %d", ErrDuplicateSKU, line)
+		}
+		var err error
+		if v, err = importedVariant(rec); err != nil {
+			return 0, err
+		}
+	}
+
+	var p *importedProduct
+	old, exists := existing[rec.SKU]
+	switch {
+	case exists:
+		if rec.ProductID != "" && rec.ProductID != old.ProductID {
+			return 0, ErrSKUProduct
+		}
+		p = imp.products[old.ProductID]
+	case rec.ProductID != "":
+		p = imp.products[rec.ProductID]
+	case rec.SKU == "":
+		return 0, ErrRecordProduct
+	case imp.byName[rec.Name] != "":
+		p = imp.products[imp.byName[rec.Name]]
+	default:
+		p = &importedProduct{Product: Product{ID: ksuid.New().String()}, isNew: true}
+	}
+	if p == nil {
+		return 0, ErrProductNotFound
+	}
+
+	fields := Product{ID: p.ID, Name: rec.Name, Description: rec.Description, Price: rec.Price}
+	productChanged := false
+	if p.line != 0 {
+		if fields != p.Product {
+			return 0, fmt.Errorf("%w on line %d", ErrProductConflict, p.line)
+		}
+	} else {
+		productChanged = p.isNew || fields != p.Product
+		if !p.isNew && p.Price.Currency != rec.Price.Currency {
+			for _, other := range p.variants {
+				if other.PriceOverride != nil {
+					return 0, ErrCurrencyChange
+				}
+			}
+		}
+	}
+
+	variantChanged := false
+	if rec.SKU != "" {
+		v.ProductID = p.ID
+		if exists {
+			v.ID, v.Position = old.ID, old.Position
+			variantChanged = !sameVariant(old, v)
+		} else {
+			v.ID = ksuid.New().String()
+			for _, other := range p.variants {
+				v.Position = max(v.Position, other.Position+1)
+			}
+			variantChanged = true
+		}
+		if variantChanged {
+			for _, other := range p.variants {
+				if other.ID != v.ID && maps.Equal(other.Options, v.Options) {
+					return 0, ErrDuplicateVariant
+				}
+			}
+		}
+	}
+
+	// Valid as far as can be told without writing
+	if productChanged && !imp.dryRun {
+		if _, err := imp.s.PutProduct(ctx, p.ID, rec.Name, rec.Description, rec.Price); err != nil {
+			return 0, err
+		}
+	}
+	if p.line == 0 {
+		if p.isNew {
+			imp.products[p.ID] = p
+			imp.report.ProductsCreated++
+		}
+		p.Product, p.line = fields, rec.Line
+	}
+	imp.byName[rec.Name] = p.ID
+
+	if rec.SKU == "" {
+		if productChanged {
+			return importUpdated, nil
+		}
+		return importUnchanged, nil
+	}
+
+	imp.skus[rec.SKU] = rec.Line
+	if variantChanged && !imp.dryRun {
+		stored, err := imp.s.storeVariant(ctx, v)
+		if err != nil {
+			return 0, err
+		}
+		v = *stored
+	}
+	if exists {
+		for i := range p.variants {
+			if p.variants[i].ID == v.ID {
+				p.variants[i] = v
+			}
+		}
+	} else {
+		p.variants = append(p.variants, v)
+	}
+
+	switch {
+	case !exists:
+		return importCreated, nil
+	case productChanged || variantChanged:
+		return importUpdated, nil
+	}
+	return importUnchanged, nil
+}
+
+// importedVariant validates the variant fields of rec like storeVariant
+// does; rec.SKU is already normalized.
+func importedVariant(rec *ImportRecord) (Variant, error) {
+	if !validSKU(rec.SKU) {
+		return Variant{}, ErrInvalidSKU
+	}
+	if rec.Barcode != "" && !validGTIN(rec.Barcode) {
+		return Variant{}, ErrInvalidBarcode
+	}
+	if rec.PriceOverride != nil {
+		if err := validatePrice(*rec.PriceOverride); err != nil {
+			return Variant{}, err
+		}
+		if rec.PriceOverride.Currency != rec.Price.Currency {
+			return Variant{}, ErrPriceCurrency
+		}
+	}
+	options, err := normalizeOptions(rec.Options)
+	if err != nil {
+		return Variant{}, err
+	}
+	return Variant{SKU: rec.SKU, Options: options, PriceOverride: rec.PriceOverride, Barcode: rec.Barcode}, nil
+}
+
+// sameVariant reports whether importing v would leave the stored variant
+// old as it is.
+func sameVariant(old, v Variant) bool {
+	if !maps.Equal(old.Options, v.Options) || old.Barcode != v.Barcode {
+		return false
+	}
+	if old.PriceOverride == nil || v.PriceOverride == nil {
+		return old.PriceOverride == v.PriceOverride
+	}
+	return *old.PriceOverride == *v.PriceOverride
+}
+
+func isImportRecordError(err error) bool {
+	for _, target := range importRecordErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportProducts pages through the products by ID. Products created or
+// deleted while the export runs may be missed or exported twice. Products
+// on sale are exported with their regular price.
+func (s *catalogService) ExportProducts(ctx context.Context, emit func([]ProductRecord) error) error {
+	for skip := uint64(0); ; skip += MaxBatchSize {
+		products, err := s.repository.ListProducts(ctx, skip, MaxBatchSize)
+		if err != nil || len(products) == 0 {
+			return err
+		}
+
+		ids := make([]string, 0, len(products))
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+		variants, err := s.repository.GetProductVariants(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byProduct := make(map[string][]Variant, len(products))
+		for _, v := range variants {
+			byProduct[v.ProductID] = append(byProduct[v.ProductID], v)
+		}
+
+		records := make([]ProductRecord, 0, len(variants)+len(products))
+		for _, p := range products {
+			p = p.regular()
+			rec := ProductRecord{ProductID: p.ID, Name: p.Name, Description: p.Description, Price: p.Price}
+			if len(byProduct[p.ID]) == 0 {
+				records = append(records, rec)
+				continue
+			}
+			for _, v := range byProduct[p.ID] {
+				rec.SKU, rec.Options, rec.PriceOverride, rec.Barcode = v.SKU, v.Options, v.PriceOverride, v.Barcode
+				records = append(records, rec)
+			}
+		}
+		if err := emit(records); err != nil {
+			return err
+		}
+		if len(products) < MaxBatchSize {
+			return nil
+		}
+	}
+}
+
+// ScheduleProductPrice validates the schedule against the clock of the
+// service and records it. A price starting now takes effect right away and
+// watchers of the product are notified if it changed the price; later ones
+// are published by PublishPriceSchedules.
+func (s *catalogService) ScheduleProductPrice(ctx context.Context, productID string, price money.Money, from time.Time, until *time.Time, label string) (*PriceChange, error) {
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > MaxPriceLabelLength {
+		return nil, ErrInvalidPriceLabel
+	}
+	now := time.Now()
+	if !from.IsZero() && from.Before(now) {
+		return nil, ErrInvalidSchedule
+	}
+	if until != nil && (!until.After(now) || !until.After(from)) {
+		return nil, ErrInvalidSchedule
+	}
+
+	p, err := s.repository.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProductNotFound
+	}
+	if p.Price.Currency != price.Currency {
+		return nil, ErrPriceCurrency
+	}
+
+	c := &PriceChange{
+		ID:             ksuid.New().String(),
+		ProductID:      productID,
+		Price:          price,
+		EffectiveFrom:  from,
+		EffectiveUntil: until,
+		Scheduled:      true,
+		Label:          label,
+	}
+	if err := s.repository.PutPriceChange(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		updated, err := s.repository.GetProductByID(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+		if updated != nil && updated.Price != p.Price {
+			s.events.Publish(*updated)
+		}
+	}
+	return c, nil
+}
+
+// CancelScheduledPrice leaves the decision between deleting and ending to
+// the repository, which reads the clock of the database. Ending a running
+// price changes the price of the product, so its watchers are notified.
+func (s *catalogService) CancelScheduledPrice(ctx context.Context, id string) error {
+	productID, running, err := s.repository.CancelScheduledPrice(ctx, id)
+	if err != nil || !running {
+		return err
+	}
+	return s.publishPrices(ctx, []string{productID})
+}
+
+// PublishPriceSchedules asks the repository which scheduled prices started
+// or ended since the previous check, on the clock of the database, and
+// publishes their products. Failed checks are retried on the next tick.
+func (s *catalogService) PublishPriceSchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	since := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ids, until, err := s.repository.GetPriceTransitions(ctx, since)
+		if err == nil {
+			err = s.publishPrices(ctx, ids)
+		}
+		if err != nil {
+			log.Printf("failed to publish scheduled prices: %v", err)
+			continue
+		}
+		since = until
+	}
+}
+
+// publishPrices notifies the watchers of productIDs of their current price.
+func (s *catalogService) publishPrices(ctx context.Context, productIDs []string) error {
+	for start := 0; start < len(productIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(productIDs))
+		products, err := s.repository.GetProductsByIDs(ctx, productIDs[start:end])
+		if err != nil {
+			return err
+		}
+		for _, p := range products {
+			s.events.Publish(p)
+		}
+	}
+	return nil
+}
+
+// GetPriceHistory de-duplicates productIDs and fetches their history in one
+// query.
+func (s *catalogService) GetPriceHistory(ctx context.Context, productIDs []string) (map[string][]PriceChange, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	history := make(map[string][]PriceChange, len(productIDs))
+	for _, id := range productIDs {
+		history[id] = []PriceChange{}
+	}
+	if len(productIDs) == 0 {
+		return history, nil
+	}
+
+	changes, err := s.repository.GetPriceHistory(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		history[c.ProductID] = append(history[c.ProductID], c)
+	}
+	return history, nil
+}
+
+// CreateReview validates the review, and asks the order service whether
+// the account bought the product; when it cannot tell, the review is not
+// stored, so that a verified purchase is never missed.
+func (s *catalogService) CreateReview(ctx context.Context, r Review) (*Review, error) {
+	if r.Rating < 1 || r.Rating > 5 {
+		return nil, ErrInvalidRating
+	}
+	if r.AccountID == "" {
+		return nil, ErrReviewAccount
+	}
+	r.Title, r.Body = strings.TrimSpace(r.Title), strings.TrimSpace(r.Body)
+	if r.Title == "" || utf8.RuneCountInString(r.Title) > MaxReviewTitleLength || utf8.RuneCountInString(r.Body) > MaxReviewBodyLength {
+		return nil, ErrInvalidReview
+	}
+
+	p, err := s.repository.GetProductByID(ctx, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrProductNotFound
+	}
+
+	r.VerifiedPurchase = false
+	if s.purchases != nil {
+		if r.VerifiedPurchase, err = s.purchases.HasPurchased(ctx, r.AccountID, r.ProductID); err != nil {
+			return nil, fmt.Errorf("verify purchase: %w", err)
+		}
+	}
+
+	r.ID = ksuid.New().String()
+	r.Status = ReviewPending
+	r.CreatedAt = time.Now().UTC()
+	r.UpdatedAt = r.CreatedAt
+	if err := s.repository.PutReview(ctx, r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ModerateReview validates the status; the repository moves the rating of
+// the product along with it.
+func (s *catalogService) ModerateReview(ctx context.Context, id string, status ReviewStatus) (*Review, error) {
+	if !status.Valid() {
+		return nil, ErrInvalidModeration
+	}
+	return s.repository.ModerateReview(ctx, id, status)
+}
+
+// GetProductReviews de-duplicates productIDs and fetches a page of reviews
+// of each in one query. Page sizes are capped like those of ListProducts.
+func (s *catalogService) GetProductReviews(ctx context.Context, productIDs []string, skip uint64, take uint64) (map[string][]Review, error) {
+	productIDs = uniqueIDs(productIDs)
+	if len(productIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+	if take > 100 || take == 0 {
+		take = 100
+	}
+
+	reviews := make(map[string][]Review, len(productIDs))
+	for _, id := range productIDs {
+		reviews[id] = []Review{}
+	}
+	if len(productIDs) == 0 {
+		return reviews, nil
+	}
+
+	found, err := s.repository.GetProductReviews(ctx, productIDs, skip, take)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range found {
+		reviews[r.ProductID] = append(reviews[r.ProductID], r)
+	}
+	return reviews, nil
+}
+
+// ListReviews validates the status filter. Page sizes are capped like those
+// of ListProducts.
+func (s *catalogService) ListReviews(ctx context.Context, status ReviewStatus, skip uint64, take uint64) ([]Review, error) {
+	if status != "" && !status.Valid() {
+		return nil, ErrInvalidModeration
+	}
+	if take > 100 || take == 0 {
+		take = 100
+	}
+	return s.repository.ListReviews(ctx, status, skip, take)
+}
@@ -0,0 +1,226 @@
+CREATE TABLE IF NOT EXISTS products (
+  id CHAR(27) PRIMARY KEY,
+  name VARCHAR(240) NOT NULL,
+  description TEXT NOT NULL,
+  -- Minor units of currency, e.g. cents for USD
+  price BIGINT NOT NULL,
+  -- ISO 4217 code
+  currency CHAR(3) NOT NULL
+);
+
+-- Full-text search over name (weight A) and description (weight B)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') ||
+    setweight(to_tsvector('english', description), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS products_search_vector_idx ON products USING GIN (search_vector);
+
+-- Typo-tolerant fallback: trigram similarity of the name
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
+
+-- Product taxonomy. Siblings are ordered by position, then name; a category
+-- with subcategories cannot be deleted.
+CREATE TABLE IF NOT EXISTS categories (
+  id CHAR(27) PRIMARY KEY,
+  parent_id CHAR(27) REFERENCES categories (id) ON DELETE RESTRICT,
+  name VARCHAR(240) NOT NULL,
+  slug VARCHAR(240) NOT NULL UNIQUE,
+  position INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id, position);
+
+CREATE TABLE IF NOT EXISTS product_categories (
+  product_id CHAR(27) REFERENCES products (id) ON DELETE CASCADE,
+  category_id CHAR(27) REFERENCES categories (id) ON DELETE CASCADE,
+  PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS product_categories_category_id_idx ON product_categories (category_id);
+
+-- Purchasable variations of a product, e.g. size M in red. options maps
+-- option names to values ({"Color": "Red", "Size": "M"}) and is unique per
+-- product; a NULL price inherits the price of the product. price is in
+-- minor units of the currency of the product.
+CREATE TABLE IF NOT EXISTS product_variants (
+  id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  sku VARCHAR(64) NOT NULL,
+  options JSONB NOT NULL DEFAULT '{}',
+  price BIGINT,
+  barcode VARCHAR(14),
+  position INT NOT NULL DEFAULT 0,
+  CONSTRAINT product_variants_sku_key UNIQUE (sku),
+  CONSTRAINT product_variants_barcode_key UNIQUE (barcode),
+  CONSTRAINT product_variants_options_key UNIQUE (product_id, options)
+);
+
+-- Prices used to be NUMERIC(12, 2) dollars. Convert them to cents once,
+-- on databases created before.
+DO $$
+BEGIN
+  IF (SELECT data_type FROM information_schema.columns
+      WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'price') = 'numeric' THEN
+    ALTER TABLE products ALTER COLUMN price TYPE BIGINT USING round(price * 100);
+    ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+    ALTER TABLE products ALTER COLUMN currency DROP DEFAULT;
+  END IF;
+  IF (SELECT data_type FROM information_schema.columns
+      WHERE table_schema = current_schema() AND table_name = 'product_variants' AND column_name = 'price') = 'numeric' THEN
+    ALTER TABLE product_variants ALTER COLUMN price TYPE BIGINT USING round(price * 100);
+  END IF;
+END $$;
+
+-- On-hand quantity of the stocked items: a variant, or a product sold
+-- without variants. item_id is the ID of the variant or of the product.
+-- Items without a row are not tracked and never run out.
+CREATE TABLE IF NOT EXISTS stock_items (
+  item_id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  variant_id CHAR(27) REFERENCES product_variants (id) ON DELETE CASCADE,
+  on_hand INT NOT NULL CHECK (on_hand >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS stock_items_product_id_idx ON stock_items (product_id);
+
+-- Stock held for a checkout. A PENDING reservation counts against the
+-- available quantity until it expires; committing it takes its items out
+-- of on_hand, releasing it gives them back. Returning a COMMITTED one puts
+-- its items back into on_hand.
+CREATE TABLE IF NOT EXISTS stock_reservations (
+  id CHAR(27) PRIMARY KEY,
+  status TEXT NOT NULL DEFAULT 'PENDING',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS stock_reservation_items (
+  reservation_id CHAR(27) NOT NULL REFERENCES stock_reservations (id) ON DELETE CASCADE,
+  item_id CHAR(27) NOT NULL REFERENCES stock_items (item_id) ON DELETE CASCADE,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  PRIMARY KEY (reservation_id, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservation_items_item_id_idx ON stock_reservation_items (item_id);
+
+-- Explicit prices of a product, or of a variant, in currencies other than
+-- the product's own. item_id is the ID of the variant or of the product.
+-- Other currencies are converted with exchange_rates.
+CREATE TABLE IF NOT EXISTS list_prices (
+  item_id CHAR(27) NOT NULL,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  variant_id CHAR(27) REFERENCES product_variants (id) ON DELETE CASCADE,
+  currency CHAR(3) NOT NULL,
+  -- Minor units of currency
+  price BIGINT NOT NULL CHECK (price >= 0),
+  PRIMARY KEY (item_id, currency)
+);
+
+CREATE INDEX IF NOT EXISTS list_prices_product_id_idx ON list_prices (product_id, currency);
+
+-- Units of currency one unit of the base currency (BASE_CURRENCY of the
+-- catalog service) buys. The base currency itself has no row.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  currency CHAR(3) PRIMARY KEY,
+  rate NUMERIC NOT NULL CHECK (rate > 0),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Pictures of a product, shown in the order of position. blob_key names the
+-- original file in the blob store; thumbnails lists the scaled-down copies
+-- as [{"size", "contentType", "width", "height", "key"}].
+CREATE TABLE IF NOT EXISTS product_images (
+  id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  alt_text TEXT NOT NULL DEFAULT '',
+  content_type TEXT NOT NULL,
+  width INT NOT NULL CHECK (width > 0),
+  height INT NOT NULL CHECK (height > 0),
+  size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+  blob_key TEXT NOT NULL,
+  thumbnails JSONB NOT NULL DEFAULT '[]',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS product_images_product_id_idx ON product_images (product_id, position);
+
+-- Price history of the products. Every change of the regular price of a
+-- product is recorded when it is made, effective at once and until the
+-- next one. Scheduled prices are recorded ahead of time: without
+-- effective_until they become the regular price at effective_from, with it
+-- they are sales that win over the regular price while they run. Rows in a
+-- currency other than the product's are ignored.
+CREATE TABLE IF NOT EXISTS product_prices (
+  id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  -- Minor units of currency
+  price BIGINT NOT NULL CHECK (price >= 0),
+  currency CHAR(3) NOT NULL,
+  effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+  effective_until TIMESTAMP WITH TIME ZONE CHECK (effective_until > effective_from),
+  scheduled BOOLEAN NOT NULL,
+  -- E.g. the name of the promotion
+  label TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS product_prices_product_id_idx ON product_prices (product_id, effective_from DESC);
+
+-- Customer reviews, one per account and product. Reviews are PENDING until
+-- moderated; verified_purchase is set when the account had ordered the
+-- product when it wrote the review.
+CREATE TABLE IF NOT EXISTS product_reviews (
+  id CHAR(27) PRIMARY KEY,
+  product_id CHAR(27) NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  account_id CHAR(27) NOT NULL,
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  title TEXT NOT NULL,
+  body TEXT NOT NULL DEFAULT '',
+  verified_purchase BOOLEAN NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PENDING',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  CONSTRAINT product_reviews_account_key UNIQUE (product_id, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS product_reviews_product_id_idx ON product_reviews (product_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS product_reviews_status_idx ON product_reviews (status, created_at DESC);
+
+-- Number and sum of the ratings of the APPROVED reviews of a product,
+-- adjusted as reviews are moderated rather than aggregated on every read
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_sum BIGINT NOT NULL DEFAULT 0;
+
+-- Products with the price in effect when queried: the sale that started
+-- last among the running ones, else the regular price that took effect
+-- last, else products.price for products changed before the history was
+-- kept. regular_price is only set while a sale runs. Columns can only be
+-- added at the end, since the view is replaced in place.
+CREATE OR REPLACE VIEW priced_products AS
+SELECT p.id, p.name, p.description,
+  COALESCE(sale.price, regular.price, p.price) AS price,
+  p.currency,
+  CASE WHEN sale.price IS NOT NULL THEN COALESCE(regular.price, p.price) END AS regular_price,
+  p.search_vector,
+  p.rating_count,
+  p.rating_sum
+FROM products p
+LEFT JOIN LATERAL (
+  SELECT pp.price FROM product_prices pp
+  WHERE pp.product_id = p.id AND pp.currency = p.currency
+  AND pp.effective_until IS NULL AND pp.effective_from <= now()
+  ORDER BY pp.effective_from DESC, pp.created_at DESC
+  LIMIT 1
+) regular ON true
+LEFT JOIN LATERAL (
+  SELECT pp.price FROM product_prices pp
+  WHERE pp.product_id = p.id AND pp.currency = p.currency
+  AND pp.effective_from <= now() AND pp.effective_until > now()
+  ORDER BY pp.effective_from DESC, pp.created_at DESC
+  LIMIT 1
+) sale ON true;
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable pointing at a config file when
+// --config is not given.
+const FileEnv = "CONFIG_FILE"
+
+// ErrPrintConfig is returned by Load after --print-config has written the
+// effective configuration; the caller should exit without starting.
+var ErrPrintConfig = errors.New("configuration printed")
+
+// Validator is implemented by config structs that need checks spanning
+// several fields. Load calls it on the root struct and every nested struct.
+type Validator interface {
+	Validate() error
+}
+
+// Load fills cfg, a pointer to a struct described with envconfig tags,
+// from these layers, each overriding the previous one:
+//
+//  1. `default:"..."` struct tags
+//  2. a YAML (.yaml, .yml) or TOML (.toml) file given by --config or CONFIG_FILE
+//  3. environment variables
+//  4. command line flags, one per variable: DATABASE_URL becomes --database-url
+//
+// The result is validated with the `validate` tags (see validate.go) and
+// every Validator in the struct. All problems are reported at once.
+//
+// With --print-config the effective configuration is written to out as
+// YAML, values tagged `secret:"true"` redacted, and ErrPrintConfig is returned.
+func Load(name string, cfg interface{}, args []string, out io.Writer) error {
+	fields, err := collectFields(cfg)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", "", "path to a YAML or TOML config file (or set "+FileEnv+")")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+
+	flagValues := map[string]*string{}
+	for _, f := range fields {
+		flagValues[f.key] = fs.String(f.flagName(), "", "overrides "+f.key)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Layer 2: config file
+	values := map[string]string{}
+	if *file == "" {
+		*file = os.Getenv(FileEnv)
+	}
+	if *file != "" {
+		if values, err = readFile(*file, fields); err != nil {
+			return err
+		}
+	}
+
+	// Layer 3: environment variables
+	for _, f := range fields {
+		if v, ok := os.LookupEnv(f.key); ok {
+			values[f.key] = v
+		}
+	}
+
+	// Layer 4: flags that were actually passed
+	fs.Visit(func(fl *flag.Flag) {
+		for key, v := range flagValues {
+			if fieldFlagName(key) == fl.Name {
+				values[key] = *v
+			}
+		}
+	})
+
+	// envconfig applies the defaults and does the type conversions;
+	// it only reads the environment, so hand it the merged layers there.
+	for key, v := range values {
+		if err := os.Setenv(key, v); err != nil {
+			return err
+		}
+	}
+	if err := envconfig.Process("", cfg); err != nil {
+		return err
+	}
+
+	if *printConfig {
+		if err := Print(out, cfg); err != nil {
+			return err
+		}
+		return ErrPrintConfig
+	}
+
+	return Validate(cfg)
+}
+
+// MustLoad is Load for main packages: it reads os.Args, exits after
+// --print-config or --help, and exits with every validation error listed
+// when the configuration is invalid.
+func MustLoad(name string, cfg interface{}) {
+	err := Load(name, cfg, os.Args[1:], os.Stdout)
+	if errors.Is(err, ErrPrintConfig) || errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("invalid %s configuration:\n%v", name, err)
+	}
+}
+
+// readFile loads a YAML or TOML file and flattens it into variable names:
+//
+//	tracing:
+//	  exporter: otlp
+//
+// becomes TRACING_EXPORTER=otlp. Unknown keys are rejected so typos do not
+// silently fall back to defaults.
+func readFile(path string, fields []field) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byKey := map[string]field{}
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+
+	values := map[string]string{}
+	if err := flatten("", doc, byKey, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(prefix string, doc map[string]interface{}, fields map[string]field, values map[string]string) error {
+	for k, v := range doc {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		if _, ok := fields[key]; ok {
+			values[key] = toEnvString(v)
+			continue
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			if err := flatten(key, nested, fields, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return fmt.Errorf("unknown key %q", strings.ToLower(key))
+	}
+	return nil
+}
+
+// toEnvString renders a file value the way envconfig expects it in the
+// environment: maps as "k:v,k:v", lists as "a,b".
+func toEnvString(v interface{}) string {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k+":"+fmt.Sprint(val[k]))
+		}
+		return strings.Join(pairs, ",")
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// field is a leaf of the config struct with its environment variable name.
+type field struct {
+	key   string   // TRACING_EXPORTER
+	path  []string // ["tracing", "exporter"], used by --print-config
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+func (f field) flagName() string {
+	return fieldFlagName(f.key)
+}
+
+func fieldFlagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// collectFields walks cfg the same way envconfig does: the key of a field is
+// its envconfig tag (or name), prefixed by the key of its parent struct
+// unless the parent is embedded.
+func collectFields(cfg interface{}) ([]field, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config: cfg must be a pointer to a struct")
+	}
+
+	var fields []field
+	walk(v.Elem(), "", nil, &fields)
+	return fields, nil
+}
+
+func walk(v reflect.Value, prefix string, path []string, fields *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if !ft.IsExported() || ft.Tag.Get("ignored") == "true" {
+			continue
+		}
+
+		key := ft.Tag.Get("envconfig")
+		if key == "" {
+			key = ft.Name
+		}
+		key = strings.ToUpper(key)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			innerPrefix, innerPath := key, append(append([]string{}, path...), strings.ToLower(lastSegment(key, prefix)))
+			if ft.Anonymous {
+				innerPrefix, innerPath = prefix, path
+			}
+			walk(fv, innerPrefix, innerPath, fields)
+			continue
+		}
+
+		*fields = append(*fields, field{
+			key:   key,
+			path:  append(append([]string{}, path...), strings.ToLower(lastSegment(key, prefix))),
+			value: fv,
+			tag:   ft.Tag,
+		})
+	}
+}
+
+// lastSegment strips the parent prefix from key.
+func lastSegment(key, prefix string) string {
+	if prefix == "" {
+		return key
+	}
+	return strings.TrimPrefix(key, prefix+"_")
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secret values in --print-config output.
+const redacted = "REDACTED"
+
+// Print writes cfg as YAML in the same shape Load accepts as a config file.
+// Fields tagged `secret:"true"` are redacted; for URLs only the password
+// is hidden so the host and database stay visible for debugging.
+func Print(out io.Writer, cfg interface{}) error {
+	fields, err := collectFields(cfg)
+	if err != nil {
+		return err
+	}
+
+	doc := map[string]interface{}{}
+	for _, f := range fields {
+		node := doc
+		for _, segment := range f.path[:len(f.path)-1] {
+			child, ok := node[segment].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[segment] = child
+			}
+			node = child
+		}
+
+		var value interface{} = f.value.Interface()
+		if d, ok := value.(time.Duration); ok {
+			// "5s" rather than nanoseconds, so the output can be read back
+			value = d.String()
+		}
+		if f.tag.Get("secret") == "true" {
+			value = redact(fmt.Sprint(value))
+		}
+		node[f.path[len(f.path)-1]] = value
+	}
+
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return redacted
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	// Credentials can also hide in the query, e.g. ?password=...
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", redacted)
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
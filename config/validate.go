@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks the `validate` tags of every field of cfg and then runs
+// every Validator found in it. Rules are comma separated:
+//
+//	required      value must not be empty
+//	port          integer between 1 and 65535
+//	url           absolute URL with a scheme and a host, e.g. postgres://db/accounts
+//	hostport      gRPC target written as host:port, not as a URL
+//	oneof=a|b|c   one of the listed values
+//	min=N, max=N  numeric bounds, or duration bounds like min=0s for time.Duration
+//
+// Rules other than required are skipped for empty values.
+func Validate(cfg interface{}) error {
+	fields, err := collectFields(cfg)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, f := range fields {
+		rules := f.tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+
+		value := fmt.Sprint(f.value.Interface())
+		for _, rule := range strings.Split(rules, ",") {
+			if err := checkRule(strings.TrimSpace(rule), value, f.value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+				break
+			}
+		}
+	}
+
+	errs = append(errs, runValidators(reflect.ValueOf(cfg))...)
+
+	return errors.Join(errs...)
+}
+
+func checkRule(rule, value string, v reflect.Value) error {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	if name == "required" {
+		if v.IsZero() {
+			return errors.New("is required")
+		}
+		return nil
+	}
+	if v.IsZero() && v.Kind() == reflect.String {
+		return nil
+	}
+
+	switch name {
+	case "port":
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("%q is not a valid port (1-65535)", value)
+		}
+
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be an absolute URL like scheme://host/path")
+		}
+
+	case "hostport":
+		if strings.Contains(value, "://") {
+			return fmt.Errorf("%q must be host:port, not a URL", value)
+		}
+		_, port, err := net.SplitHostPort(value)
+		if err != nil {
+			return fmt.Errorf("%q must be host:port", value)
+		}
+		if err := checkRule("port", port, reflect.ValueOf(port)); err != nil {
+			return err
+		}
+
+	case "oneof":
+		for _, allowed := range strings.Split(arg, "|") {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q must be one of %s", value, strings.ReplaceAll(arg, "|", ", "))
+
+	case "min", "max":
+		bound, err := strconv.ParseFloat(arg, 64)
+		n, nerr := strconv.ParseFloat(value, 64)
+		// Durations are compared as durations: min=0s, max=1m
+		if d, ok := v.Interface().(time.Duration); ok {
+			var b time.Duration
+			b, err = time.ParseDuration(arg)
+			bound, n, nerr = float64(b), float64(d), nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s rule %q", name, arg)
+		}
+		if nerr != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		if name == "min" && n < bound {
+			return fmt.Errorf("%s must be at least %s", value, arg)
+		}
+		if name == "max" && n > bound {
+			return fmt.Errorf("%s must be at most %s", value, arg)
+		}
+
+	default:
+		return fmt.Errorf("unknown validate rule %q", rule)
+	}
+
+	return nil
+}
+
+// runValidators calls Validate on v and on every nested struct that
+// implements Validator, depth first.
+func runValidators(v reflect.Value) []error {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).IsExported() && v.Field(i).Kind() == reflect.Struct {
+			errs = append(errs, runValidators(v.Field(i))...)
+		}
+	}
+
+	if v.CanAddr() {
+		if val, ok := v.Addr().Interface().(Validator); ok {
+			if err := val.Validate(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errs
+}
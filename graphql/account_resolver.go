@@ -15,8 +15,17 @@ func (a *accountResolver) CreatedAt(ctx context.Context, obj *Account) (*time.Ti
 }
 
 // Orders implements AccountResolver.
+// The orders of every account in the operation are fetched in one batch,
+// so `listAccounts { orders }` costs one order-service round-trip, not one per account.
 func (a *accountResolver) Orders(ctx context.Context, obj *Account) ([]*Order, error) {
-	panic("unimplemented")
+	orders, err := a.server.loaders(ctx).ordersByAccount.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []*Order{}
+	}
+	return orders, nil
 }
 
 // UpdatedAt implements AccountResolver.
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+)
+
+// directives returns the handlers of the access directives of the schema.
+// The checks themselves live in the auth package, shared with the subgraphs.
+func (s *Server) directives() DirectiveRoot {
+	return DirectiveRoot{
+		Authenticated: auth.Authenticated,
+		HasRole:       auth.HasRole,
+		Owner:         s.ownerDirective,
+	}
+}
+
+// ownerDirective implements @owner(field:, of:). With of: ORDER the ID is
+// an order's, and the order service tells whose it is.
+func (s *Server) ownerDirective(ctx context.Context, obj any, next graphql.Resolver, field string, of *OwnedType) (any, error) {
+	owner := ownedAccountID
+	if of != nil && *of == OwnedTypeOrder {
+		owner = s.orderOwner
+	}
+	return auth.Owner(ctx, obj, next, field, owner)
+}
+
+// ownedAccountID is the owner of an account: the account itself.
+func ownedAccountID(ctx context.Context, id string) (string, error) {
+	local, err := localID(nodeAccount, id)
+	if err != nil {
+		return "", fmt.Errorf("%s is not an account ID", id)
+	}
+	return local, nil
+}
+
+// orderOwner returns the account an order belongs to.
+func (s *Server) orderOwner(ctx context.Context, id string) (string, error) {
+	orderID, err := localID(nodeOrder, id)
+	if err != nil {
+		return "", err
+	}
+	o, err := s.orderClient.GetOrder(ctx, orderID)
+	if status.Code(err) == codes.NotFound {
+		return "", auth.ErrNoOwner
+	}
+	if err != nil {
+		return "", err
+	}
+	return o.AccountID, nil
+}
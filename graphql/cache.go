@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+)
+
+// ResponseCacheConfig controls the in-process response cache. The gateway
+// embeds it as `ResponseCache ResponseCacheConfig envconfig:"RESPONSE_CACHE"`.
+type ResponseCacheConfig struct {
+	// Off by default: Cache-Control headers alone let CDNs and browsers cache
+	Enabled bool `envconfig:"ENABLED" default:"false"`
+
+	// Number of responses kept, least recently used first out
+	Size int `envconfig:"SIZE" default:"1000" validate:"min=1"`
+}
+
+// responseCacheLookups counts lookups in the response cache by result.
+var responseCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Subsystem: "graphql",
+	Name:      "response_cache_lookups_total",
+	Help:      "Lookups in the gateway response cache, by result (hit, miss).",
+}, []string{"result"})
+
+func init() {
+	prometheus.MustRegister(responseCacheLookups)
+}
+
+// cachePolicy is how long, and by whom, a response may be cached.
+// A zero maxAge means not at all.
+type cachePolicy struct {
+	maxAge int
+	scope  CacheControlScope
+}
+
+// header renders the policy as a Cache-Control header value.
+func (p cachePolicy) header() string {
+	if p.maxAge <= 0 {
+		return "no-store"
+	}
+	return strings.ToLower(string(p.scope)) + ", max-age=" + strconv.Itoa(p.maxAge)
+}
+
+// cachedResponse is a response stored by the response cache.
+type cachedResponse struct {
+	response  graphql.Response
+	expiresAt time.Time
+}
+
+type responseHeaderCtxKey struct{}
+
+// withResponseHeader makes the response headers reachable from gqlgen
+// extensions, which only see the context. gqlgen writes the response after
+// the operation has run, so headers set by the extensions are sent.
+func withResponseHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), responseHeaderCtxKey{}, w.Header())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// cacheControl is a gqlgen handler extension that derives the cache policy
+// of every query from the @cacheControl hints of the schema, sends it as a
+// Cache-Control header, and optionally serves repeated queries from an
+// in-process cache without calling the services.
+//
+// Cached responses are keyed by query, operation name, variables and the
+// X-Currency header, and for PRIVATE policies by the caller's account too. Only error-free
+// responses are stored; mutations and subscriptions are never cached.
+type cacheControl struct {
+	schema *ast.Schema
+	cache  *lru.Cache[string, cachedResponse]
+	now    func() time.Time
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &cacheControl{}
+
+// newCacheControl creates the extension, with a response cache when cfg enables it.
+func newCacheControl(cfg ResponseCacheConfig) (*cacheControl, error) {
+	c := &cacheControl{now: time.Now}
+	if cfg.Enabled {
+		cache, err := lru.New[string, cachedResponse](cfg.Size)
+		if err != nil {
+			return nil, err
+		}
+		c.cache = cache
+	}
+	return c, nil
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (*cacheControl) ExtensionName() string {
+	return "CacheControl"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (c *cacheControl) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema.Schema()
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor.
+func (c *cacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return next(ctx)
+	}
+
+	header, _ := ctx.Value(responseHeaderCtxKey{}).(http.Header)
+	policy := c.policy(oc.Operation)
+
+	// Private responses are only shared with the same caller, so those of
+	// anonymous callers are not stored at all
+	var key string
+	claims := auth.FromContext(ctx)
+	if c.cache != nil && policy.maxAge > 0 && (policy.scope == CacheControlScopePublic || claims != nil) {
+		key = c.key(oc, policy, claims, currencyFromContext(ctx))
+	}
+
+	if key != "" {
+		if entry, ok := c.cache.Get(key); ok && c.now().Before(entry.expiresAt) {
+			responseCacheLookups.WithLabelValues("hit").Inc()
+			// Tell downstream caches how much of the max age is left
+			policy.maxAge = int(entry.expiresAt.Sub(c.now()).Seconds())
+			setCacheControl(header, policy)
+			resp := entry.response
+			return &resp
+		}
+		responseCacheLookups.WithLabelValues("miss").Inc()
+	}
+
+	resp := next(ctx)
+	if resp == nil || len(resp.Errors) > 0 {
+		setCacheControl(header, cachePolicy{})
+		return resp
+	}
+
+	setCacheControl(header, policy)
+	if key != "" {
+		c.cache.Add(key, cachedResponse{
+			response:  *resp,
+			expiresAt: c.now().Add(time.Duration(policy.maxAge) * time.Second),
+		})
+	}
+	return resp
+}
+
+func setCacheControl(header http.Header, policy cachePolicy) {
+	if header != nil {
+		header.Set("Cache-Control", policy.header())
+	}
+}
+
+// key identifies a response in the cache.
+func (c *cacheControl) key(oc *graphql.OperationContext, policy cachePolicy, claims *auth.Claims, currency string) string {
+	var caller string
+	if policy.scope == CacheControlScopePrivate {
+		caller = claims.Subject
+	}
+
+	// json.Marshal sorts map keys, so equal variables give equal keys
+	b, err := json.Marshal(struct {
+		Query     string         `json:"q"`
+		Operation string         `json:"o"`
+		Variables map[string]any `json:"v"`
+		Caller    string         `json:"c"`
+		Currency  string         `json:"cur"`
+	}{oc.RawQuery, oc.OperationName, oc.Variables, caller, currency})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// policy computes the cache policy of a validated query: the lowest maxAge
+// of its fields, PRIVATE when any field is.
+func (c *cacheControl) policy(op *ast.OperationDefinition) cachePolicy {
+	p := cachePolicy{maxAge: -1, scope: CacheControlScopePublic}
+	c.walk(op.SelectionSet, 0, true, &p)
+	if p.maxAge < 0 {
+		p.maxAge = 0
+	}
+	return p
+}
+
+// walk restricts p by the fields of set. parentMaxAge is the maxAge of the
+// enclosing field, which scalar fields and inheritMaxAge hints take on.
+func (c *cacheControl) walk(set ast.SelectionSet, parentMaxAge int, root bool, p *cachePolicy) {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			c.walkField(sel, parentMaxAge, root, p)
+		case *ast.InlineFragment:
+			c.walk(sel.SelectionSet, parentMaxAge, root, p)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				c.walk(sel.Definition.SelectionSet, parentMaxAge, root, p)
+			}
+		}
+	}
+}
+
+func (c *cacheControl) walkField(f *ast.Field, parentMaxAge int, root bool, p *cachePolicy) {
+	// __typename and introspection do not depend on the data
+	if f.Definition == nil || strings.HasPrefix(f.Name, "__") {
+		return
+	}
+
+	fieldHint := parseCacheHint(f.Definition.Directives)
+	var typeHint cacheHint
+	var composite bool
+	if def := c.schema.Types[f.Definition.Type.Name()]; def != nil {
+		composite = def.IsCompositeType()
+		if composite {
+			typeHint = parseCacheHint(def.Directives)
+		}
+	}
+
+	var maxAge int
+	switch {
+	case fieldHint.maxAge != nil:
+		maxAge = *fieldHint.maxAge
+	case fieldHint.inheritMaxAge:
+		maxAge = parentMaxAge
+	case typeHint.maxAge != nil:
+		maxAge = *typeHint.maxAge
+	case typeHint.inheritMaxAge:
+		maxAge = parentMaxAge
+	case composite || root:
+		maxAge = 0
+	default:
+		// Scalars of a cached object are as fresh as the object
+		maxAge = parentMaxAge
+	}
+
+	if maxAge < p.maxAge || p.maxAge < 0 {
+		p.maxAge = maxAge
+	}
+	if fieldHint.scope == CacheControlScopePrivate || typeHint.scope == CacheControlScopePrivate {
+		p.scope = CacheControlScopePrivate
+	}
+
+	c.walk(f.SelectionSet, maxAge, false, p)
+}
+
+// cacheHint is a parsed @cacheControl directive.
+type cacheHint struct {
+	maxAge        *int
+	scope         CacheControlScope
+	inheritMaxAge bool
+}
+
+func parseCacheHint(directives ast.DirectiveList) cacheHint {
+	var h cacheHint
+	d := directives.ForName("cacheControl")
+	if d == nil {
+		return h
+	}
+
+	if arg := d.Arguments.ForName("maxAge"); arg != nil && arg.Value != nil {
+		if n, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			h.maxAge = &n
+		}
+	}
+	if arg := d.Arguments.ForName("scope"); arg != nil && arg.Value != nil {
+		h.scope = CacheControlScope(arg.Value.Raw)
+	}
+	if arg := d.Arguments.ForName("inheritMaxAge"); arg != nil && arg.Value != nil {
+		h.inheritMaxAge = arg.Value.Raw == "true"
+	}
+	return h
+}
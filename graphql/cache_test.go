@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+)
+
+const cacheTestSchema = `
+directive @cacheControl(maxAge: Int, scope: CacheControlScope, inheritMaxAge: Boolean) on FIELD_DEFINITION | OBJECT
+enum CacheControlScope { PUBLIC PRIVATE }
+
+type Product @cacheControl(maxAge: 60) {
+  id: String!
+  name: String!
+  stock: Int @cacheControl(maxAge: 10)
+}
+
+type Account @cacheControl(maxAge: 30, scope: PRIVATE) {
+  id: String!
+  name: String!
+}
+
+type Query {
+  product(id: String!): Product
+  me: Account
+  uncached: String
+}
+
+type Mutation {
+  rename(name: String!): Product
+}
+`
+
+func newTestCacheControl(t *testing.T) (*cacheControl, *ast.Schema, *time.Time) {
+	t.Helper()
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: cacheTestSchema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newCacheControl(ResponseCacheConfig{Enabled: true, Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.schema = schema
+	now := time.Unix(1700000000, 0)
+	c.now = func() time.Time { return now }
+	return c, schema, &now
+}
+
+// request runs query through c for the caller with the given subject
+// ("" for anonymous) and currency. It returns the response, its
+// Cache-Control header and whether the resolvers ran.
+func request(t *testing.T, c *cacheControl, schema *ast.Schema, query, subject, currency string) (*graphql.Response, string, bool) {
+	t.Helper()
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	if errs != nil {
+		t.Fatalf("LoadQuery(%q): %v", query, errs)
+	}
+
+	header := http.Header{}
+	ctx := context.WithValue(context.Background(), responseHeaderCtxKey{}, header)
+	if subject != "" {
+		ctx = auth.WithClaims(ctx, &auth.Claims{Subject: subject})
+	}
+	if currency != "" {
+		ctx = context.WithValue(ctx, currencyCtxKey{}, currency)
+	}
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		RawQuery:  query,
+		Operation: doc.Operations[0],
+		Variables: map[string]any{},
+	})
+
+	var ran bool
+	resp := c.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		ran = true
+		data, _ := json.Marshal(map[string]string{"caller": subject, "currency": currency})
+		return &graphql.Response{Data: data}
+	})
+	return resp, header.Get("Cache-Control"), ran
+}
+
+func TestCachePolicy(t *testing.T) {
+	c, schema, _ := newTestCacheControl(t)
+
+	tests := []struct {
+		name, query, want string
+	}{
+		{"public", `{ product(id: "1") { id name } }`, "public, max-age=60"},
+		{"lowest max age", `{ product(id: "1") { id stock } }`, "public, max-age=10"},
+		{"private", `{ me { id } product(id: "1") { id } }`, "private, max-age=30"},
+		{"uncached root", `{ uncached product(id: "1") { id } }`, "no-store"},
+		{"typename only", `{ __typename }`, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, _ := request(t, c, schema, tt.query, "account-1", "")
+			if got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCacheScope(t *testing.T) {
+	const (
+		public  = `{ product(id: "1") { id name } }`
+		private = `{ me { id name } }`
+	)
+
+	// Each step runs after the previous ones against the same cache
+	steps := []struct {
+		name     string
+		query    string
+		subject  string
+		currency string
+		wantRun  bool
+		wantFrom string // subject the response was computed for
+	}{
+		{"public miss", public, "", "", true, ""},
+		{"public hit for anyone", public, "account-2", "", false, ""},
+		{"public per currency", public, "", "EUR", true, ""},
+		{"private miss", private, "account-1", "", true, "account-1"},
+		{"private hit for its caller", private, "account-1", "", false, "account-1"},
+		{"private never served to another caller", private, "account-2", "", true, "account-2"},
+		{"other caller gets its own entry", private, "account-2", "", false, "account-2"},
+		{"first caller still gets its own", private, "account-1", "", false, "account-1"},
+		{"private never served to anonymous callers", private, "", "", true, ""},
+		{"private of anonymous callers not stored", private, "", "", true, ""},
+	}
+
+	c, schema, _ := newTestCacheControl(t)
+	for _, s := range steps {
+		resp, _, ran := request(t, c, schema, s.query, s.subject, s.currency)
+		if ran != s.wantRun {
+			t.Errorf("%s: resolvers ran = %v, want %v", s.name, ran, s.wantRun)
+		}
+		var data map[string]string
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if data["caller"] != s.wantFrom {
+			t.Errorf("%s: served the response of %q, want %q", s.name, data["caller"], s.wantFrom)
+		}
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	c, schema, now := newTestCacheControl(t)
+	const query = `{ product(id: "1") { id stock } }`
+
+	request(t, c, schema, query, "", "")
+
+	*now = now.Add(4 * time.Second)
+	if _, header, ran := request(t, c, schema, query, "", ""); ran || header != "public, max-age=6" {
+		t.Errorf("after 4s: ran = %v, Cache-Control = %q, want a hit with max-age=6", ran, header)
+	}
+
+	*now = now.Add(6 * time.Second)
+	if _, _, ran := request(t, c, schema, query, "", ""); !ran {
+		t.Error("after 10s: served an expired response")
+	}
+}
+
+func TestResponseCacheSkipsMutationsAndErrors(t *testing.T) {
+	c, schema, _ := newTestCacheControl(t)
+
+	for i := 0; i < 2; i++ {
+		if _, header, ran := request(t, c, schema, `mutation { rename(name: "x") { id } }`, "", ""); !ran || header != "" {
+			t.Errorf("mutation %d: ran = %v, Cache-Control = %q, want a run without header", i, ran, header)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errCategoryLookup is returned by getCategory without exactly one of id and slug.
+var errCategoryLookup = errors.New("exactly one of id and slug is required")
+
+type categoryResolver struct {
+	server *Server
+}
+
+// Breadcrumbs implements CategoryResolver.
+// The paths of every category in the operation are fetched in one batch.
+func (c *categoryResolver) Breadcrumbs(ctx context.Context, obj *Category) ([]*Category, error) {
+	path, err := c.server.loaders(ctx).categoryPaths.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		path = []*Category{}
+	}
+	return path, nil
+}
+
+// Children implements CategoryResolver.
+func (c *categoryResolver) Children(ctx context.Context, obj *Category) ([]*Category, error) {
+	children, err := c.server.catalogClient.ListCategories(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toCategories(children), nil
+}
+
+// Categories implements ProductResolver.
+// The categories of every product in the operation are fetched in one
+// batch, so `listProducts { categories { name } }` costs one catalog call.
+func (p *productResolver) Categories(ctx context.Context, obj *Product) ([]*Category, error) {
+	categories, err := p.server.loaders(ctx).productCategories.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if categories == nil {
+		categories = []*Category{}
+	}
+	return categories, nil
+}
+
+// GetCategory implements QueryResolver.
+func (q *queryResolver) GetCategory(ctx context.Context, id *string, slug *string) (*Category, error) {
+	if (id == nil) == (slug == nil) {
+		return nil, errCategoryLookup
+	}
+
+	var err error
+	var c *Category
+	if id != nil {
+		cat, e := q.server.catalogClient.GetCategory(ctx, *id)
+		c, err = toCategory(cat), e
+	} else {
+		cat, e := q.server.catalogClient.GetCategoryBySlug(ctx, *slug)
+		c, err = toCategory(cat), e
+	}
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListCategories implements QueryResolver.
+func (q *queryResolver) ListCategories(ctx context.Context, parentID *string) ([]*Category, error) {
+	var parent string
+	if parentID != nil {
+		parent = *parentID
+	}
+	categories, err := q.server.catalogClient.ListCategories(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	return toCategories(categories), nil
+}
+
+// ListProductsByCategory implements QueryResolver.
+// Found products are primed in the loader like those of ListProducts.
+func (q *queryResolver) ListProductsByCategory(ctx context.Context, categoryID string, pagination *PaginationInput) ([]*Product, error) {
+	skip, take := paginate(pagination)
+	products, err := q.server.catalogClient.ListProductsByCategory(ctx, categoryID, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := q.server.loaders(ctx).products
+	out := make([]*Product, 0, len(products))
+	for i := range products {
+		p := toProduct(&products[i])
+		loader.Prime(p.ID, p)
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// CreateCategory implements MutationResolver.
+func (m *mutationResolver) CreateCategory(ctx context.Context, input CategoryInput) (*Category, error) {
+	parentID, slug, position := categoryInput(input)
+	c, err := m.server.catalogClient.CreateCategory(ctx, parentID, input.Name, slug, position)
+	if err != nil {
+		return nil, err
+	}
+	return toCategory(c), nil
+}
+
+// UpdateCategory implements MutationResolver.
+func (m *mutationResolver) UpdateCategory(ctx context.Context, id string, input CategoryInput) (*Category, error) {
+	parentID, slug, position := categoryInput(input)
+	c, err := m.server.catalogClient.UpdateCategory(ctx, id, parentID, input.Name, slug, position)
+	if err != nil {
+		return nil, err
+	}
+	return toCategory(c), nil
+}
+
+// DeleteCategory implements MutationResolver.
+func (m *mutationResolver) DeleteCategory(ctx context.Context, id string) (bool, error) {
+	if err := m.server.catalogClient.DeleteCategory(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetProductCategories implements MutationResolver.
+// The new categories are primed in the loader, so selecting
+// Product.categories on the result costs no second call.
+func (m *mutationResolver) SetProductCategories(ctx context.Context, productID string, categoryIds []string) (*Product, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := m.server.catalogClient.SetProductCategories(ctx, productID, categoryIds)
+	if err != nil {
+		return nil, err
+	}
+	m.server.loaders(ctx).productCategories.Prime(productID, toCategories(categories))
+
+	return m.server.loaders(ctx).products.Load(ctx, productID)
+}
+
+// categoryInput unpacks the optional fields of a CategoryInput.
+func categoryInput(input CategoryInput) (parentID, slug string, position int32) {
+	if input.ParentID != nil {
+		parentID = *input.ParentID
+	}
+	if input.Slug != nil {
+		slug = *input.Slug
+	}
+	if input.Position != nil {
+		position = int32(*input.Position)
+	}
+	return parentID, slug, position
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/olujimiAdebakin/ProtoGraph/account"
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+)
+
+// defaultPageSize is used when a list query does not ask for a limit.
+const defaultPageSize = 100
+
+// paginate converts the optional GraphQL pagination input to the
+// skip/take pair of the services.
+func paginate(p *PaginationInput) (skip uint64, take uint64) {
+	take = defaultPageSize
+	if p == nil {
+		return 0, take
+	}
+	if p.Offset != nil && *p.Offset > 0 {
+		skip = uint64(*p.Offset)
+	}
+	if p.Limit != nil && *p.Limit > 0 {
+		take = uint64(*p.Limit)
+	}
+	return skip, take
+}
+
+// toAccount maps an account of the account service to the GraphQL model.
+func toAccount(a *account.Account) *Account {
+	if a == nil {
+		return nil
+	}
+	return &Account{
+		ID:   a.ID,
+		Name: a.Name,
+	}
+}
+
+// toProduct maps a product of the catalog service to the GraphQL model.
+func toProduct(p *catalog.Product) *Product {
+	if p == nil {
+		return nil
+	}
+	out := &Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       toMoney(p.Price),
+		ReviewCount: int(p.RatingCount),
+	}
+	if p.RatingCount > 0 {
+		out.AverageRating = &p.AverageRating
+	}
+	return out
+}
+
+// toMoney maps an amount of the services to the GraphQL model.
+func toMoney(m money.Money) *Money {
+	return &Money{Amount: m.Decimal(), Currency: m.Currency}
+}
+
+func toOptionalMoney(m *money.Money) *Money {
+	if m == nil {
+		return nil
+	}
+	return toMoney(*m)
+}
+
+// moneyInput parses an optional GraphQL amount; nil stays nil.
+func moneyInput(in *MoneyInput) (*money.Money, error) {
+	if in == nil {
+		return nil, nil
+	}
+	m, err := money.Parse(in.Amount, in.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// toCategory maps a category of the catalog service to the GraphQL model.
+func toCategory(c *catalog.Category) *Category {
+	if c == nil {
+		return nil
+	}
+	out := &Category{
+		ID:       c.ID,
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Position: int(c.Position),
+	}
+	if c.ParentID != "" {
+		out.ParentID = &c.ParentID
+	}
+	return out
+}
+
+func toCategories(categories []catalog.Category) []*Category {
+	out := make([]*Category, 0, len(categories))
+	for i := range categories {
+		out = append(out, toCategory(&categories[i]))
+	}
+	return out
+}
+
+// toSearchResult maps a page of catalog search hits to the GraphQL model.
+func toSearchResult(res *catalog.SearchResult) *ProductSearchResult {
+	out := &ProductSearchResult{
+		Hits:       make([]*ProductSearchHit, 0, len(res.Hits)),
+		TotalCount: int(res.Total),
+		Fuzzy:      res.Fuzzy,
+	}
+	for i := range res.Hits {
+		h := &res.Hits[i]
+		out.Hits = append(out.Hits, &ProductSearchHit{
+			Product:              toProduct(&h.Product),
+			Rank:                 h.Rank,
+			NameHighlight:        h.NameHighlight,
+			DescriptionHighlight: h.DescriptionHighlight,
+		})
+	}
+	return out
+}
+
+// toVariant maps a variant of the catalog service to the GraphQL model.
+func toVariant(v *catalog.Variant) *ProductVariant {
+	if v == nil {
+		return nil
+	}
+
+	return &ProductVariant{
+		ID:            v.ID,
+		ProductID:     v.ProductID,
+		Sku:           v.SKU,
+		Options:       toVariantOptions(v.Options),
+		Price:         toMoney(v.Price),
+		PriceOverride: toOptionalMoney(v.PriceOverride),
+		Barcode:       optionalString(v.Barcode),
+		Position:      int(v.Position),
+	}
+}
+
+func toVariants(variants []catalog.Variant) []*ProductVariant {
+	out := make([]*ProductVariant, 0, len(variants))
+	for i := range variants {
+		out = append(out, toVariant(&variants[i]))
+	}
+	return out
+}
+
+// optionalString maps the empty string of a service to null.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// toVariantOptions lists options ordered by name, so they render the same
+// way for every variant of a product.
+func toVariantOptions(options map[string]string) []*VariantOption {
+	out := make([]*VariantOption, 0, len(options))
+	for name, value := range options {
+		out = append(out, &VariantOption{Name: name, Value: value})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// toOrder maps an order of the order service to the GraphQL model.
+// Quantity is the number of items over all product lines.
+func toOrder(o *order.Order) *Order {
+	if o == nil {
+		return nil
+	}
+
+	out := &Order{
+		ID:         o.ID,
+		AccountID:  o.AccountID,
+		Status:     OrderStatus(o.Status),
+		TotalPrice: toMoney(o.TotalPrice),
+		CreatedAt:  o.CreatedAt,
+		UpdatedaAt: o.CreatedAt,
+		Products:   []*OrderedProduct{},
+	}
+	for _, p := range o.Products {
+		out.Quantity += int(p.Quantity)
+		out.Products = append(out.Products, &OrderedProduct{
+			ID:            p.ID,
+			ProductID:     p.ID,
+			Name:          p.Name,
+			Description:   p.Description,
+			Price:         toMoney(p.Price),
+			ConvertedFrom: toOptionalMoney(p.ConvertedFrom),
+			ExchangeRate:  optionalString(p.ExchangeRate),
+			Quantity:      int(p.Quantity),
+			VariantID:     optionalString(p.VariantID),
+			Sku:           optionalString(p.SKU),
+			Options:       toVariantOptions(p.Options),
+			CreatedAt:     o.CreatedAt,
+			UpdatedAt:     o.CreatedAt,
+		})
+	}
+	return out
+}
+
+func toOrders(orders []order.Order) []*Order {
+	out := make([]*Order, 0, len(orders))
+	for i := range orders {
+		out = append(out, toOrder(&orders[i]))
+	}
+	return out
+}
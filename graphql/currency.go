@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+)
+
+// currencyHeader names the currency prices are wanted in when a price
+// field is queried without a currency argument.
+const currencyHeader = "X-Currency"
+
+type currencyCtxKey struct{}
+
+// withCurrency puts the currency of the X-Currency request header in the
+// context of the request. Responses vary with the header, which downstream
+// caches are told with Vary.
+func withCurrency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", currencyHeader)
+		ctx := r.Context()
+		if c := money.NormalizeCurrency(r.Header.Get(currencyHeader)); c != "" {
+			ctx = context.WithValue(ctx, currencyCtxKey{}, c)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// currencyFromContext returns the currency of the X-Currency header, or ""
+// when the request did not send one.
+func currencyFromContext(ctx context.Context) string {
+	c, _ := ctx.Value(currencyCtxKey{}).(string)
+	return c
+}
+
+// requestedCurrency is the currency a price field is resolved in: its
+// argument, else the X-Currency header, else "" for the currency of the
+// product.
+func requestedCurrency(ctx context.Context, arg *string) string {
+	if arg != nil {
+		return money.NormalizeCurrency(*arg)
+	}
+	return currencyFromContext(ctx)
+}
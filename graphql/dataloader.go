@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Defaults for every loader: how long the first Load waits for more keys
+// before the batch is fetched, and how many keys a single batch may hold
+// (the MaxBatchSize of the batch RPCs).
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+// fetchFunc resolves a batch of keys in one go. Keys missing from the
+// returned map resolve to the zero value (nil for pointers): not found.
+type fetchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// loader batches and de-duplicates lookups made while a single GraphQL
+// operation executes. gqlgen resolves sibling fields concurrently, so the
+// Loads of `listAccounts { orders }` arrive within microseconds of each
+// other; the first one opens a batch, the ones arriving within loaderWait
+// join it, and the batch is fetched with a single call.
+//
+// Results are cached for the lifetime of the loader, so a key is fetched
+// at most once per operation. Loaders are not shared between operations.
+type loader[K comparable, V any] struct {
+	fetch    fetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+// loaderResult is the eventual value of one key.
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// loaderBatch collects the keys of the batch currently being filled.
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loaderResult[V]
+}
+
+func newLoader[K comparable, V any](fetch fetchFunc[K, V]) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:    fetch,
+		wait:     loaderWait,
+		maxBatch: loaderMaxBatch,
+		cache:    map[K]*loaderResult[V]{},
+	}
+}
+
+// Load returns the value of key, fetching it together with the other keys
+// requested around the same time.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+
+	res, ok := l.cache[key]
+	if !ok {
+		res = &loaderResult[V]{done: make(chan struct{})}
+		l.cache[key] = res
+		l.enqueue(ctx, key, res)
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll loads every key and returns the values in the order of keys.
+func (l *loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key K) {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Prime stores a value already at hand, e.g. an entity returned by a list
+// query, so later Loads of its key are answered without a fetch.
+func (l *loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; ok {
+		return
+	}
+	res := &loaderResult[V]{done: make(chan struct{}), value: value}
+	close(res.done)
+	l.cache[key] = res
+}
+
+// enqueue adds key to the open batch, opening one if needed. It must be
+// called with l.mu held.
+func (l *loader[K, V]) enqueue(ctx context.Context, key K, res *loaderResult[V]) {
+	if l.batch == nil {
+		b := &loaderBatch[K, V]{}
+		l.batch = b
+		// The batch runs on the context of the Load that opened it, so it
+		// stays inside the operation's trace and carries the caller identity
+		time.AfterFunc(l.wait, func() { l.dispatch(ctx, b) })
+	}
+
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results = append(l.batch.results, res)
+
+	// Full batch: send it now rather than waiting for the timer
+	if len(l.batch.keys) >= l.maxBatch {
+		b := l.batch
+		l.batch = nil
+		go l.run(ctx, b)
+	}
+}
+
+// dispatch is called by the timer of b; b may already have been sent
+// because it filled up.
+func (l *loader[K, V]) dispatch(ctx context.Context, b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	l.run(ctx, b)
+}
+
+// run fetches the keys of b and hands every waiting Load its result.
+func (l *loader[K, V]) run(ctx context.Context, b *loaderBatch[K, V]) {
+	values, err := l.fetch(ctx, b.keys)
+
+	for i, key := range b.keys {
+		res := b.results[i]
+		if err != nil {
+			res.err = err
+		} else {
+			res.value = values[key]
+		}
+		close(res.done)
+	}
+
+	// Failed lookups are not cached, a later Load tries again
+	if err != nil {
+		l.mu.Lock()
+		for i, key := range b.keys {
+			if l.cache[key] == b.results[i] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
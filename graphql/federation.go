@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/olujimiAdebakin/ProtoGraph/subgraph/accountgraph"
+	"github.com/olujimiAdebakin/ProtoGraph/subgraph/cataloggraph"
+	"github.com/olujimiAdebakin/ProtoGraph/subgraph/ordergraph"
+)
+
+// executableSchema returns the schema the gateway serves. Without a
+// subgraph it is the whole graph of schema.graphql. With one it is that
+// domain's Apollo Federation v2 subgraph, and a federation router composes
+// the three subgraphs into one graph in front of them.
+func (s *Server) executableSchema(subgraph string) graphql.ExecutableSchema {
+	switch subgraph {
+	case "account":
+		return accountgraph.NewSchema(s.accountClient)
+	case "catalog":
+		return cataloggraph.NewSchema(s.catalogClient)
+	case "order":
+		return ordergraph.NewSchema(s.orderClient)
+	default:
+		return s.ToExecutableSchema()
+	}
+}
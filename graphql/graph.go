@@ -73,6 +73,17 @@ func (s *Server) Query() QueryResolver {
 	}
 }
 
+// Subscription returns the SubscriptionResolver implementation.
+// gqlgen calls this when a client starts a subscription over WebSocket.
+//
+// → This wires GraphQL "Subscription { ... }"
+//   to your subscriptionResolver struct.
+func (s *Server) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{
+		server: s,
+	}
+}
+
 // Account returns the resolver for nested Account fields.
 // Example: Account → orders
 //
@@ -83,6 +94,38 @@ func (s *Server) Account() AccountResolver {
 	}
 }
 
+// Product returns the resolver for nested Product fields.
+// Example: Product → id (global ID)
+func (s *Server) Product() ProductResolver {
+	return &productResolver{
+		server: s,
+	}
+}
+
+// Category returns the resolver for nested Category fields.
+// Example: Category → breadcrumbs
+func (s *Server) Category() CategoryResolver {
+	return &categoryResolver{
+		server: s,
+	}
+}
+
+// ProductVariant returns the resolver for nested ProductVariant fields.
+// Example: ProductVariant → availableQuantity
+func (s *Server) ProductVariant() ProductVariantResolver {
+	return &productVariantResolver{
+		server: s,
+	}
+}
+
+// Order returns the resolver for nested Order fields.
+// Example: Order → id (global ID)
+func (s *Server) Order() OrderResolver {
+	return &orderResolver{
+		server: s,
+	}
+}
+
 // ToExecutableSchema compiles your resolvers + schema into
 // an executable GraphQL schema engine.
 //
@@ -94,5 +137,11 @@ func (s *Server) ToExecutableSchema() graphql.ExecutableSchema {
 		Resolvers: s, // tell gqlgen to use this Server as the resolver root
 	}
 
+	// weigh list fields by their expected length for the complexity limit
+	queryComplexity(&cfg.Complexity)
+
+	// access rules declared in the schema (@authenticated, @hasRole, @owner)
+	cfg.Directives = s.directives()
+
 	return NewExecutableSchema(cfg)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Values of the status fields of /healthz and /readyz.
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// dependencyStatus is the result of probing one downstream service.
+type dependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// readiness is the body of /readyz.
+type readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies"`
+}
+
+// healthz reports that the process is up and serving HTTP. It checks
+// nothing else, so a restart is only triggered when the gateway itself hangs.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// healthChecks returns the health checks of the services the gateway
+// depends on: all of them, or only its own domain's when serving a subgraph.
+func (s *Server) healthChecks(subgraph string) map[string]func(context.Context) error {
+	checks := map[string]func(context.Context) error{
+		"account": s.accountClient.Health,
+		"catalog": s.catalogClient.Health,
+		"order":   s.orderClient.Health,
+	}
+	if subgraph != "" {
+		return map[string]func(context.Context) error{subgraph: checks[subgraph]}
+	}
+	return checks
+}
+
+// readyz runs checks in parallel and answers 503 when any of the services
+// is unavailable, so load balancers stop routing to a gateway that cannot
+// serve queries.
+func readyz(timeout time.Duration, checks map[string]func(context.Context) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		res := readiness{
+			Status:       statusOK,
+			Dependencies: make(map[string]dependencyStatus, len(checks)),
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, check := range checks {
+			wg.Add(1)
+			go func(name string, check func(context.Context) error) {
+				defer wg.Done()
+
+				start := time.Now()
+				err := check(ctx)
+				dep := dependencyStatus{
+					Status:    statusOK,
+					LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+				}
+				if err != nil {
+					dep.Status = statusUnavailable
+					dep.Error = err.Error()
+				}
+
+				mu.Lock()
+				res.Dependencies[name] = dep
+				if err != nil {
+					res.Status = statusUnavailable
+				}
+				mu.Unlock()
+			}(name, check)
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		if res.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, res)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
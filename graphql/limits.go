@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// depthLimitCode is the extensions.code of operations rejected for nesting too deep.
+const depthLimitCode = "DEPTH_LIMIT_EXCEEDED"
+
+// Expected length of the list fields that take no pagination argument.
+// They only weigh the complexity score, nothing is truncated.
+const (
+	ordersPerAccount = 10
+	productsPerOrder = 5
+
+	categoriesPerProduct = 3
+	categoriesPerParent  = 20
+	categoryDepth        = 5
+	variantsPerProduct   = 10
+	imagesPerProduct     = 8
+	pricesPerProduct     = 10
+)
+
+// QueryLimits bounds the cost of a single operation. Both limits are
+// checked after validation and before any resolver runs, so a rejected
+// operation never reaches the services.
+type QueryLimits struct {
+	// Deepest allowed selection; `listAccounts { orders { products { name } } }`
+	// has a depth of 4. Introspection fields are not counted.
+	MaxDepth int `envconfig:"MAX_DEPTH" default:"7" validate:"min=1"`
+
+	// Highest allowed complexity score. Every field costs 1, and the
+	// selection below a list field is multiplied by the length of the list:
+	// the page size for listAccounts, listProducts, searchProducts and
+	// listProductsByCategory, ordersPerAccount for Account.orders,
+	// productsPerOrder for Order.products, categoriesPerProduct for
+	// Product.categories, variantsPerProduct for Product.variants,
+	// imagesPerProduct for Product.images and reorderProductImages,
+	// pricesPerProduct for Product.priceHistory, the page size for
+	// Product.reviews and listReviews,
+	// categoriesPerParent for listCategories and Category.children, and
+	// categoryDepth for Category.breadcrumbs.
+	MaxComplexity int `envconfig:"MAX_COMPLEXITY" default:"5000" validate:"min=1"`
+}
+
+// queryComplexity installs the list multipliers in the complexity functions
+// of the schema; fields without a function keep gqlgen's default of
+// 1 + the complexity of their selection.
+func queryComplexity(c *ComplexityRoot) {
+	c.Query.ListAccounts = func(childComplexity int, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Query.ListProducts = func(childComplexity int, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Query.SearchProducts = func(childComplexity int, query string, filters *ProductFilters, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Query.ListProductsByCategory = func(childComplexity int, categoryID string, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Query.ListCategories = func(childComplexity int, parentID *string) int {
+		return listComplexity(childComplexity, categoriesPerParent)
+	}
+	c.Query.Nodes = func(childComplexity int, ids []string) int {
+		return listComplexity(childComplexity, uint64(len(ids)))
+	}
+	c.Account.Orders = func(childComplexity int) int {
+		return listComplexity(childComplexity, ordersPerAccount)
+	}
+	c.Order.Products = func(childComplexity int) int {
+		return listComplexity(childComplexity, productsPerOrder)
+	}
+	c.Product.Categories = func(childComplexity int) int {
+		return listComplexity(childComplexity, categoriesPerProduct)
+	}
+	c.Product.Variants = func(childComplexity int) int {
+		return listComplexity(childComplexity, variantsPerProduct)
+	}
+	c.Product.Images = func(childComplexity int) int {
+		return listComplexity(childComplexity, imagesPerProduct)
+	}
+	c.Product.PriceHistory = func(childComplexity int) int {
+		return listComplexity(childComplexity, pricesPerProduct)
+	}
+	c.Product.Reviews = func(childComplexity int, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Query.ListReviews = func(childComplexity int, status *ReviewStatus, pagination *PaginationInput) int {
+		_, take := paginate(pagination)
+		return listComplexity(childComplexity, take)
+	}
+	c.Mutation.ReorderProductImages = func(childComplexity int, productID string, imageIds []string) int {
+		return listComplexity(childComplexity, imagesPerProduct)
+	}
+	c.Category.Children = func(childComplexity int) int {
+		return listComplexity(childComplexity, categoriesPerParent)
+	}
+	c.Category.Breadcrumbs = func(childComplexity int) int {
+		return listComplexity(childComplexity, categoryDepth)
+	}
+}
+
+// listComplexity is the cost of a list of n elements whose selection costs
+// childComplexity each. It saturates instead of overflowing, so a huge
+// limit argument cannot wrap the score around to a small number.
+func listComplexity(childComplexity int, n uint64) int {
+	if childComplexity <= 0 || n == 0 {
+		return 1
+	}
+	if n > uint64(math.MaxInt32/childComplexity) {
+		return math.MaxInt32
+	}
+	return 1 + childComplexity*int(n)
+}
+
+// depthLimit is a gqlgen handler extension that rejects operations whose
+// selections nest deeper than max, before they are executed.
+type depthLimit struct {
+	max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (depthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext implements graphql.OperationContextMutator.
+func (d depthLimit) MutateOperationContext(ctx context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	if d.max <= 0 || oc.Operation == nil {
+		return nil
+	}
+
+	depth := selectionDepth(oc.Operation.SelectionSet)
+	if depth <= d.max {
+		return nil
+	}
+
+	err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.max)
+	errcode.Set(err, depthLimitCode)
+	return err
+}
+
+// selectionDepth returns how many fields deep set nests, looking through
+// inline fragments and fragment spreads. The document has been validated,
+// so fragments cannot form cycles.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, sel := range set {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			// __schema and __type are as deep as the schema, not the data
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(sel.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				d = selectionDepth(sel.Definition.SelectionSet)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
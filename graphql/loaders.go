@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
+
+// loaders holds the DataLoaders of one GraphQL operation.
+type loaders struct {
+	accounts        *loader[string, *Account]
+	ordersByAccount *loader[string, []*Order]
+	products        *loader[string, *Product]
+
+	productCategories *loader[string, []*Category]
+	categoryPaths     *loader[string, []*Category]
+	productVariants   *loader[string, []*ProductVariant]
+	stockLevels       *loader[string, []catalog.StockLevel]
+	prices            *loader[priceKey, *Money]
+	productImages     *loader[string, []*ProductImage]
+	priceHistory      *loader[string, []*PriceChange]
+	reviews           *loader[reviewKey, []*Review]
+}
+
+type loadersCtxKey struct{}
+
+func newLoaders(s *Server) *loaders {
+	return &loaders{
+		accounts:        newLoader(s.fetchAccounts),
+		ordersByAccount: newLoader(s.fetchOrdersByAccount),
+		products:        newLoader(s.fetchProducts),
+
+		productCategories: newLoader(s.fetchProductCategories),
+		categoryPaths:     newLoader(s.fetchCategoryPaths),
+		productVariants:   newLoader(s.fetchProductVariants),
+		stockLevels:       newLoader(s.fetchStockLevels),
+		prices:            newLoader(s.fetchPrices),
+		productImages:     newLoader(s.fetchProductImages),
+		priceHistory:      newLoader(s.fetchPriceHistory),
+		reviews:           newLoader(s.fetchReviews),
+	}
+}
+
+// loaders returns the DataLoaders of the operation running in ctx. Outside
+// of an operation (no dataLoaders extension) every call gets fresh loaders,
+// which still works but batches nothing.
+func (s *Server) loaders(ctx context.Context) *loaders {
+	if l, ok := ctx.Value(loadersCtxKey{}).(*loaders); ok {
+		return l
+	}
+	return newLoaders(s)
+}
+
+// dataLoaders is a gqlgen handler extension that gives every operation its
+// own set of loaders, so lookups are batched and cached within an operation
+// but never leak between callers.
+type dataLoaders struct {
+	server *Server
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = dataLoaders{}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (dataLoaders) ExtensionName() string {
+	return "DataLoaders"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (dataLoaders) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation implements graphql.OperationInterceptor.
+func (d dataLoaders) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	// A subscription lives as long as the client stays connected; caching
+	// its lookups would serve stale entities for every later event
+	if oc := graphql.GetOperationContext(ctx); oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+	return next(context.WithValue(ctx, loadersCtxKey{}, newLoaders(d.server)))
+}
+
+// fetchAccounts resolves a batch of account IDs with one GetAccountsByIDs call.
+func (s *Server) fetchAccounts(ctx context.Context, ids []string) (map[string]*Account, error) {
+	accounts, _, err := s.accountClient.GetAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*Account, len(accounts))
+	for id, a := range accounts {
+		out[id] = toAccount(&a)
+	}
+	return out, nil
+}
+
+// fetchOrdersByAccount resolves the orders of a batch of account IDs with
+// one ListOrdersByAccountIDs call.
+func (s *Server) fetchOrdersByAccount(ctx context.Context, accountIDs []string) (map[string][]*Order, error) {
+	byAccount, err := s.orderClient.ListOrdersByAccountIDs(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*Order, len(byAccount))
+	for accountID, orders := range byAccount {
+		out[accountID] = toOrders(orders)
+	}
+	return out, nil
+}
+
+// fetchProducts resolves a batch of product IDs with one GetProductsByIDs call.
+func (s *Server) fetchProducts(ctx context.Context, ids []string) (map[string]*Product, error) {
+	products, _, err := s.catalogClient.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*Product, len(products))
+	for id, p := range products {
+		out[id] = toProduct(&p)
+	}
+	return out, nil
+}
+
+// fetchProductCategories resolves the categories of a batch of product IDs
+// with one GetProductCategories call.
+func (s *Server) fetchProductCategories(ctx context.Context, productIDs []string) (map[string][]*Category, error) {
+	byProduct, err := s.catalogClient.GetProductCategories(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*Category, len(byProduct))
+	for id, categories := range byProduct {
+		out[id] = toCategories(categories)
+	}
+	return out, nil
+}
+
+// fetchCategoryPaths resolves the breadcrumbs of a batch of category IDs
+// with one GetCategoryPaths call.
+func (s *Server) fetchCategoryPaths(ctx context.Context, ids []string) (map[string][]*Category, error) {
+	paths, err := s.catalogClient.GetCategoryPaths(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*Category, len(paths))
+	for id, path := range paths {
+		out[id] = toCategories(path)
+	}
+	return out, nil
+}
+
+// fetchProductVariants resolves the variants of a batch of product IDs
+// with one GetProductVariants call.
+func (s *Server) fetchProductVariants(ctx context.Context, productIDs []string) (map[string][]*ProductVariant, error) {
+	byProduct, err := s.catalogClient.GetProductVariants(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*ProductVariant, len(byProduct))
+	for id, variants := range byProduct {
+		out[id] = toVariants(variants)
+	}
+	return out, nil
+}
+
+// fetchProductImages resolves the images of a batch of product IDs with
+// one GetProductImages call.
+func (s *Server) fetchProductImages(ctx context.Context, productIDs []string) (map[string][]*ProductImage, error) {
+	byProduct, err := s.catalogClient.GetProductImages(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*ProductImage, len(byProduct))
+	for id, images := range byProduct {
+		out[id] = toProductImages(images)
+	}
+	return out, nil
+}
+
+// fetchPriceHistory resolves the price history of a batch of product IDs
+// with one GetPriceHistory call.
+func (s *Server) fetchPriceHistory(ctx context.Context, productIDs []string) (map[string][]*PriceChange, error) {
+	byProduct, err := s.catalogClient.GetPriceHistory(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]*PriceChange, len(byProduct))
+	for id, changes := range byProduct {
+		out[id] = toPriceChanges(changes)
+	}
+	return out, nil
+}
+
+// reviewKey identifies a page of the reviews of a product.
+type reviewKey struct {
+	productID string
+	skip      uint64
+	take      uint64
+}
+
+// fetchReviews resolves a batch of review pages with one GetProductReviews
+// call per page asked for; usually every product asks for the same one.
+func (s *Server) fetchReviews(ctx context.Context, keys []reviewKey) (map[reviewKey][]*Review, error) {
+	type page struct{ skip, take uint64 }
+	byPage := map[page][]string{}
+	pages := []page{}
+	for _, k := range keys {
+		p := page{k.skip, k.take}
+		if _, ok := byPage[p]; !ok {
+			pages = append(pages, p)
+		}
+		byPage[p] = append(byPage[p], k.productID)
+	}
+
+	out := make(map[reviewKey][]*Review, len(keys))
+	for _, p := range pages {
+		byProduct, err := s.catalogClient.GetProductReviews(ctx, byPage[p], p.skip, p.take)
+		if err != nil {
+			return nil, err
+		}
+		for id, reviews := range byProduct {
+			out[reviewKey{productID: id, skip: p.skip, take: p.take}] = toReviews(reviews)
+		}
+	}
+	return out, nil
+}
+
+// fetchStockLevels resolves the stock of a batch of product IDs with one
+// GetStockLevels call. Untracked products map to an empty list.
+func (s *Server) fetchStockLevels(ctx context.Context, productIDs []string) (map[string][]catalog.StockLevel, error) {
+	return s.catalogClient.GetStockLevels(ctx, productIDs)
+}
+
+// priceKey identifies the price of a variant, or of a product sold without
+// variants when variantID is empty, in one currency.
+type priceKey struct {
+	productID string
+	variantID string
+	currency  string
+}
+
+// fetchPrices resolves a batch of prices with one GetPrices call per
+// currency asked for.
+func (s *Server) fetchPrices(ctx context.Context, keys []priceKey) (map[priceKey]*Money, error) {
+	byCurrency := map[string][]catalog.PriceItem{}
+	currencies := []string{}
+	for _, k := range keys {
+		if _, ok := byCurrency[k.currency]; !ok {
+			currencies = append(currencies, k.currency)
+		}
+		byCurrency[k.currency] = append(byCurrency[k.currency], catalog.PriceItem{ProductID: k.productID, VariantID: k.variantID})
+	}
+
+	out := make(map[priceKey]*Money, len(keys))
+	for _, c := range currencies {
+		prices, err := s.catalogClient.GetPrices(ctx, byCurrency[c], c)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range prices {
+			out[priceKey{productID: p.ProductID, variantID: p.VariantID, currency: c}] = toMoney(p.Amount)
+		}
+	}
+	return out, nil
+}
@@ -42,27 +42,106 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 
-	"github.com/kelseyhightower/envconfig"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
-	"github.com/kelseyhightower/envconfig"
+	"github.com/gorilla/websocket"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/config"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/ratelimit"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
 )
 
 type AppConfig struct {
-	AccountServiceURL string `envconfig:"ACCOUNT_SERVICE_URL" default:"http://localhost:8081"`
-	CatalogServiceURL string `envconfig:"CATALOG_SERVICE_URL" default:"http://localhost:8082"`
-	OrderServiceURL   string `envconfig:"ORDER_SERVICE_URL" default:"http://localhost:8083"`
+	Port int `envconfig:"PORT" default:"8080" validate:"port"`
+
+	// gRPC targets of the downstream services, written as host:port
+	AccountServiceURL string `envconfig:"ACCOUNT_SERVICE_URL" default:"localhost:8081" validate:"required,hostport"`
+	CatalogServiceURL string `envconfig:"CATALOG_SERVICE_URL" default:"localhost:8082" validate:"required,hostport"`
+	OrderServiceURL   string `envconfig:"ORDER_SERVICE_URL" default:"localhost:8083" validate:"required,hostport"`
+
+	// Tracing is disabled unless TRACING_EXPORTER is set
+	Tracing tracing.Config `envconfig:"TRACING"`
+
+	// Per-caller token buckets, see RATE_LIMIT_* variables
+	RateLimit ratelimit.Config `envconfig:"RATE_LIMIT"`
+
+	// Maximum depth and complexity of an operation, see QUERY_* variables
+	QueryLimits QueryLimits `envconfig:"QUERY"`
+
+	// Key of the bearer tokens checked by the schema directives, see AUTH_* variables
+	Auth auth.Config `envconfig:"AUTH"`
+
+	// How long /readyz waits for the health checks of the services
+	ReadyTimeout time.Duration `envconfig:"READY_TIMEOUT" default:"2s" validate:"min=1ms"`
+
+	// In-process cache of query responses, see RESPONSE_CACHE_* variables
+	ResponseCache ResponseCacheConfig `envconfig:"RESPONSE_CACHE"`
+
+	// Hashes of recently seen queries kept for automatic persisted queries
+	APQCacheSize int `envconfig:"APQ_CACHE_SIZE" default:"1000" validate:"min=1"`
+
+	// Production disables introspection and only runs the operations of
+	// the OPERATION_ALLOWLIST manifest, which it therefore requires
+	Production         bool   `envconfig:"PRODUCTION" default:"false"`
+	OperationAllowlist string `envconfig:"OPERATION_ALLOWLIST"`
+
+	// Operation names the metrics report by name, on top of those of the
+	// OPERATION_ALLOWLIST manifest; any other name is reported as "other"
+	MetricsOperations []string `envconfig:"METRICS_OPERATIONS"`
+
+	// Subscriptions run over WebSocket. Browsers may only open one from a
+	// page served by the gateway itself or by one of these origins
+	// (e.g. https://shop.example.com); "*" allows any origin.
+	WebsocketOrigins []string `envconfig:"WEBSOCKET_ORIGINS"`
+
+	// Idle subscriptions are pinged this often so proxies keep them open
+	WebsocketKeepAlive time.Duration `envconfig:"WEBSOCKET_KEEP_ALIVE" default:"10s" validate:"min=1s"`
+
+	// Serve one domain as an Apollo Federation v2 subgraph instead of the
+	// whole graph; empty keeps the monolithic gateway
+	Subgraph string `envconfig:"SUBGRAPH" validate:"oneof=account|catalog|order"`
+}
+
+// Validate implements config.Validator.
+func (c *AppConfig) Validate() error {
+	// A subgraph runs the query plans of the router, not client operations
+	if c.Subgraph != "" && c.OperationAllowlist != "" {
+		return errors.New("OPERATION_ALLOWLIST cannot be used with SUBGRAPH; register operations with the router")
+	}
+	if c.Production && c.Subgraph == "" && c.OperationAllowlist == "" {
+		return errors.New("PRODUCTION requires OPERATION_ALLOWLIST")
+	}
+	if c.Production && c.Auth.Secret == "" {
+		return errors.New("PRODUCTION requires AUTH_JWT_SECRET")
+	}
+	return nil
 }
 
 func main() {
+	// Defaults < config file < environment < flags, validated before anything starts
 	var cfg AppConfig
-	err := envconfig.Process("", &cfg)
+	config.MustLoad("graphql", &cfg)
+
+	// Install the tracer provider before the gRPC clients are created
+	shutdownTracing, err := tracing.Init(context.Background(), "graphql", cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to process envconfig: %v", err)
+		log.Fatalf("Failed to initialize tracing: %v", err)
 	}
+	defer shutdownTracing(context.Background())
 
 	// Initialize your GraphQL server, capturing both server instance and error
 	s, err := NewGraphQlServer(cfg.AccountServiceURL, cfg.CatalogServiceURL, cfg.OrderServiceURL)
@@ -70,18 +149,135 @@ func main() {
 		log.Fatalf("Failed to create GraphQL server: %v", err)
 	}
 
-	// Create executable schema from your server resolvers
-	execSchema := s.ToExecutableSchema()
+	// Create executable schema from your server resolvers: the whole graph,
+	// or one domain's subgraph
+	execSchema := s.executableSchema(cfg.Subgraph)
 
 	// Setup the GraphQL handler with the executable schema
 	srv := handler.New(execSchema)
 
+	// Bearer tokens identify callers for the access directives
+	verifier := auth.NewVerifier(cfg.Auth)
+	if cfg.Auth.Secret == "" {
+		log.Println("AUTH_JWT_SECRET is not set: every caller is anonymous")
+	}
+
+	// Queries over GET (cacheable by CDNs) or POST, mutations over POST,
+	// subscriptions over WebSocket
+	srv.AddTransport(transport.Websocket{
+		InitFunc:              auth.WebsocketInit(verifier),
+		KeepAlivePingInterval: cfg.WebsocketKeepAlive,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: checkOrigin(cfg.WebsocketOrigins),
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+
+	// Files for uploadProductImage come as multipart requests; parts above
+	// 1 MiB are buffered in temporary files rather than in memory
+	srv.AddTransport(transport.MultipartForm{
+		MaxUploadSize: catalog.MaxImageSize + 1<<20,
+		MaxMemory:     1 << 20,
+	})
+
+	// In production only registered operations run; the allowlist resolves
+	// hash-only requests itself, so it goes before APQ
+	metricsOperations := cfg.MetricsOperations
+	if cfg.OperationAllowlist != "" {
+		allowlist, err := loadAllowlist(cfg.OperationAllowlist)
+		if err != nil {
+			log.Fatalf("Failed to load operation allowlist: %v", err)
+		}
+		srv.Use(allowlist)
+		metricsOperations = append(metricsOperations, allowlist.operationNames()...)
+	}
+
+	// Accept sha256 hashes in place of query texts already seen (APQ)
+	srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](cfg.APQCacheSize)})
+
+	// Let the Playground and codegen tools read the schema, except in production
+	if !cfg.Production {
+		srv.Use(extension.Introspection{})
+	}
+
+	// Reject too deep or too expensive operations before they run
+	srv.Use(depthLimit{max: cfg.QueryLimits.MaxDepth})
+	srv.Use(extension.FixedComplexityLimit(cfg.QueryLimits.MaxComplexity))
+
+	// Record per-operation latency and error counts
+	srv.Use(newOperationMetrics(metricsOperations))
+
+	// Trace every operation and resolver
+	srv.Use(newOperationTracing())
+
+	// Send Cache-Control from the @cacheControl hints and answer repeated
+	// queries from the response cache when it is enabled
+	cache, err := newCacheControl(cfg.ResponseCache)
+	if err != nil {
+		log.Fatalf("Failed to create response cache: %v", err)
+	}
+	srv.Use(cache)
+
+	// Batch and de-duplicate nested lookups within each operation
+	srv.Use(dataLoaders{server: s})
+
+	// Throttle each caller per root field
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		log.Fatalf("Failed to configure rate limiting: %v", err)
+	}
+	srv.Use(rateLimit{limiter: limiter})
+
 	// Register the GraphQL endpoint
-	http.Handle("/graphql", srv)
+	http.Handle("/graphql", auth.Middleware(rateLimitCaller(withCurrency(withResponseHeader(srv)), cfg.RateLimit.TrustForwardedFor), verifier))
+
+	// Register Playground UI at /playground for easy testing; it needs
+	// introspection, so production has none
+	if !cfg.Production {
+		http.Handle("/playground", playground.Handler("GraphQL Playground", "/graphql"))
+	}
+
+	// Liveness of the process, and readiness of the services behind it
+	http.HandleFunc("/healthz", healthz)
+	http.Handle("/readyz", readyz(cfg.ReadyTimeout, s.healthChecks(cfg.Subgraph)))
 
-	// Register Playground UI at /playground for easy testing
-	http.Handle("/playground", playground.Handler("GraphQL Playground", "/graphql"))
+	// Expose Prometheus metrics for the gateway
+	http.Handle("/metrics", metrics.Handler())
 
-	log.Println("GraphQL server is running on http://localhost:8080/playground")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	if cfg.Subgraph != "" {
+		log.Printf("Serving the %s subgraph", cfg.Subgraph)
+	}
+	if cfg.Production {
+		log.Printf("GraphQL server is running on http://localhost:%d/graphql", cfg.Port)
+	} else {
+		log.Printf("GraphQL server is running on http://localhost:%d/playground", cfg.Port)
+	}
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), nil))
+}
+
+// checkOrigin accepts WebSocket upgrades from the gateway's own origin and
+// from the allowed ones, so other sites cannot subscribe with a visitor's
+// cookies. Clients that send no Origin (not browsers) are always accepted.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if u.Host == r.Host {
+			return true
+		}
+		for _, o := range allowed {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		return false
+	}
 }
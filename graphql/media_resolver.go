@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
+
+// Images implements ProductResolver.
+// The images of every product in the operation are fetched in one batch.
+func (p *productResolver) Images(ctx context.Context, obj *Product) ([]*ProductImage, error) {
+	images, err := p.server.loaders(ctx).productImages.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if images == nil {
+		images = []*ProductImage{}
+	}
+	return images, nil
+}
+
+// UploadProductImage implements MutationResolver.
+// The file is streamed to the catalog service from the part the multipart
+// transport buffered; oversized files are refused before any byte is sent.
+func (m *mutationResolver) UploadProductImage(ctx context.Context, productID string, file graphql.Upload, altText *string) (*ProductImage, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	if file.Size > catalog.MaxImageSize {
+		return nil, fmt.Errorf("%s is larger than %d MiB", file.Filename, catalog.MaxImageSize>>20)
+	}
+	var alt string
+	if altText != nil {
+		alt = *altText
+	}
+
+	img, err := m.server.catalogClient.UploadProductImage(ctx, productID, alt, file.File)
+	if err != nil {
+		return nil, err
+	}
+	return toProductImage(img), nil
+}
+
+// UpdateProductImage implements MutationResolver.
+func (m *mutationResolver) UpdateProductImage(ctx context.Context, id string, altText string) (*ProductImage, error) {
+	img, err := m.server.catalogClient.UpdateProductImage(ctx, id, altText)
+	if err != nil {
+		return nil, err
+	}
+	return toProductImage(img), nil
+}
+
+// ReorderProductImages implements MutationResolver.
+func (m *mutationResolver) ReorderProductImages(ctx context.Context, productID string, imageIds []string) ([]*ProductImage, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	images, err := m.server.catalogClient.ReorderProductImages(ctx, productID, imageIds)
+	if err != nil {
+		return nil, err
+	}
+	return toProductImages(images), nil
+}
+
+// DeleteProductImage implements MutationResolver.
+func (m *mutationResolver) DeleteProductImage(ctx context.Context, id string) (bool, error) {
+	if err := m.server.catalogClient.DeleteProductImage(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// toProductImage maps a catalog image to its GraphQL type.
+func toProductImage(img *catalog.ProductImage) *ProductImage {
+	out := &ProductImage{
+		ID:          img.ID,
+		ProductID:   img.ProductID,
+		URL:         optionalString(img.URL),
+		AltText:     img.AltText,
+		ContentType: img.ContentType,
+		Width:       int(img.Width),
+		Height:      int(img.Height),
+		Size:        int(img.Size),
+		Position:    int(img.Position),
+		Thumbnails:  make([]*ImageThumbnail, 0, len(img.Thumbnails)),
+		CreatedAt:   img.CreatedAt,
+	}
+	for _, t := range img.Thumbnails {
+		out.Thumbnails = append(out.Thumbnails, &ImageThumbnail{
+			Size:        int(t.Size),
+			Width:       int(t.Width),
+			Height:      int(t.Height),
+			ContentType: t.ContentType,
+			URL:         optionalString(t.URL),
+		})
+	}
+	return out
+}
+
+func toProductImages(images []catalog.ProductImage) []*ProductImage {
+	out := make([]*ProductImage, 0, len(images))
+	for i := range images {
+		out = append(out, toProductImage(&images[i]))
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+)
+
+var (
+	// operationSeconds observes how long each GraphQL operation took.
+	operationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "graphql",
+		Name:      "operation_seconds",
+		Help:      "Latency of GraphQL operations served by the gateway.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	// operationErrors counts operations whose response carried at least one error.
+	operationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "graphql",
+		Name:      "operation_errors_total",
+		Help:      "Total number of GraphQL operations that returned errors.",
+	}, []string{"operation", "type"})
+)
+
+func init() {
+	prometheus.MustRegister(operationSeconds, operationErrors)
+}
+
+// Operation labels of the requests whose operation name is not reported.
+const (
+	anonymousOperation = "anonymous"
+	otherOperation     = "other"
+	unknownOperation   = "unknown"
+)
+
+// operationMetrics is a gqlgen handler extension that records latency and
+// error counts for every GraphQL operation, labelled by operation name.
+// Clients choose the names, so only known ones become label values; the
+// others are all counted as "other".
+type operationMetrics struct {
+	known map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = operationMetrics{}
+
+// newOperationMetrics reports the operations named in names by their name.
+func newOperationMetrics(names []string) operationMetrics {
+	m := operationMetrics{known: make(map[string]bool, len(names))}
+	for _, name := range names {
+		if name != "" {
+			m.known[name] = true
+		}
+	}
+	return m
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (operationMetrics) ExtensionName() string {
+	return "OperationMetrics"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (operationMetrics) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor.
+func (m operationMetrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	start := time.Now()
+	resp := next(ctx)
+
+	name, opType := operationLabels(ctx)
+	if name != anonymousOperation && name != unknownOperation && !m.known[name] {
+		name = otherOperation
+	}
+	operationSeconds.WithLabelValues(name, opType).Observe(time.Since(start).Seconds())
+	if resp != nil && len(resp.Errors) > 0 {
+		operationErrors.WithLabelValues(name, opType).Inc()
+	}
+
+	return resp
+}
+
+// operationLabels returns the operation name and type ("query", "mutation")
+// of the current request, "anonymous" for operations without a name. The
+// name is the client's; metrics only report known ones.
+func operationLabels(ctx context.Context) (string, string) {
+	if !graphql.HasOperationContext(ctx) {
+		return unknownOperation, unknownOperation
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	name := oc.OperationName
+	if name == "" {
+		name = anonymousOperation
+	}
+
+	opType := unknownOperation
+	if oc.Operation != nil {
+		opType = string(oc.Operation.Operation)
+	}
+	if opType == "" {
+		opType = string(ast.Query)
+	}
+
+	return name, opType
+}
@@ -5,4 +5,10 @@ type Account struct{
 	ID  string  `json:"id"`
 	Name  string `json:"name"`
 	Orders []Order `json:"orders"`
-}
\ No newline at end of file
+}
+
+// IsNode marks Account as a Relay Node.
+func (Account) IsNode() {}
+
+// GetID returns the account service ID; the global ID is built by the id resolver.
+func (a Account) GetID() string { return a.ID }
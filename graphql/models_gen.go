@@ -3,47 +3,124 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
+type Node interface {
+	IsNode()
+	GetID() string
+}
+
 type AccountInput struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type Category struct {
+	ID          string      `json:"id"`
+	ParentID    *string     `json:"parentId,omitempty"`
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Position    int         `json:"position"`
+	Breadcrumbs []*Category `json:"breadcrumbs"`
+	Children    []*Category `json:"children"`
+}
+
+type CategoryInput struct {
+	Name     string  `json:"name"`
+	Slug     *string `json:"slug,omitempty"`
+	ParentID *string `json:"parentId,omitempty"`
+	Position *int    `json:"position,omitempty"`
+}
+
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ExchangeRateInput struct {
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+}
+
+type ExchangeRates struct {
+	BaseCurrency string          `json:"baseCurrency"`
+	Rates        []*ExchangeRate `json:"rates"`
+}
+
+type ImageThumbnail struct {
+	Size        int     `json:"size"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	ContentType string  `json:"contentType"`
+	URL         *string `json:"url,omitempty"`
+}
+
+type ListPrice struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Price     *Money  `json:"price"`
+}
+
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type MoneyInput struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
 type Mutation struct {
 }
 
 type Order struct {
 	ID         string            `json:"id"`
 	AccountID  string            `json:"accountId"`
+	Status     OrderStatus       `json:"status"`
 	Products   []*OrderedProduct `json:"products"`
 	Quantity   int               `json:"quantity"`
-	TotalPrice float64           `json:"totalPrice"`
+	TotalPrice *Money            `json:"totalPrice"`
 	CreatedAt  time.Time         `json:"createdAt"`
 	UpdatedaAt time.Time         `json:"updatedaAt"`
 }
 
+func (Order) IsNode()            {}
+func (this Order) GetID() string { return this.ID }
+
 type OrderInput struct {
 	AccountID string               `json:"accountId"`
 	Products  []*OrderProductInput `json:"products"`
+	Currency  *string              `json:"currency,omitempty"`
 }
 
 type OrderProductInput struct {
-	ID       string `json:"id"`
-	Quantity int    `json:"quantity"`
+	ID       *string `json:"id,omitempty"`
+	Sku      *string `json:"sku,omitempty"`
+	Quantity int     `json:"quantity"`
 }
 
 type OrderedProduct struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	ProductID   string    `json:"productId"`
-	Quantity    int       `json:"quantity"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID            string           `json:"id"`
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	Price         *Money           `json:"price"`
+	ConvertedFrom *Money           `json:"convertedFrom,omitempty"`
+	ExchangeRate  *string          `json:"exchangeRate,omitempty"`
+	ProductID     string           `json:"productId"`
+	Quantity      int              `json:"quantity"`
+	VariantID     *string          `json:"variantId,omitempty"`
+	Sku           *string          `json:"sku,omitempty"`
+	Options       []*VariantOption `json:"options"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
 }
 
 type PaginationInput struct {
@@ -51,21 +128,356 @@ type PaginationInput struct {
 	Limit  *int `json:"limit,omitempty"`
 }
 
+type PriceChange struct {
+	ID             string     `json:"id"`
+	ProductID      string     `json:"productId"`
+	Price          *Money     `json:"price"`
+	EffectiveFrom  time.Time  `json:"effectiveFrom"`
+	EffectiveUntil *time.Time `json:"effectiveUntil,omitempty"`
+	Scheduled      bool       `json:"scheduled"`
+	Label          string     `json:"label"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
 type Product struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	Description       string            `json:"description"`
+	Price             *Money            `json:"price"`
+	Categories        []*Category       `json:"categories"`
+	Variants          []*ProductVariant `json:"variants"`
+	Images            []*ProductImage   `json:"images"`
+	PriceHistory      []*PriceChange    `json:"priceHistory"`
+	Reviews           []*Review         `json:"reviews"`
+	AverageRating     *float64          `json:"averageRating,omitempty"`
+	ReviewCount       int               `json:"reviewCount"`
+	AvailableQuantity *int              `json:"availableQuantity,omitempty"`
+	CreatedAt         time.Time         `json:"createdAt"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
+}
+
+func (Product) IsNode()            {}
+func (this Product) GetID() string { return this.ID }
+
+type ProductFilters struct {
+	MinPrice *MoneyInput `json:"minPrice,omitempty"`
+	MaxPrice *MoneyInput `json:"maxPrice,omitempty"`
+}
+
+type ProductImage struct {
+	ID          string            `json:"id"`
+	ProductID   string            `json:"productId"`
+	URL         *string           `json:"url,omitempty"`
+	AltText     string            `json:"altText"`
+	ContentType string            `json:"contentType"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Size        int               `json:"size"`
+	Position    int               `json:"position"`
+	Thumbnails  []*ImageThumbnail `json:"thumbnails"`
+	CreatedAt   time.Time         `json:"createdAt"`
 }
 
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       *MoneyInput `json:"price"`
+}
+
+type ProductSearchHit struct {
+	Product              *Product `json:"product"`
+	Rank                 float64  `json:"rank"`
+	NameHighlight        string   `json:"nameHighlight"`
+	DescriptionHighlight string   `json:"descriptionHighlight"`
+}
+
+type ProductSearchResult struct {
+	Hits       []*ProductSearchHit `json:"hits"`
+	TotalCount int                 `json:"totalCount"`
+	Fuzzy      bool                `json:"fuzzy"`
+}
+
+type ProductVariant struct {
+	ID                string           `json:"id"`
+	ProductID         string           `json:"productId"`
+	Sku               string           `json:"sku"`
+	Options           []*VariantOption `json:"options"`
+	Price             *Money           `json:"price"`
+	PriceOverride     *Money           `json:"priceOverride,omitempty"`
+	Barcode           *string          `json:"barcode,omitempty"`
+	Position          int              `json:"position"`
+	AvailableQuantity *int             `json:"availableQuantity,omitempty"`
+}
+
+type ProductVariantInput struct {
+	Sku           string                `json:"sku"`
+	Options       []*VariantOptionInput `json:"options"`
+	PriceOverride *MoneyInput           `json:"priceOverride,omitempty"`
+	Barcode       *string               `json:"barcode,omitempty"`
+	Position      *int                  `json:"position,omitempty"`
 }
 
 type Query struct {
-	
+}
+
+type Review struct {
+	ID               string       `json:"id"`
+	ProductID        string       `json:"productId"`
+	AccountID        string       `json:"accountId"`
+	Rating           int          `json:"rating"`
+	Title            string       `json:"title"`
+	Body             string       `json:"body"`
+	VerifiedPurchase bool         `json:"verifiedPurchase"`
+	Status           ReviewStatus `json:"status"`
+	CreatedAt        time.Time    `json:"createdAt"`
+	UpdatedAt        time.Time    `json:"updatedAt"`
+}
+
+type ReviewInput struct {
+	ProductID string  `json:"productId"`
+	AccountID string  `json:"accountId"`
+	Rating    int     `json:"rating"`
+	Title     string  `json:"title"`
+	Body      *string `json:"body,omitempty"`
+}
+
+type Subscription struct {
+}
+
+type VariantOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VariantOptionInput struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+var AllCacheControlScope = []CacheControlScope{
+	CacheControlScopePublic,
+	CacheControlScopePrivate,
+}
+
+func (e CacheControlScope) IsValid() bool {
+	switch e {
+	case CacheControlScopePublic, CacheControlScopePrivate:
+		return true
+	}
+	return false
+}
+
+func (e CacheControlScope) String() string {
+	return string(e)
+}
+
+func (e *CacheControlScope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CacheControlScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CacheControlScope", str)
+	}
+	return nil
+}
+
+func (e CacheControlScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CacheControlScope) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CacheControlScope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPlaced    OrderStatus = "PLACED"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPlaced,
+	OrderStatusPaid,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPlaced, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OwnedType string
+
+const (
+	OwnedTypeAccount OwnedType = "ACCOUNT"
+	OwnedTypeOrder   OwnedType = "ORDER"
+)
+
+var AllOwnedType = []OwnedType{
+	OwnedTypeAccount,
+	OwnedTypeOrder,
+}
+
+func (e OwnedType) IsValid() bool {
+	switch e {
+	case OwnedTypeAccount, OwnedTypeOrder:
+		return true
+	}
+	return false
+}
+
+func (e OwnedType) String() string {
+	return string(e)
+}
+
+func (e *OwnedType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OwnedType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OwnedType", str)
+	}
+	return nil
+}
+
+func (e OwnedType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OwnedType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OwnedType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "PENDING"
+	ReviewStatusApproved ReviewStatus = "APPROVED"
+	ReviewStatusRejected ReviewStatus = "REJECTED"
+)
+
+var AllReviewStatus = []ReviewStatus{
+	ReviewStatusPending,
+	ReviewStatusApproved,
+	ReviewStatusRejected,
+}
+
+func (e ReviewStatus) IsValid() bool {
+	switch e {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e ReviewStatus) String() string {
+	return string(e)
+}
+
+func (e *ReviewStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReviewStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReviewStatus", str)
+	}
+	return nil
+}
+
+func (e ReviewStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReviewStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReviewStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
 }
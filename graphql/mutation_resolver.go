@@ -1,6 +1,14 @@
 package main
 
-import "context"
+import (
+	"context"
+	"errors"
+
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+)
+
+// errOrderLine is returned by createOrder for a product given by neither ID nor SKU.
+var errOrderLine = errors.New("every ordered product needs an id or a sku")
 
 type mutationResolver struct {
 	server *Server
@@ -12,8 +20,41 @@ func (m *mutationResolver) CreateAccount(ctx context.Context, input AccountInput
 }
 
 // CreateOrder implements MutationResolver.
+// Products are referenced by ID, by variant SKU or both; the order service
+// prices them from the catalog and records the variant of every line.
 func (m *mutationResolver) CreateOrder(ctx context.Context, input OrderInput) (*Order, error) {
-	panic("unimplemented")
+	accountID, err := localID(nodeAccount, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]order.OrderedProduct, 0, len(input.Products))
+	for _, p := range input.Products {
+		if p.ID == nil && p.Sku == nil {
+			return nil, errOrderLine
+		}
+		if p.Quantity <= 0 {
+			return nil, order.ErrInvalidQuantity
+		}
+
+		line := order.OrderedProduct{Quantity: uint32(p.Quantity)}
+		if p.ID != nil {
+			if line.ID, err = localID(nodeProduct, *p.ID); err != nil {
+				return nil, err
+			}
+		}
+		if p.Sku != nil {
+			line.SKU = *p.Sku
+		}
+		products = append(products, line)
+	}
+
+	// Paid in the currency the prices were shown in
+	o, err := m.server.orderClient.PostOrder(ctx, accountID, products, requestedCurrency(ctx, input.Currency))
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
 }
 
 // CreateProduct implements MutationResolver.
@@ -50,3 +91,17 @@ func (m *mutationResolver) UpdateOrder(ctx context.Context, id string, input Ord
 func (m *mutationResolver) UpdateProduct(ctx context.Context, id string, input ProductInput) (*Product, error) {
 	panic("unimplemented")
 }
+
+// UpdateOrderStatus implements MutationResolver.
+// Subscribers of orderStatusChanged are notified once the order service has stored the change.
+func (m *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	id, err := localID(nodeOrder, id)
+	if err != nil {
+		return nil, err
+	}
+	o, err := m.server.orderClient.UpdateOrderStatus(ctx, id, order.OrderStatus(status))
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+)
+
+// Type names used as the prefix of global IDs.
+const (
+	nodeAccount = "Account"
+	nodeProduct = "Product"
+	nodeOrder   = "Order"
+)
+
+// toGlobalID builds the Relay global ID of the object of type typ whose
+// service ID is id: base64url("Account:2X4..."). Clients must treat it as
+// opaque; the gateway turns it back into the service ID with fromGlobalID.
+func toGlobalID(typ, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(typ + ":" + id))
+}
+
+// fromGlobalID splits a global ID into its type and service ID. ok is false
+// when gid is not a global ID of one of the Node types.
+func fromGlobalID(gid string) (typ, id string, ok bool) {
+	b, err := base64.RawURLEncoding.DecodeString(gid)
+	if err != nil {
+		return "", "", false
+	}
+	typ, id, found := strings.Cut(string(b), ":")
+	if !found || id == "" {
+		return "", "", false
+	}
+	switch typ {
+	case nodeAccount, nodeProduct, nodeOrder:
+		return typ, id, true
+	}
+	return "", "", false
+}
+
+// localID returns the service ID passed as an argument expecting an object
+// of type typ. Both global IDs and raw service IDs are accepted, so clients
+// written before global IDs keep working; a global ID of another type is
+// an error.
+func localID(typ, id string) (string, error) {
+	gtyp, local, ok := fromGlobalID(id)
+	if !ok {
+		return id, nil
+	}
+	if gtyp != typ {
+		return "", fmt.Errorf("%s is a global ID of type %s, expected %s", id, gtyp, typ)
+	}
+	return local, nil
+}
+
+// ID implements AccountResolver.
+func (a *accountResolver) ID(ctx context.Context, obj *Account) (string, error) {
+	return toGlobalID(nodeAccount, obj.ID), nil
+}
+
+type productResolver struct {
+	server *Server
+}
+
+// ID implements ProductResolver.
+func (p *productResolver) ID(ctx context.Context, obj *Product) (string, error) {
+	return toGlobalID(nodeProduct, obj.ID), nil
+}
+
+type orderResolver struct {
+	server *Server
+}
+
+// ID implements OrderResolver.
+func (o *orderResolver) ID(ctx context.Context, obj *Order) (string, error) {
+	return toGlobalID(nodeOrder, obj.ID), nil
+}
+
+// Node implements QueryResolver.
+// The type prefix of the ID picks the service to ask; unknown or missing
+// objects resolve to null.
+func (q *queryResolver) Node(ctx context.Context, id string) (Node, error) {
+	return q.server.node(ctx, id)
+}
+
+// Nodes implements QueryResolver.
+// The IDs are resolved concurrently, so accounts and products are fetched
+// in one batch per service through the loaders.
+func (q *queryResolver) Nodes(ctx context.Context, ids []string) ([]Node, error) {
+	nodes := make([]Node, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			nodes[i], errs[i] = q.server.node(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	// Entries are nullable: one missing, forbidden or failed object leaves
+	// the others intact and reports its error alongside them
+	for _, err := range errs {
+		if err != nil {
+			graphql.AddError(ctx, err)
+		}
+	}
+	return nodes, nil
+}
+
+// node fetches the object behind a global ID from its service. Accounts
+// and orders are subject to the same ownership rule as their @owner fields.
+func (s *Server) node(ctx context.Context, gid string) (Node, error) {
+	typ, id, ok := fromGlobalID(gid)
+	if !ok {
+		return nil, nil
+	}
+
+	switch typ {
+	case nodeAccount:
+		if err := auth.AuthorizeOwner(ctx, id); err != nil {
+			return nil, err
+		}
+		a, err := s.loaders(ctx).accounts.Load(ctx, id)
+		if a == nil || err != nil {
+			return nil, err
+		}
+		return a, nil
+	case nodeProduct:
+		p, err := s.loaders(ctx).products.Load(ctx, id)
+		if p == nil || err != nil {
+			return nil, err
+		}
+		return p, nil
+	case nodeOrder:
+		o, err := s.orderClient.GetOrder(ctx, id)
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := auth.AuthorizeOwner(ctx, o.AccountID); err != nil {
+			return nil, err
+		}
+		return toOrder(o), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// operationNotAllowedCode is the extensions.code of operations missing from the allowlist.
+const operationNotAllowedCode = "OPERATION_NOT_ALLOWED"
+
+var errEmptyAllowlist = errors.New("operation allowlist has no operations")
+
+// operationAllowlist is a gqlgen handler extension that only lets through
+// the operations registered in a manifest. A client may send the full
+// query text, which must match a registered operation byte for byte, or
+// only its sha256 hash through the APQ extension, in which case the text
+// is taken from the manifest.
+type operationAllowlist struct {
+	// sha256 of the query text → query text
+	queries map[string]string
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = &operationAllowlist{}
+
+// loadAllowlist reads the operation manifest at path. Two formats are
+// understood:
+//
+//   - a JSON object of id → query text, as written by relay-compiler
+//     (`persistConfig.file`) and most persisted-query tooling;
+//   - an Apollo persisted query manifest,
+//     {"format": "apollo-persisted-query-manifest", "operations": [{"body": "..."}]}.
+//
+// Operations are keyed by the sha256 of their text, whatever id the
+// manifest uses, which is the hash APQ clients send.
+func loadAllowlist(path string) (*operationAllowlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var apollo struct {
+		Format     string `json:"format"`
+		Operations []struct {
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+	var bodies []string
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Format != "" {
+		for _, op := range apollo.Operations {
+			bodies = append(bodies, op.Body)
+		}
+	} else {
+		var byID map[string]string
+		if err := json.Unmarshal(data, &byID); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, body := range byID {
+			bodies = append(bodies, body)
+		}
+	}
+
+	a := &operationAllowlist{queries: make(map[string]string, len(bodies))}
+	for _, body := range bodies {
+		if body != "" {
+			a.queries[queryHash(body)] = body
+		}
+	}
+	if len(a.queries) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, errEmptyAllowlist)
+	}
+	return a, nil
+}
+
+// operationNames lists the names of the operations of the manifest, e.g.
+// to report them in the metrics. Anonymous operations have none.
+func (a *operationAllowlist) operationNames() []string {
+	var names []string
+	for _, query := range a.queries {
+		doc, err := parser.ParseQuery(&ast.Source{Input: query})
+		if err != nil {
+			continue
+		}
+		for _, op := range doc.Operations {
+			if op.Name != "" {
+				names = append(names, op.Name)
+			}
+		}
+	}
+	return names
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (*operationAllowlist) ExtensionName() string {
+	return "OperationAllowlist"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (*operationAllowlist) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationParameters implements graphql.OperationParameterMutator.
+// It must run before the APQ extension: a hash-only request is answered
+// from the manifest, so it never ends in PERSISTED_QUERY_NOT_FOUND and
+// the client never retries with a query that would be refused anyway.
+func (a *operationAllowlist) MutateOperationParameters(ctx context.Context, raw *graphql.RawParams) *gqlerror.Error {
+	var hash string
+	if raw.Query == "" {
+		hash = persistedQueryHash(raw)
+	} else {
+		hash = queryHash(raw.Query)
+	}
+
+	query, ok := a.queries[hash]
+	if !ok {
+		err := gqlerror.Errorf("operation is not in the allowlist")
+		errcode.Set(err, operationNotAllowedCode)
+		return err
+	}
+
+	raw.Query = query
+	return nil
+}
+
+// persistedQueryHash returns the sha256Hash of the APQ extension of raw,
+// or "" when the request has none.
+func persistedQueryHash(raw *graphql.RawParams) string {
+	pq, _ := raw.Extensions["persistedQuery"].(map[string]interface{})
+	hash, _ := pq["sha256Hash"].(string)
+	return hash
+}
+
+// queryHash is the hex sha256 of a query text, as used by APQ.
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
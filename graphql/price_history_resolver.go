@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+)
+
+// PriceHistory implements ProductResolver.
+// The history of every product in the operation is fetched in one batch.
+func (p *productResolver) PriceHistory(ctx context.Context, obj *Product) ([]*PriceChange, error) {
+	changes, err := p.server.loaders(ctx).priceHistory.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []*PriceChange{}
+	}
+	return changes, nil
+}
+
+// ScheduleProductPrice implements MutationResolver.
+func (m *mutationResolver) ScheduleProductPrice(ctx context.Context, productID string, price MoneyInput, startsAt *time.Time, endsAt *time.Time, label *string) (*PriceChange, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := money.Parse(price.Amount, price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var from time.Time
+	if startsAt != nil {
+		from = *startsAt
+	}
+	var l string
+	if label != nil {
+		l = *label
+	}
+
+	c, err := m.server.catalogClient.ScheduleProductPrice(ctx, productID, amount, from, endsAt, l)
+	if err != nil {
+		return nil, err
+	}
+	return toPriceChange(c), nil
+}
+
+// CancelScheduledPrice implements MutationResolver.
+func (m *mutationResolver) CancelScheduledPrice(ctx context.Context, id string) (bool, error) {
+	if err := m.server.catalogClient.CancelScheduledPrice(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// toPriceChange maps an entry of a price history to its GraphQL type.
+func toPriceChange(c *catalog.PriceChange) *PriceChange {
+	return &PriceChange{
+		ID:             c.ID,
+		ProductID:      c.ProductID,
+		Price:          toMoney(c.Price),
+		EffectiveFrom:  c.EffectiveFrom,
+		EffectiveUntil: c.EffectiveUntil,
+		Scheduled:      c.Scheduled,
+		Label:          c.Label,
+		CreatedAt:      c.CreatedAt,
+	}
+}
+
+func toPriceChanges(changes []catalog.PriceChange) []*PriceChange {
+	out := make([]*PriceChange, 0, len(changes))
+	for i := range changes {
+		out = append(out, toPriceChange(&changes[i]))
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+)
+
+// Price implements ProductResolver.
+func (p *productResolver) Price(ctx context.Context, obj *Product, currency *string) (*Money, error) {
+	return p.server.price(ctx, obj.ID, "", obj.Price, currency)
+}
+
+// Price implements ProductVariantResolver.
+func (p *productVariantResolver) Price(ctx context.Context, obj *ProductVariant, currency *string) (*Money, error) {
+	return p.server.price(ctx, obj.ProductID, obj.ID, obj.Price, currency)
+}
+
+// price resolves the price of an item in the requested currency. Prices
+// already in that currency are returned as they are; the others are
+// fetched in one batch per currency for the whole operation.
+func (s *Server) price(ctx context.Context, productID, variantID string, base *Money, currency *string) (*Money, error) {
+	c := requestedCurrency(ctx, currency)
+	if c == "" || (base != nil && c == base.Currency) {
+		return base, nil
+	}
+	return s.loaders(ctx).prices.Load(ctx, priceKey{productID: productID, variantID: variantID, currency: c})
+}
+
+// ExchangeRates implements QueryResolver.
+func (q *queryResolver) ExchangeRates(ctx context.Context) (*ExchangeRates, error) {
+	base, rates, err := q.server.catalogClient.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toExchangeRates(base, rates), nil
+}
+
+// SetListPrice implements MutationResolver.
+func (m *mutationResolver) SetListPrice(ctx context.Context, productID string, variantID *string, price MoneyInput) (*ListPrice, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := money.Parse(price.Amount, price.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var variant string
+	if variantID != nil {
+		variant = *variantID
+	}
+
+	l, err := m.server.catalogClient.SetListPrice(ctx, productID, variant, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &ListPrice{
+		ProductID: l.ProductID,
+		VariantID: optionalString(l.VariantID),
+		Price:     toMoney(l.Price),
+	}, nil
+}
+
+// DeleteListPrice implements MutationResolver.
+func (m *mutationResolver) DeleteListPrice(ctx context.Context, productID string, variantID *string, currency string) (bool, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return false, err
+	}
+	var variant string
+	if variantID != nil {
+		variant = *variantID
+	}
+	if err := m.server.catalogClient.DeleteListPrice(ctx, productID, variant, currency); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetExchangeRates implements MutationResolver.
+func (m *mutationResolver) SetExchangeRates(ctx context.Context, rates []*ExchangeRateInput) (*ExchangeRates, error) {
+	in := make([]catalog.ExchangeRate, 0, len(rates))
+	for _, r := range rates {
+		in = append(in, catalog.ExchangeRate{Currency: r.Currency, Rate: r.Rate})
+	}
+
+	base, out, err := m.server.catalogClient.SetExchangeRates(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return toExchangeRates(base, out), nil
+}
+
+// toExchangeRates maps the rate table of the catalog service to the GraphQL model.
+func toExchangeRates(base string, rates []catalog.ExchangeRate) *ExchangeRates {
+	out := &ExchangeRates{BaseCurrency: base, Rates: make([]*ExchangeRate, 0, len(rates))}
+	for _, r := range rates {
+		out.Rates = append(out.Rates, &ExchangeRate{Currency: r.Currency, Rate: r.Rate, UpdatedAt: r.UpdatedAt})
+	}
+	return out
+}
@@ -1,27 +1,95 @@
 package main
 
-import "context"
+import (
+	"context"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
 
 type queryResolver struct {
 	server *Server
 }
 
 // GetAccount implements QueryResolver.
+// Goes through the loader so aliased lookups of the same ID share one call.
 func (q *queryResolver) GetAccount(ctx context.Context, id string) (*Account, error) {
-	panic("unimplemented")
+	id, err := localID(nodeAccount, id)
+	if err != nil {
+		return nil, err
+	}
+	return q.server.loaders(ctx).accounts.Load(ctx, id)
 }
 
 // GetProduct implements QueryResolver.
 func (q *queryResolver) GetProduct(ctx context.Context, id string) (*Product, error) {
-	panic("unimplemented")
+	id, err := localID(nodeProduct, id)
+	if err != nil {
+		return nil, err
+	}
+	return q.server.loaders(ctx).products.Load(ctx, id)
 }
 
 // ListAccounts implements QueryResolver.
 func (q *queryResolver) ListAccounts(ctx context.Context, pagination *PaginationInput) ([]*Account, error) {
-	panic("unimplemented")
+	skip, take := paginate(pagination)
+	accounts, err := q.server.accountClient.ListAccounts(ctx, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := q.server.loaders(ctx).accounts
+	out := make([]*Account, 0, len(accounts))
+	for i := range accounts {
+		a := toAccount(&accounts[i])
+		loader.Prime(a.ID, a)
+		out = append(out, a)
+	}
+	return out, nil
 }
 
 // ListProducts implements QueryResolver.
 func (q *queryResolver) ListProducts(ctx context.Context, pagination *PaginationInput) ([]*Product, error) {
-	panic("unimplemented")
+	skip, take := paginate(pagination)
+	products, err := q.server.catalogClient.ListProducts(ctx, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := q.server.loaders(ctx).products
+	out := make([]*Product, 0, len(products))
+	for i := range products {
+		p := toProduct(&products[i])
+		loader.Prime(p.ID, p)
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// SearchProducts implements QueryResolver.
+// Found products are primed in the loader like those of ListProducts.
+func (q *queryResolver) SearchProducts(ctx context.Context, query string, filters *ProductFilters, pagination *PaginationInput) (*ProductSearchResult, error) {
+	skip, take := paginate(pagination)
+
+	var f catalog.SearchFilters
+	if filters != nil {
+		var err error
+		if f.MinPrice, err = moneyInput(filters.MinPrice); err != nil {
+			return nil, err
+		}
+		if f.MaxPrice, err = moneyInput(filters.MaxPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := q.server.catalogClient.SearchProducts(ctx, query, f, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	out := toSearchResult(res)
+	loader := q.server.loaders(ctx).products
+	for _, h := range out.Hits {
+		loader.Prime(h.Product.ID, h.Product)
+	}
+	return out, nil
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+	"github.com/olujimiAdebakin/ProtoGraph/ratelimit"
+)
+
+// rateLimitedCode is the extensions.code of errors returned to throttled callers.
+const rateLimitedCode = "RATE_LIMITED"
+
+type callerCtxKey struct{}
+
+// retryAfter collects the longest wait imposed on any root field of a
+// request so it can be sent back as a Retry-After header.
+type retryAfter struct {
+	mu      sync.Mutex
+	seconds int
+}
+
+type retryAfterCtxKey struct{}
+
+// rateLimitCaller identifies the caller of every HTTP request for the
+// limiter and forwards its identity to the downstream services in the
+// outgoing gRPC metadata. It runs inside auth.Middleware, so signed-in
+// callers are limited per account and everyone else per client address.
+func rateLimitCaller(next http.Handler, trustForwardedFor bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ra := &retryAfter{}
+
+		if claims := auth.FromContext(r.Context()); claims != nil && claims.Subject != "" {
+			r = r.WithContext(ratelimit.WithCaller(r.Context(), "account:"+claims.Subject))
+		}
+
+		ctx := ratelimit.ForwardCaller(r.Context(), r, trustForwardedFor)
+		ctx = context.WithValue(ctx, callerCtxKey{}, ratelimit.HTTPCaller(r, trustForwardedFor))
+		ctx = context.WithValue(ctx, retryAfterCtxKey{}, ra)
+
+		next.ServeHTTP(&retryAfterWriter{ResponseWriter: w, retryAfter: ra}, r.WithContext(ctx))
+	})
+}
+
+// retryAfterWriter adds the Retry-After header right before the response
+// is written, once the operation has run and we know if it was throttled.
+type retryAfterWriter struct {
+	http.ResponseWriter
+	retryAfter  *retryAfter
+	wroteHeader bool
+}
+
+func (w *retryAfterWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.retryAfter.mu.Lock()
+		if w.retryAfter.seconds > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(w.retryAfter.seconds))
+		}
+		w.retryAfter.mu.Unlock()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *retryAfterWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack hands the connection over to the WebSocket transport of
+// subscriptions, which needs the raw connection of the request.
+func (w *retryAfterWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Flush implements http.Flusher for streaming transports.
+func (w *retryAfterWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// rateLimit is a gqlgen handler extension that takes a token per root field
+// (createAccount, listProducts, ...) from the caller's bucket. Throttled
+// fields resolve to null with a RATE_LIMITED error carrying retryAfter
+// seconds; the other fields of the operation still run.
+type rateLimit struct {
+	limiter *ratelimit.Limiter
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.RootFieldInterceptor
+} = rateLimit{}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (rateLimit) ExtensionName() string {
+	return "RateLimit"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (rateLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptRootField implements graphql.RootFieldInterceptor.
+func (l rateLimit) InterceptRootField(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+	fc := graphql.GetRootFieldContext(ctx)
+	if fc == nil || l.limiter == nil {
+		return next(ctx)
+	}
+
+	caller, _ := ctx.Value(callerCtxKey{}).(string)
+	ok, wait := l.limiter.Allow(fc.Field.Name, caller)
+	if ok {
+		return next(ctx)
+	}
+
+	graphql.AddError(ctx, rateLimitedError(ctx, fc.Field.Name, fc.Field.Alias, wait))
+	return graphql.Null
+}
+
+// rateLimitedError builds the error returned for a throttled field and
+// records the wait for the Retry-After header.
+func rateLimitedError(ctx context.Context, field, alias string, wait time.Duration) *gqlerror.Error {
+	seconds := ratelimit.RetryAfterSeconds(wait)
+
+	if ra, ok := ctx.Value(retryAfterCtxKey{}).(*retryAfter); ok {
+		ra.mu.Lock()
+		if seconds > ra.seconds {
+			ra.seconds = seconds
+		}
+		ra.mu.Unlock()
+	}
+
+	return &gqlerror.Error{
+		Message: "rate limit exceeded for " + field + ", retry in " + strconv.Itoa(seconds) + "s",
+		Path:    ast.Path{ast.PathName(alias)},
+		Extensions: map[string]interface{}{
+			"code":       rateLimitedCode,
+			"retryAfter": seconds,
+		},
+	}
+}
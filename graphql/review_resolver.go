@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
+
+// Reviews implements ProductResolver.
+// The page of reviews of every product in the operation is fetched in one
+// batch when they ask for the same page.
+func (p *productResolver) Reviews(ctx context.Context, obj *Product, pagination *PaginationInput) ([]*Review, error) {
+	skip, take := paginate(pagination)
+	reviews, err := p.server.loaders(ctx).reviews.Load(ctx, reviewKey{productID: obj.ID, skip: skip, take: take})
+	if err != nil {
+		return nil, err
+	}
+	if reviews == nil {
+		reviews = []*Review{}
+	}
+	return reviews, nil
+}
+
+// ListReviews implements QueryResolver.
+func (q *queryResolver) ListReviews(ctx context.Context, status *ReviewStatus, pagination *PaginationInput) ([]*Review, error) {
+	var s catalog.ReviewStatus
+	if status != nil {
+		s = catalog.ReviewStatus(*status)
+	}
+	skip, take := paginate(pagination)
+
+	reviews, err := q.server.catalogClient.ListReviews(ctx, s, skip, take)
+	if err != nil {
+		return nil, err
+	}
+	return toReviews(reviews), nil
+}
+
+// CreateReview implements MutationResolver.
+// Whether the review is a verified purchase is up to the catalog service.
+func (m *mutationResolver) CreateReview(ctx context.Context, input ReviewInput) (*Review, error) {
+	productID, err := localID(nodeProduct, input.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	accountID, err := localID(nodeAccount, input.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	var body string
+	if input.Body != nil {
+		body = *input.Body
+	}
+
+	r, err := m.server.catalogClient.CreateReview(ctx, catalog.Review{
+		ProductID: productID,
+		AccountID: accountID,
+		Rating:    int32(input.Rating),
+		Title:     input.Title,
+		Body:      body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toReview(r), nil
+}
+
+// ModerateReview implements MutationResolver.
+func (m *mutationResolver) ModerateReview(ctx context.Context, id string, status ReviewStatus) (*Review, error) {
+	r, err := m.server.catalogClient.ModerateReview(ctx, id, catalog.ReviewStatus(status))
+	if err != nil {
+		return nil, err
+	}
+	return toReview(r), nil
+}
+
+// toReview maps a catalog review to its GraphQL type.
+func toReview(r *catalog.Review) *Review {
+	return &Review{
+		ID:               r.ID,
+		ProductID:        r.ProductID,
+		AccountID:        r.AccountID,
+		Rating:           int(r.Rating),
+		Title:            r.Title,
+		Body:             r.Body,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           ReviewStatus(r.Status),
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
+}
+
+func toReviews(reviews []catalog.Review) []*Review {
+	out := make([]*Review, 0, len(reviews))
+	for i := range reviews {
+		out = append(out, toReview(&reviews[i]))
+	}
+	return out
+}
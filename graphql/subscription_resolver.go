@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+)
+
+type subscriptionResolver struct {
+	server *Server
+}
+
+// OrderStatusChanged implements SubscriptionResolver.
+// Every order of the account is pushed as it is placed or changes status.
+// The channel closes when the client unsubscribes or the order service ends the stream.
+func (s *subscriptionResolver) OrderStatusChanged(ctx context.Context, accountID string) (<-chan *Order, error) {
+	accountID, err := localID(nodeAccount, accountID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.server.orderClient.WatchOrderStatus(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return forward(ctx, events, func(o order.Order) *Order { return toOrder(&o) }), nil
+}
+
+// ProductPriceChanged implements SubscriptionResolver.
+// The product is pushed every time its price changes.
+func (s *subscriptionResolver) ProductPriceChanged(ctx context.Context, productID string) (<-chan *Product, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.server.catalogClient.WatchProductPrice(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return forward(ctx, events, func(p catalog.Product) *Product { return toProduct(&p) }), nil
+}
+
+// forward converts the events of a service stream to GraphQL models until
+// the stream ends or the subscription is cancelled. Closing the returned
+// channel ends the subscription for the client.
+func forward[T, M any](ctx context.Context, events <-chan T, convert func(T) M) <-chan M {
+	out := make(chan M)
+	go func() {
+		defer close(out)
+		for event := range events {
+			select {
+			case out <- convert(event):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// operationTracing is a gqlgen handler extension that opens a span per
+// GraphQL operation and a child span per resolver call. Spans started by
+// the gRPC clients inside resolvers become children of the resolver span,
+// so a slow getAccount { orders { products } } shows which hop is to blame.
+type operationTracing struct {
+	tracer trace.Tracer
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = operationTracing{}
+
+// newOperationTracing returns the extension using the global tracer provider.
+func newOperationTracing() operationTracing {
+	return operationTracing{tracer: tracing.Tracer("github.com/olujimiAdebakin/ProtoGraph/graphql")}
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (operationTracing) ExtensionName() string {
+	return "OperationTracing"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (operationTracing) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor.
+// It continues a trace started by the client when the request carries
+// a traceparent header.
+func (t operationTracing) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(oc.Headers))
+
+	name, opType := operationLabels(ctx)
+	ctx, span := t.tracer.Start(ctx, opType+" "+name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.operation.type", opType),
+		),
+	)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+
+	return resp
+}
+
+// InterceptField implements graphql.FieldInterceptor.
+// Only fields backed by a resolver get a span; plain struct fields are free
+// and would only add noise.
+func (t operationTracing) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := t.tracer.Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			attribute.String("graphql.field.path", fc.Path().String()),
+		),
+	)
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return res, err
+}
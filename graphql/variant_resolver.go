@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+)
+
+// Variants implements ProductResolver.
+// The variants of every product in the operation are fetched in one batch.
+func (p *productResolver) Variants(ctx context.Context, obj *Product) ([]*ProductVariant, error) {
+	variants, err := p.server.loaders(ctx).productVariants.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	if variants == nil {
+		variants = []*ProductVariant{}
+	}
+	return variants, nil
+}
+
+// GetProductVariant implements QueryResolver.
+func (q *queryResolver) GetProductVariant(ctx context.Context, sku string) (*ProductVariant, error) {
+	variants, _, err := q.server.catalogClient.GetVariantsBySKUs(ctx, []string{sku})
+	if err != nil {
+		return nil, err
+	}
+	v, ok := variants[catalog.NormalizeSKU(sku)]
+	if !ok {
+		return nil, nil
+	}
+	return toVariant(&v), nil
+}
+
+// CreateProductVariant implements MutationResolver.
+func (m *mutationResolver) CreateProductVariant(ctx context.Context, productID string, input ProductVariantInput) (*ProductVariant, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	v, err := variantInput(input)
+	if err != nil {
+		return nil, err
+	}
+	v.ProductID = productID
+
+	created, err := m.server.catalogClient.CreateVariant(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return toVariant(created), nil
+}
+
+// UpdateProductVariant implements MutationResolver.
+func (m *mutationResolver) UpdateProductVariant(ctx context.Context, id string, input ProductVariantInput) (*ProductVariant, error) {
+	v, err := variantInput(input)
+	if err != nil {
+		return nil, err
+	}
+	v.ID = id
+
+	updated, err := m.server.catalogClient.UpdateVariant(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return toVariant(updated), nil
+}
+
+// DeleteProductVariant implements MutationResolver.
+func (m *mutationResolver) DeleteProductVariant(ctx context.Context, id string) (bool, error) {
+	if err := m.server.catalogClient.DeleteVariant(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// variantInput maps a ProductVariantInput to a catalog variant. An option
+// name given twice is an error rather than silently keeping one value.
+func variantInput(input ProductVariantInput) (catalog.Variant, error) {
+	priceOverride, err := moneyInput(input.PriceOverride)
+	if err != nil {
+		return catalog.Variant{}, err
+	}
+
+	v := catalog.Variant{
+		SKU:           input.Sku,
+		Options:       make(map[string]string, len(input.Options)),
+		PriceOverride: priceOverride,
+	}
+	for _, o := range input.Options {
+		if _, ok := v.Options[o.Name]; ok {
+			return catalog.Variant{}, fmt.Errorf("option %q is given twice", o.Name)
+		}
+		v.Options[o.Name] = o.Value
+	}
+	if input.Barcode != nil {
+		v.Barcode = *input.Barcode
+	}
+	if input.Position != nil {
+		v.Position = int32(*input.Position)
+	}
+	return v, nil
+}
+
+type productVariantResolver struct {
+	server *Server
+}
+
+// AvailableQuantity implements ProductResolver.
+// It adds up the available quantity of the tracked items of the product,
+// its variants or the product itself; the stock of every product in the
+// operation is fetched in one batch.
+func (p *productResolver) AvailableQuantity(ctx context.Context, obj *Product) (*int, error) {
+	levels, err := p.server.loaders(ctx).stockLevels.Load(ctx, obj.ID)
+	if err != nil || len(levels) == 0 {
+		return nil, err
+	}
+
+	available := 0
+	for _, l := range levels {
+		available += int(l.Available())
+	}
+	return &available, nil
+}
+
+// AvailableQuantity implements ProductVariantResolver.
+// The stock of the product is loaded once for all of its variants.
+func (v *productVariantResolver) AvailableQuantity(ctx context.Context, obj *ProductVariant) (*int, error) {
+	levels, err := v.server.loaders(ctx).stockLevels.Load(ctx, obj.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range levels {
+		if l.VariantID == obj.ID {
+			available := int(l.Available())
+			return &available, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetStock implements MutationResolver.
+func (m *mutationResolver) SetStock(ctx context.Context, productID string, variantID *string, onHand int) (*Product, error) {
+	if onHand < 0 || onHand > math.MaxInt32 {
+		return nil, catalog.ErrInvalidStockLevel
+	}
+	return m.changeStock(ctx, productID, variantID, func(productID, variantID string) error {
+		_, err := m.server.catalogClient.SetStock(ctx, productID, variantID, uint32(onHand))
+		return err
+	})
+}
+
+// AdjustStock implements MutationResolver.
+func (m *mutationResolver) AdjustStock(ctx context.Context, productID string, variantID *string, delta int) (*Product, error) {
+	if delta < math.MinInt32 || delta > math.MaxInt32 {
+		return nil, catalog.ErrInvalidStockLevel
+	}
+	return m.changeStock(ctx, productID, variantID, func(productID, variantID string) error {
+		_, err := m.server.catalogClient.AdjustStock(ctx, productID, variantID, int32(delta))
+		return err
+	})
+}
+
+// changeStock resolves the IDs of a stock mutation, applies it and returns
+// the product, whose availableQuantity then reflects the change.
+func (m *mutationResolver) changeStock(ctx context.Context, productID string, variantID *string, apply func(productID, variantID string) error) (*Product, error) {
+	productID, err := localID(nodeProduct, productID)
+	if err != nil {
+		return nil, err
+	}
+	var variant string
+	if variantID != nil {
+		variant = *variantID
+	}
+
+	if err := apply(productID, variant); err != nil {
+		return nil, err
+	}
+	return m.server.loaders(ctx).products.Load(ctx, productID)
+}
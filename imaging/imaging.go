@@ -0,0 +1,117 @@
+// Package imaging inspects uploaded images and scales them down to
+// thumbnails. JPEG, PNG, GIF and WebP images are read; thumbnails are
+// written as JPEG, or as PNG when the image has transparency.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("image must be a JPEG, PNG, GIF or WebP file")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// MaxPixels bounds width × height of the images decoded, so that a small
+// file declaring huge dimensions cannot exhaust memory.
+const MaxPixels = 50_000_000
+
+// thumbnailQuality is the JPEG quality of thumbnails.
+const thumbnailQuality = 85
+
+// Info describes an encoded image.
+type Info struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// formats maps the format names of the image package to content types and
+// file extensions.
+var formats = map[string]Info{
+	"jpeg": {ContentType: "image/jpeg", Extension: ".jpg"},
+	"png":  {ContentType: "image/png", Extension: ".png"},
+	"gif":  {ContentType: "image/gif", Extension: ".gif"},
+	"webp": {ContentType: "image/webp", Extension: ".webp"},
+}
+
+// Inspect reads the format and dimensions of data from its header, without
+// decoding the pixels.
+func Inspect(data []byte) (Info, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, ErrUnsupportedFormat
+	}
+	info, ok := formats[format]
+	if !ok {
+		return Info{}, ErrUnsupportedFormat
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return Info{}, ErrUnsupportedFormat
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return Info{}, ErrTooManyPixels
+	}
+	info.Width, info.Height = cfg.Width, cfg.Height
+	return info, nil
+}
+
+// Decode decodes data after checking its dimensions with Inspect. GIFs
+// decode to their first frame.
+func Decode(data []byte) (image.Image, error) {
+	if _, err := Inspect(data); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	return img, nil
+}
+
+// Fit returns the dimensions of a width × height image scaled down to fit
+// within a size × size square, keeping its aspect ratio. Images that
+// already fit keep their dimensions.
+func Fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, (height*size+width/2)/width)
+	}
+	return max(1, (width*size+height/2)/height), size
+}
+
+// Thumbnail scales img down to fit within a size × size square and
+// encodes it.
+func Thumbnail(img image.Image, size int) ([]byte, Info, error) {
+	b := img.Bounds()
+	width, height := Fit(b.Dx(), b.Dy(), size)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	var buf bytes.Buffer
+	info := formats["jpeg"]
+	var err error
+	if dst.Opaque() {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality})
+	} else {
+		info = formats["png"]
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	info.Width, info.Height = width, height
+	return buf.Bytes(), info, nil
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Namespace prefixes every metric exported by ProtoGraph services,
+// e.g. protograph_grpc_server_handling_seconds.
+const Namespace = "protograph"
+
+var (
+	// grpcHandled counts finished RPCs by method and gRPC status code.
+	grpcHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_server",
+		Name:      "handled_total",
+		Help:      "Total number of RPCs completed on the server, regardless of success or failure.",
+	}, []string{"service", "method", "code"})
+
+	// grpcHandlingSeconds observes how long each RPC took to complete.
+	grpcHandlingSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "grpc_server",
+		Name:      "handling_seconds",
+		Help:      "Latency of RPCs handled by the server.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "method", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(grpcHandled, grpcHandlingSeconds)
+}
+
+// UnaryServerInterceptor records latency and status code for every unary RPC.
+// Pass it to grpc.NewServer through grpc.ChainUnaryInterceptor.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records latency and status code for every streaming RPC.
+// The latency covers the whole lifetime of the stream.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+// observe splits "/pb.AccountService/GetAccount" into service and method
+// and updates both the counter and the histogram.
+func observe(fullMethod string, err error, elapsed time.Duration) {
+	service, method := SplitMethodName(fullMethod)
+	code := status.Code(err).String()
+
+	grpcHandled.WithLabelValues(service, method, code).Inc()
+	grpcHandlingSeconds.WithLabelValues(service, method, code).Observe(elapsed.Seconds())
+}
+
+// SplitMethodName turns a gRPC full method name ("/pb.AccountService/GetAccount")
+// into its service ("pb.AccountService") and method ("GetAccount") parts.
+func SplitMethodName(fullMethod string) (string, string) {
+	if len(fullMethod) > 0 && fullMethod[0] == '/' {
+		fullMethod = fullMethod[1:]
+	}
+	for i := len(fullMethod) - 1; i >= 0; i-- {
+		if fullMethod[i] == '/' {
+			return fullMethod[:i], fullMethod[i+1:]
+		}
+	}
+	return "unknown", fullMethod
+}
+
+// RegisterDBStats exports the connection pool statistics of db
+// (open, idle, in-use connections, wait counts, ...) labelled with dbName.
+// Registering the same dbName twice is a no-op.
+func RegisterDBStats(dbName string, db *sql.DB) error {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, dbName))
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+	return err
+}
+
+// Handler returns the HTTP handler that serves every registered metric
+// in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ListenAndServe exposes /metrics on the given port.
+// It blocks, so services usually run it in its own goroutine.
+func ListenAndServe(port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+}
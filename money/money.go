@@ -0,0 +1,281 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrency  = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrInvalidAmount    = errors.New("amount must be a decimal number with at most the fractional digits of its currency")
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrInvalidRate      = errors.New("exchange rate must be a positive decimal number")
+)
+
+// RateDigits is the number of fractional digits exchange rates are kept
+// to, e.g. by CrossRate.
+const RateDigits = 12
+
+// Money is an exact amount of one currency. Amount counts the minor units
+// of the currency (cents for USD, yen for JPY, fils for KWD), so sums and
+// products never pick up the rounding errors of floating point.
+//
+// The zero value has no currency and is only useful as "not set".
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// minorDigits lists the ISO 4217 currencies whose minor unit is not a
+// hundredth; every other currency has two fractional digits.
+var minorDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// New returns amount minor units of currency.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns nothing of currency, the start of a sum.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code: three
+// uppercase ASCII letters.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeCurrency trims and uppercases a currency code given by a user.
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Digits is the number of fractional digits of currency, e.g. 2 for USD
+// and 0 for JPY.
+func Digits(currency string) int {
+	if d, ok := minorDigits[currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// Parse reads a decimal amount such as "19.99" or "-5" of currency. It
+// rejects more fractional digits than the currency has rather than
+// rounding them away.
+func Parse(amount, currency string) (Money, error) {
+	currency = NormalizeCurrency(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	digits := Digits(currency)
+	if (whole == "" && frac == "") || len(frac) > digits || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", digits-len(frac)), 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, ErrOverflow
+		}
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units with the fractional digits of
+// the currency, e.g. "19.99" or "-0.50".
+func (m Money) Decimal() string {
+	digits := Digits(m.Currency)
+	s := strconv.FormatInt(m.Amount, 10)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// String formats m as its decimal amount and currency, e.g. "19.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether m is the zero value.
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// IsNegative reports whether m is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o; both must be of the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o; both must be of the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Mul returns m times n, e.g. the price of a line of n items.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	product := m.Amount * n
+	if product/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Convert returns m in currency at rate units of currency per unit of the
+// currency of m, rounding half away from zero to the minor unit of
+// currency. rate is a decimal string such as "0.9172" (see ParseRate) so
+// that the conversion is exact and can be repeated from a record of it.
+func (m Money) Convert(currency, rate string) (Money, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return Money{}, err
+	}
+
+	// minor units of m * rate * 10^(digits of currency - digits of m)
+	v := new(big.Rat).SetInt64(m.Amount)
+	v.Mul(v, r)
+	shift := Digits(currency) - Digits(m.Currency)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		v.Mul(v, scale)
+	} else {
+		v.Quo(v, scale)
+	}
+
+	// Round half away from zero: truncate |v| + 1/2
+	neg := v.Sign() < 0
+	v.Abs(v).Add(v, big.NewRat(1, 2))
+	q := new(big.Int).Quo(v.Num(), v.Denom())
+	if neg {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: q.Int64(), Currency: currency}, nil
+}
+
+// ParseRate reads an exchange rate written as a positive decimal number,
+// e.g. "0.9172" or "151.2". Fractions and exponents are rejected so that
+// rates read the same everywhere they are recorded.
+func ParseRate(rate string) (*big.Rat, error) {
+	whole, frac, _ := strings.Cut(strings.TrimSpace(rate), ".")
+	if (whole == "" && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return nil, ErrInvalidRate
+	}
+	r, ok := new(big.Rat).SetString(whole + "." + frac + "0")
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return r, nil
+}
+
+// FormatRate writes r as a decimal with at most RateDigits fractional
+// digits and no trailing zeros.
+func FormatRate(r *big.Rat) string {
+	s := r.FloatString(RateDigits)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// CrossRate returns the rate converting from one currency to another,
+// given the rate of each against a common base currency (units of the
+// currency per unit of the base), rounded to RateDigits fractional digits.
+func CrossRate(from, to string) (string, error) {
+	f, err := ParseRate(from)
+	if err != nil {
+		return "", err
+	}
+	t, err := ParseRate(to)
+	if err != nil {
+		return "", err
+	}
+	cross := new(big.Rat).Quo(t, f)
+	if cross.Sign() <= 0 || FormatRate(cross) == "0" {
+		return "", ErrInvalidRate
+	}
+	return FormatRate(cross), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Sum adds amounts, all of which must be of currency. The sum of no
+// amounts is zero of currency.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, a := range amounts {
+		var err error
+		if total, err = total.Add(a); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
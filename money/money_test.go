@@ -0,0 +1,188 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             Money
+		err              error
+	}{
+		{"19.99", "USD", New(1999, "USD"), nil},
+		{" 19.9 ", "usd", New(1990, "USD"), nil},
+		{"-0.50", "EUR", New(-50, "EUR"), nil},
+		{"+5", "EUR", New(500, "EUR"), nil},
+		{".5", "EUR", New(50, "EUR"), nil},
+		{"1.", "EUR", New(100, "EUR"), nil},
+		{"1500", "JPY", New(1500, "JPY"), nil},
+		{"1.234", "KWD", New(1234, "KWD"), nil},
+		{"0", "USD", New(0, "USD"), nil},
+
+		{"19.999", "USD", Money{}, ErrInvalidAmount},
+		{"1.5", "JPY", Money{}, ErrInvalidAmount},
+		{"", "USD", Money{}, ErrInvalidAmount},
+		{"-", "USD", Money{}, ErrInvalidAmount},
+		{".", "USD", Money{}, ErrInvalidAmount},
+		{"1e3", "USD", Money{}, ErrInvalidAmount},
+		{"1,50", "USD", Money{}, ErrInvalidAmount},
+		{"--1", "USD", Money{}, ErrInvalidAmount},
+		{"1.5", "US", Money{}, ErrInvalidCurrency},
+		{"1.5", "US1", Money{}, ErrInvalidCurrency},
+		{"92233720368547758.08", "USD", Money{}, ErrOverflow},
+		{"9223372036854775808", "JPY", Money{}, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			got, err := Parse(tt.amount, tt.currency)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse(%q, %q) error = %v, want %v", tt.amount, tt.currency, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(1999, "USD"), "19.99"},
+		{New(5, "USD"), "0.05"},
+		{New(-50, "USD"), "-0.50"},
+		{New(0, "USD"), "0.00"},
+		{New(1500, "JPY"), "1500"},
+		{New(-7, "JPY"), "-7"},
+		{New(1, "KWD"), "0.001"},
+		{New(12345, "CLF"), "1.2345"},
+		{New(math.MaxInt64, "USD"), "92233720368547758.07"},
+		{New(math.MinInt64, "USD"), "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.m.Currency, func(t *testing.T) {
+			if got := tt.m.Decimal(); got != tt.want {
+				t.Errorf("%#v.Decimal() = %q, want %q", tt.m, got, tt.want)
+			}
+		})
+	}
+}
+
+// Every amount Decimal writes must Parse back to the same Money.
+func TestDecimalParseRoundTrip(t *testing.T) {
+	amounts := []int64{0, 1, -1, 5, 99, 100, -150, 1999, 123456789, math.MaxInt64, math.MinInt64 + 1}
+	currencies := []string{"USD", "JPY", "KWD", "CLF"}
+
+	for _, currency := range currencies {
+		for _, amount := range amounts {
+			m := New(amount, currency)
+			got, err := Parse(m.Decimal(), currency)
+			if err != nil {
+				t.Errorf("Parse(%q, %q) error = %v", m.Decimal(), currency, err)
+				continue
+			}
+			if got != m {
+				t.Errorf("Parse(%q, %q) = %v, want %v", m.Decimal(), currency, got, m)
+			}
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want Money
+		err  error
+	}{
+		{"sum", New(150, "USD"), New(250, "USD"), New(400, "USD"), nil},
+		{"negative", New(150, "USD"), New(-250, "USD"), New(-100, "USD"), nil},
+		{"to max", New(math.MaxInt64-1, "USD"), New(1, "USD"), New(math.MaxInt64, "USD"), nil},
+		{"to min", New(math.MinInt64+1, "USD"), New(-1, "USD"), New(math.MinInt64, "USD"), nil},
+		{"above max", New(math.MaxInt64, "USD"), New(1, "USD"), Money{}, ErrOverflow},
+		{"below min", New(math.MinInt64, "USD"), New(-1, "USD"), Money{}, ErrOverflow},
+		{"currencies", New(1, "USD"), New(1, "EUR"), Money{}, ErrCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("%v.Add(%v) error = %v, want %v", tt.a, tt.b, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("%v.Add(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Money
+		n    int64
+		want Money
+		err  error
+	}{
+		{"line", New(1999, "USD"), 3, New(5997, "USD"), nil},
+		{"zero items", New(1999, "USD"), 0, New(0, "USD"), nil},
+		{"free", New(0, "USD"), math.MaxInt64, New(0, "USD"), nil},
+		{"negative", New(-5, "USD"), 4, New(-20, "USD"), nil},
+		{"max", New(math.MaxInt64, "USD"), 1, New(math.MaxInt64, "USD"), nil},
+		{"above max", New(math.MaxInt64/2+1, "USD"), 2, Money{}, ErrOverflow},
+		{"large", New(1<<32, "USD"), 1 << 32, Money{}, ErrOverflow},
+		{"min times -1", New(math.MinInt64, "USD"), -1, Money{}, ErrOverflow},
+		{"-1 times min", New(-1, "USD"), math.MinInt64, Money{}, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Mul(tt.n)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("%v.Mul(%d) error = %v, want %v", tt.m, tt.n, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("%v.Mul(%d) = %v, want %v", tt.m, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		currency string
+		rate     string
+		want     Money
+		err      error
+	}{
+		{"rounds half up", New(1999, "USD"), "EUR", "0.9172", New(1833, "EUR"), nil},
+		{"rounds half away from zero", New(-1, "USD"), "EUR", "0.5", New(-1, "EUR"), nil},
+		{"to fewer digits", New(1999, "USD"), "JPY", "151.2", New(3022, "JPY"), nil},
+		{"to more digits", New(1500, "JPY"), "KWD", "0.002", New(3000, "KWD"), nil},
+		{"invalid rate", New(1, "USD"), "EUR", "-1", Money{}, ErrInvalidRate},
+		{"zero rate", New(1, "USD"), "EUR", "0", Money{}, ErrInvalidRate},
+		{"overflow", New(math.MaxInt64, "USD"), "EUR", "2", Money{}, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Convert(tt.currency, tt.rate)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("%v.Convert(%q, %q) error = %v, want %v", tt.m, tt.currency, tt.rate, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("%v.Convert(%q, %q) = %v, want %v", tt.m, tt.currency, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/order/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// ErrNotServing is returned by Health when the service answers but reports
+// it is not ready to serve.
+var ErrNotServing = errors.New("service is not serving")
+
+type Client struct {
+	conn    *grpc.ClientConn
+	service pb.OrderServiceClient
+}
+
+// NewClient creates a new gRPC client for the Order service
+// url: the server address in the format "host:port"
+// Example usage:
+// client, err := order.NewClient("localhost:8080")
+func NewClient(url string) (*Client, error) {
+	conn, err := grpc.NewClient(url,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		tracing.DialOption(),
+	)
+	if err != nil {
+		return nil, errors.New("failed to connect to server: " + err.Error())
+	}
+
+	return &Client{
+		conn:    conn,
+		service: pb.NewOrderServiceClient(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Health asks the gRPC health service of the order service whether it is
+// serving, for the gateway's /readyz.
+func (c *Client) Health(ctx context.Context) error {
+	res, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: pb.OrderService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return ErrNotServing
+	}
+	return nil
+}
+
+// PostOrder places an order paid in currency, or in the currency of the
+// products when it is empty; only ID, SKU and Quantity of each product are
+// sent, the order service prices them from the catalog.
+func (c *Client) PostOrder(ctx context.Context, accountID string, products []OrderedProduct, currency string) (*Order, error) {
+	req := &pb.PostOrderRequest{AccountId: accountID, Currency: currency}
+	for _, p := range products {
+		req.Products = append(req.Products, &pb.PostOrderRequest_OrderProduct{
+			ProductId: p.ID,
+			Quantity:  p.Quantity,
+			Sku:       p.SKU,
+		})
+	}
+
+	res, err := c.service.PostOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Order), nil
+}
+
+func (c *Client) GetOrder(ctx context.Context, id string) (*Order, error) {
+	res, err := c.service.GetOrder(ctx, &pb.GetOrderRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Order), nil
+}
+
+func (c *Client) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
+	res, err := c.service.GetOrdersForAccount(ctx, &pb.GetOrdersForAccountRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []Order{}
+	for _, o := range res.Orders {
+		orders = append(orders, *fromProto(o))
+	}
+	return orders, nil
+}
+
+// ListOrdersByAccountIDs fetches the orders of any number of accounts keyed
+// by account ID, in batches of MaxBatchSize. Every account gets an entry,
+// empty when it has no orders.
+func (c *Client) ListOrdersByAccountIDs(ctx context.Context, accountIDs []string) (map[string][]Order, error) {
+	byAccount := map[string][]Order{}
+
+	accountIDs = uniqueIDs(accountIDs)
+	for start := 0; start < len(accountIDs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(accountIDs))
+
+		res, err := c.service.ListOrdersByAccountIDs(ctx, &pb.ListOrdersByAccountIDsRequest{AccountIds: accountIDs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for accountID, list := range res.Orders {
+			orders := []Order{}
+			for _, o := range list.Orders {
+				orders = append(orders, *fromProto(o))
+			}
+			byAccount[accountID] = orders
+		}
+	}
+
+	return byAccount, nil
+}
+
+// UpdateOrderStatus moves an order to status.
+func (c *Client) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	res, err := c.service.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{
+		Id:     id,
+		Status: statusToProto(status),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fromProto(res.Order), nil
+}
+
+// WatchOrderStatus streams the orders of an account as they are placed or
+// change status. The channel is closed when ctx is done or the stream ends,
+// e.g. because the service dropped a watcher that fell behind.
+func (c *Client) WatchOrderStatus(ctx context.Context, accountID string) (<-chan Order, error) {
+	stream, err := c.service.WatchOrderStatus(ctx, &pb.WatchOrderStatusRequest{AccountId: accountID})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make(chan Order)
+	go func() {
+		defer close(orders)
+		for {
+			o, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case orders <- *fromProto(o):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return orders, nil
+}
+
+func (c *Client) DeleteOrder(ctx context.Context, id string) error {
+	_, err := c.service.DeleteOrder(ctx, &pb.DeleteOrderRequest{Id: id})
+	return err
+}
+
+// HasPurchased reports whether the account has an order of the product
+// that was not cancelled.
+func (c *Client) HasPurchased(ctx context.Context, accountID, productID string) (bool, error) {
+	resp, err := c.service.HasPurchased(ctx, &pb.HasPurchasedRequest{
+		AccountId: accountID,
+		ProductId: productID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Purchased, nil
+}
+
+// fromProto maps a gRPC order to the internal representation
+func fromProto(o *pb.Order) *Order {
+	createdAt, _ := time.Parse(time.RFC3339, o.CreatedAt)
+
+	order := &Order{
+		ID:         o.Id,
+		CreatedAt:  createdAt,
+		AccountID:  o.AccountId,
+		TotalPrice: moneyFromProto(o.TotalPrice),
+		Status:     statusFromProto(o.Status),
+		Products:   []OrderedProduct{},
+	}
+	for _, p := range o.Products {
+		order.Products = append(order.Products, OrderedProduct{
+			ID:            p.Id,
+			Name:          p.Name,
+			Description:   p.Description,
+			Price:         moneyFromProto(p.Price),
+			Quantity:      p.Quantity,
+			VariantID:     p.VariantId,
+			SKU:           p.Sku,
+			Options:       p.Options,
+			ConvertedFrom: optionalMoneyFromProto(p.ConvertedFrom),
+			ExchangeRate:  p.ExchangeRate,
+		})
+	}
+	return order
+}
+
+// moneyFromProto maps a gRPC amount to the internal representation
+func moneyFromProto(m *pb.Money) money.Money {
+	return money.Money{Amount: m.GetAmount(), Currency: m.GetCurrency()}
+}
+
+func optionalMoneyFromProto(m *pb.Money) *money.Money {
+	if m == nil {
+		return nil
+	}
+	out := moneyFromProto(m)
+	return &out
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/avast/retry-go/v4"
+
+	"github.com/olujimiAdebakin/ProtoGraph/config"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+	"github.com/olujimiAdebakin/ProtoGraph/postgres"
+	"github.com/olujimiAdebakin/ProtoGraph/ratelimit"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+type Config struct {
+	Port        int    `envconfig:"PORT" default:"8080" validate:"port"`
+	DatabaseURL string `envconfig:"DATABASE_URL" validate:"required,url" secret:"true"`
+	CatalogURL  string `envconfig:"CATALOG_SERVICE_URL" validate:"required,hostport"`
+	MetricsPort int    `envconfig:"METRICS_PORT" default:"9090" validate:"port"`
+
+	// Connection pool and query timeouts, see DB_* variables
+	DB postgres.Config `envconfig:"DB"`
+
+	// Tracing is disabled unless TRACING_EXPORTER is set
+	Tracing tracing.Config `envconfig:"TRACING"`
+
+	// Per-caller token buckets, see RATE_LIMIT_* variables
+	RateLimit ratelimit.Config `envconfig:"RATE_LIMIT"`
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.Port == c.MetricsPort {
+		return errors.New("PORT and METRICS_PORT must differ")
+	}
+	return nil
+}
+
+func main() {
+	// Defaults < config file < environment < flags, validated before anything starts
+	var cfg Config
+	config.MustLoad("order", &cfg)
+
+	shutdownTracing, err := tracing.Init(context.Background(), "order", cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdownTracing(context.Background())
+
+	var r order.Repository
+
+	err = retry.Do(
+		func() error {
+			var err error
+			r, err = order.NewPostgresRepositry(cfg.DatabaseURL, cfg.DB)
+			if err != nil {
+				log.Printf("failed to connect to database: %v", err)
+			}
+			return err
+		},
+		retry.Attempts(5),
+		retry.Delay(2*time.Second),
+		retry.OnRetry(func(n uint, err error) {
+			log.Printf("Retry attempt %d: %v", n, err)
+		}),
+	)
+
+	if err != nil {
+		log.Fatal("Failed to connect after retries: ", err)
+	}
+
+	defer r.Close()
+
+	// Serve Prometheus metrics next to the gRPC API
+	go func() {
+		log.Fatal(metrics.ListenAndServe(cfg.MetricsPort))
+	}()
+
+	limiter, err := ratelimit.New(cfg.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limits := ratelimit.ServerOptions(limiter, cfg.RateLimit.TrustForwardedFor)
+
+	log.Printf("Listening on port %d......", cfg.Port)
+	s := order.NewService(r)
+	log.Fatal(order.ListenGRPCServer(s, cfg.CatalogURL, cfg.Port, limits...))
+}
@@ -1,15 +1,288 @@
 package order
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"time"
 
+	"github.com/lib/pq"
+
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/postgres"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// Order repository interface
 type Repository interface {
-    Close()
-    PutOrder(ctx context.Context, o Order) error
-    GetOrderByID(ctx context.Context, id string) (*Order, error)
-    ListOrders(ctx context.Context, skip uint64, take uint64) ([]Order, error)
-    DeleteOrder(ctx context.Context, id string) error
+	Close()
+
+	// Create an order together with its ordered products
+	PutOrder(ctx context.Context, o Order) error
+
+	// Fetch one order by ID
+	GetOrderByID(ctx context.Context, id string) (*Order, error)
+
+	// Fetch every order placed by an account, newest first
+	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+
+	// Fetch every order placed by any of the accounts, newest first
+	GetOrdersForAccounts(ctx context.Context, accountIDs []string) ([]Order, error)
+
+	// Change the status of an order if it is currently in one of from, and
+	// report whether it was changed
+	UpdateOrderStatus(ctx context.Context, id string, status OrderStatus, from []OrderStatus) (bool, error)
+
+	// List orders with pagination (skip = offset, take = limit)
+	ListOrders(ctx context.Context, skip uint64, take uint64) ([]Order, error)
+
+	// Delete order by ID
+	DeleteOrder(ctx context.Context, id string) error
+
+	// Report whether an account has an order of a product that was not cancelled
+	HasPurchased(ctx context.Context, accountID, productID string) (bool, error)
+}
+
+// postgresRepositry implements the Repository interface.
+// It provides all DB operations for orders using PostgreSQL.
+type postgresRepositry struct {
+	db      *sql.DB
+	timeout time.Duration
+
+	// Prepared once, used by every request
+	getOrderByID        *sql.Stmt
+	getOrdersForAccount *sql.Stmt
+}
+
+// Hot queries: the gateway resolves orders by ID and for every account it returns.
+var (
+	getOrderByIDQuery        = ordersQuery("WHERE o.id = $1")
+	getOrdersForAccountQuery = ordersQuery("WHERE o.account_id = $1")
+)
+
+// NewPostgresRepositry connects to PostgreSQL with the pool settings of cfg
+// and returns a repository instance.
+func NewPostgresRepositry(url string, cfg postgres.Config) (Repository, error) {
+	db, err := postgres.Open("order", url, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &postgresRepositry{db: db, timeout: cfg.QueryTimeout}
+
+	ctx, cancel := postgres.WithTimeout(context.Background(), cfg.QueryTimeout)
+	defer cancel()
+	err = postgres.Prepare(ctx, db, map[string]**sql.Stmt{
+		getOrderByIDQuery:        &r.getOrderByID,
+		getOrdersForAccountQuery: &r.getOrdersForAccount,
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *postgresRepositry) Close() {
+	r.getOrderByID.Close()
+	r.getOrdersForAccount.Close()
+	r.db.Close()
+}
+
+func (r *postgresRepositry) Ping() error {
+	return r.db.Ping()
+}
+
+// PutOrder inserts the order and its products in a single transaction.
+func (r *postgresRepositry) PutOrder(ctx context.Context, o Order) (err error) {
+	const (
+		insertOrder   = "INSERT INTO orders (id, created_at, account_id, total_price, currency, status, reservation_id) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))"
+		insertProduct = "INSERT INTO order_products (order_id, product_id, quantity, variant_id, sku, price, base_price, base_currency, exchange_rate) " +
+			"VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, NULLIF($9, '')::numeric)"
+	)
+	ctx, span := tracing.StartDBSpan(ctx, "orders", "PutOrder", insertOrder+"; "+insertProduct)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	_, err = tx.ExecContext(ctx, insertOrder, o.ID, o.CreatedAt, o.AccountID, o.TotalPrice.Amount, o.TotalPrice.Currency, o.Status, o.ReservationID)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range o.Products {
+		var basePrice, baseCurrency interface{}
+		if p.ConvertedFrom != nil {
+			basePrice, baseCurrency = p.ConvertedFrom.Amount, p.ConvertedFrom.Currency
+		}
+		_, err = tx.ExecContext(ctx, insertProduct, o.ID, p.ID, p.Quantity, p.VariantID, p.SKU, p.Price.Amount, basePrice, baseCurrency, p.ExchangeRate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetOrderByID fetches a single order by ID.
+// It returns (nil, nil) if no row exists.
+func (r *postgresRepositry) GetOrderByID(ctx context.Context, id string) (*Order, error) {
+	orders, err := r.queryOrders(ctx, "GetOrderByID", getOrderByIDQuery, r.getOrderByID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+	return &orders[0], nil
 }
 
-type postgresRepositry struct{
-	db *spl.DB
-}
\ No newline at end of file
+// GetOrdersForAccount fetches every order placed by the given account.
+func (r *postgresRepositry) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
+	return r.queryOrders(ctx, "GetOrdersForAccount", getOrdersForAccountQuery, r.getOrdersForAccount, accountID)
+}
+
+// GetOrdersForAccounts fetches the orders of a batch of accounts in one round-trip.
+func (r *postgresRepositry) GetOrdersForAccounts(ctx context.Context, accountIDs []string) ([]Order, error) {
+	return r.queryOrders(ctx, "GetOrdersForAccounts", ordersQuery("WHERE o.account_id = ANY($1)"), nil, pq.Array(accountIDs))
+}
+
+// ListOrders returns paginated orders using LIMIT + OFFSET.
+func (r *postgresRepositry) ListOrders(ctx context.Context, skip uint64, take uint64) ([]Order, error) {
+	return r.queryOrders(ctx, "ListOrders", ordersQuery("WHERE o.id IN (SELECT id FROM orders ORDER BY created_at DESC, id OFFSET $1 LIMIT $2)"), nil, skip, take)
+}
+
+// UpdateOrderStatus sets the status of an order in a single conditional
+// UPDATE, so the check of the current status and the change cannot be
+// interleaved with another update.
+func (r *postgresRepositry) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus, from []OrderStatus) (_ bool, err error) {
+	const query = "UPDATE orders SET status = $2 WHERE id = $1 AND status = ANY($3)"
+	ctx, span := tracing.StartDBSpan(ctx, "orders", "UpdateOrderStatus", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	allowed := make([]string, len(from))
+	for i, s := range from {
+		allowed[i] = string(s)
+	}
+
+	res, err := r.db.ExecContext(ctx, query, id, status, pq.Array(allowed))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteOrder removes an order by ID. Its products go with it (ON DELETE CASCADE).
+func (r *postgresRepositry) DeleteOrder(ctx context.Context, id string) (err error) {
+	const query = "DELETE FROM orders WHERE id = $1"
+	ctx, span := tracing.StartDBSpan(ctx, "orders", "DeleteOrder", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// HasPurchased looks for a line of the product in the orders of the
+// account, of any variant.
+func (r *postgresRepositry) HasPurchased(ctx context.Context, accountID, productID string) (_ bool, err error) {
+	const query = `SELECT EXISTS (
+		SELECT 1 FROM orders o JOIN order_products op ON o.id = op.order_id
+		WHERE o.account_id = $1 AND op.product_id = $2 AND o.status <> $3
+	)`
+	ctx, span := tracing.StartDBSpan(ctx, "orders", "HasPurchased", query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var purchased bool
+	err = r.db.QueryRowContext(ctx, query, accountID, productID, StatusCancelled).Scan(&purchased)
+	return purchased, err
+}
+
+// ordersQuery joins orders with their products, filtered by where. Rows come
+// back ordered so that products of the same order are adjacent.
+func ordersQuery(where string) string {
+	return "SELECT o.id, o.created_at, o.account_id, o.total_price, o.currency, o.status, COALESCE(o.reservation_id, ''), op.product_id, op.quantity, COALESCE(op.variant_id, ''), COALESCE(op.sku, ''), " +
+		"op.price, op.base_price, COALESCE(op.base_currency, ''), COALESCE(op.exchange_rate::text, '') " +
+		"FROM orders o JOIN order_products op ON o.id = op.order_id " +
+		where + " ORDER BY o.created_at DESC, o.id"
+}
+
+// queryOrders runs an ordersQuery and folds the rows back into one Order
+// per ID. stmt is the prepared form of query, or nil to run query directly.
+// operation names the tracing span.
+func (r *postgresRepositry) queryOrders(ctx context.Context, operation, query string, stmt *sql.Stmt, args ...interface{}) (_ []Order, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "orders", operation, query)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx, cancel := postgres.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var rows *sql.Rows
+	if stmt != nil {
+		rows, err = stmt.QueryContext(ctx, args...)
+	} else {
+		rows, err = r.db.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+
+	for rows.Next() {
+		o := Order{}
+		p := OrderedProduct{}
+		var price, basePrice sql.NullInt64
+		var baseCurrency string
+		if err := rows.Scan(&o.ID, &o.CreatedAt, &o.AccountID, &o.TotalPrice.Amount, &o.TotalPrice.Currency, &o.Status, &o.ReservationID, &p.ID, &p.Quantity, &p.VariantID, &p.SKU,
+			&price, &basePrice, &baseCurrency, &p.ExchangeRate); err != nil {
+			return nil, err
+		}
+
+		// Lines stored before prices were recorded are left without one
+		if price.Valid {
+			p.Price = money.New(price.Int64, o.TotalPrice.Currency)
+		}
+		if basePrice.Valid {
+			p.ConvertedFrom = &money.Money{Amount: basePrice.Int64, Currency: baseCurrency}
+		}
+
+		// Same order as the previous row: just collect the product
+		if n := len(orders); n > 0 && orders[n-1].ID == o.ID {
+			orders[n-1].Products = append(orders[n-1].Products, p)
+			continue
+		}
+
+		o.Products = []OrderedProduct{p}
+		orders = append(orders, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
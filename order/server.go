@@ -0,0 +1,531 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/metrics"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/order/pb"
+	"github.com/olujimiAdebakin/ProtoGraph/tracing"
+)
+
+// grpcServer wraps the business logic service and implements gRPC methods.
+// It talks to the catalog service to price and describe ordered products.
+type grpcServer struct {
+	pb.UnimplementedOrderServiceServer
+	service       Service
+	catalogClient *catalog.Client
+}
+
+// ListenGRPCServer starts a gRPC server on the specified port
+// catalogURL: address of the catalog service used to look up products
+// opts: extra server options (e.g. rate limiting) appended after the built-in ones
+func ListenGRPCServer(service Service, catalogURL string, port int, opts ...grpc.ServerOption) error {
+	catalogClient, err := catalog.NewClient(catalogURL)
+	if err != nil {
+		return err
+	}
+	defer catalogClient.Close()
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+
+	// Record RPC latency and status codes for every call and continue
+	// the caller's trace
+	grpcSrv := grpc.NewServer(append([]grpc.ServerOption{
+		tracing.ServerOption(),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor()),
+	}, opts...)...)
+
+	pb.RegisterOrderServiceServer(grpcSrv, &grpcServer{
+		service:       service,
+		catalogClient: catalogClient,
+	})
+
+	// Report SERVING on the standard gRPC health service, probed by the
+	// gateway's /readyz and by load balancers
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(pb.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcSrv, healthSrv)
+
+	// Enable gRPC reflection - allows tools like grpcurl to discover API
+	reflection.Register(grpcSrv)
+
+	return grpcSrv.Serve(lis)
+}
+
+// PostOrder handles order creation requests via gRPC.
+// Every product is looked up in the catalog so the order is priced server
+// side, in the requested currency. Lines ordered by SKU are priced and
+// recorded as that variant.
+func (s *grpcServer) PostOrder(ctx context.Context, req *pb.PostOrderRequest) (*pb.PostOrderResponse, error) {
+	// Checked before any stock is reserved, and before lines are merged,
+	// which would hide empty ones
+	switch {
+	case req.AccountId == "":
+		return nil, status.Error(codes.InvalidArgument, ErrMissingAccount.Error())
+	case len(req.Products) == 0:
+		return nil, status.Error(codes.InvalidArgument, ErrEmptyOrder.Error())
+	}
+
+	skus := []string{}
+	for _, p := range req.Products {
+		if p.Quantity == 0 {
+			return nil, status.Error(codes.InvalidArgument, ErrInvalidQuantity.Error())
+		}
+		if p.Sku != "" {
+			skus = append(skus, p.Sku)
+		}
+	}
+	variants, err := s.lookupVariants(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	// The product of every line, from its variant when it has one
+	ids := make([]string, 0, len(req.Products))
+	withoutSKU := []string{}
+	for _, p := range req.Products {
+		productID := p.ProductId
+		if p.Sku != "" {
+			v := variants[catalog.NormalizeSKU(p.Sku)]
+			if productID != "" && productID != v.ProductID {
+				return nil, status.Errorf(codes.InvalidArgument, "SKU %s is not a variant of product %s", p.Sku, productID)
+			}
+			productID = v.ProductID
+		} else {
+			withoutSKU = append(withoutSKU, productID)
+		}
+		if productID == "" {
+			return nil, status.Error(codes.InvalidArgument, "every ordered product needs a product ID or a SKU")
+		}
+		ids = append(ids, productID)
+	}
+
+	catalogProducts, err := s.lookupProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// A product with variants is only sold as one of them
+	productVariants, err := s.catalogClient.GetProductVariants(ctx, withoutSKU)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range withoutSKU {
+		if len(productVariants[id]) > 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "product %s has variants; order one of them by SKU", id)
+		}
+	}
+
+	// An order has one line per product and variant, so lines asking for
+	// the same one again are merged by adding up their quantities
+	products := []OrderedProduct{}
+	items := []catalog.PriceItem{}
+	lineOf := map[catalog.PriceItem]int{}
+	for i, p := range req.Products {
+		cp := catalogProducts[ids[i]]
+		line := OrderedProduct{
+			ID:          cp.ID,
+			Name:        cp.Name,
+			Description: cp.Description,
+			Quantity:    p.Quantity,
+		}
+		if p.Sku != "" {
+			v := variants[catalog.NormalizeSKU(p.Sku)]
+			line.VariantID = v.ID
+			line.SKU = v.SKU
+			line.Options = v.Options
+		}
+		key := catalog.PriceItem{ProductID: line.ID, VariantID: line.VariantID}
+		if j, ok := lineOf[key]; ok {
+			if products[j].Quantity > math.MaxUint32-line.Quantity {
+				return nil, status.Errorf(codes.InvalidArgument, "ordered quantity of product %s is too large", line.ID)
+			}
+			products[j].Quantity += line.Quantity
+			continue
+		}
+		lineOf[key] = len(products)
+		products = append(products, line)
+		items = append(items, catalog.PriceItem{ProductID: line.ID, VariantID: line.VariantID})
+	}
+
+	// The price of every line in the currency of the order, with the rate
+	// of converted ones recorded so the order shows what it was charged
+	prices, err := s.catalogClient.GetPrices(ctx, items, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range prices {
+		products[i].Price = p.Amount
+		if p.Rate != "" {
+			base := p.Base
+			products[i].ConvertedFrom = &base
+			products[i].ExchangeRate = p.Rate
+		}
+	}
+
+	o, err := s.placeOrder(ctx, req.AccountId, products)
+	if errors.Is(err, ErrMissingAccount) || errors.Is(err, ErrEmptyOrder) || errors.Is(err, ErrInvalidQuantity) ||
+		errors.Is(err, ErrMixedCurrencies) || errors.Is(err, money.ErrOverflow) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.PostOrderResponse{Order: toProto(o)}, nil
+}
+
+// reservationTTL bounds how long the stock of an order being placed is
+// held should the order service die between reserving and committing it.
+const reservationTTL = time.Minute
+
+// placeOrder stores the order while holding its stock: the items are
+// reserved in the catalog, the order is stored, then the reservation is
+// committed. An order that cannot be stored releases its stock, and one
+// whose stock cannot be committed is deleted again, so no order exists
+// without its stock and no stock is lost without an order. Watchers only
+// hear of the order once its stock is committed.
+func (s *grpcServer) placeOrder(ctx context.Context, accountID string, products []OrderedProduct) (*Order, error) {
+	items := make([]catalog.StockItem, 0, len(products))
+	for _, p := range products {
+		items = append(items, catalog.StockItem{
+			ProductID: p.ID,
+			VariantID: p.VariantID,
+			Quantity:  p.Quantity,
+		})
+	}
+
+	reservation, err := s.catalogClient.ReserveStock(ctx, items, reservationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Undo with a context that outlives a cancelled request
+	cleanup := context.WithoutCancel(ctx)
+
+	o, err := s.service.PostOrder(ctx, accountID, reservation.ID, products)
+	if err != nil {
+		s.catalogClient.ReleaseReservation(cleanup, reservation.ID)
+		return nil, err
+	}
+
+	if _, err := s.catalogClient.CommitReservation(ctx, reservation.ID); err != nil {
+		s.service.DeleteOrder(cleanup, o.ID)
+		s.catalogClient.ReleaseReservation(cleanup, reservation.ID)
+		return nil, err
+	}
+
+	s.service.PublishOrder(*o)
+	return o, nil
+}
+
+// GetOrder handles single order retrieval requests via gRPC
+func (s *grpcServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	o, err := s.service.GetOrder(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.Id)
+	}
+	if err := s.describeProducts(ctx, *o); err != nil {
+		return nil, err
+	}
+
+	return &pb.GetOrderResponse{Order: toProto(o)}, nil
+}
+
+// GetOrdersForAccount handles requests for every order of an account via gRPC
+func (s *grpcServer) GetOrdersForAccount(ctx context.Context, req *pb.GetOrdersForAccountRequest) (*pb.GetOrdersForAccountResponse, error) {
+	orders, err := s.service.GetOrdersForAccount(ctx, req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.describeProducts(ctx, orders...); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetOrdersForAccountResponse{}
+	for i := range orders {
+		resp.Orders = append(resp.Orders, toProto(&orders[i]))
+	}
+	return resp, nil
+}
+
+// ListOrdersByAccountIDs handles batch requests for the orders of many accounts via gRPC.
+// Every requested account gets an entry, empty when it has no orders.
+func (s *grpcServer) ListOrdersByAccountIDs(ctx context.Context, req *pb.ListOrdersByAccountIDsRequest) (*pb.ListOrdersByAccountIDsResponse, error) {
+	byAccount, err := s.service.ListOrdersByAccountIDs(ctx, req.AccountIds)
+	if errors.Is(err, ErrTooManyIDs) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Describe the products of all accounts with a single catalog call
+	all := []Order{}
+	for _, orders := range byAccount {
+		all = append(all, orders...)
+	}
+	if err := s.describeProducts(ctx, all...); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListOrdersByAccountIDsResponse{Orders: map[string]*pb.OrderList{}}
+	for accountID := range byAccount {
+		resp.Orders[accountID] = &pb.OrderList{}
+	}
+	for i := range all {
+		list := resp.Orders[all[i].AccountID]
+		list.Orders = append(list.Orders, toProto(&all[i]))
+	}
+	return resp, nil
+}
+
+// UpdateOrderStatus handles order status changes via gRPC. A cancelled
+// order gives its stock back to the catalog. Returning a reservation twice
+// has no effect, so when that fails cancelling the order again retries it.
+func (s *grpcServer) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
+	o, err := s.service.UpdateOrderStatus(ctx, req.Id, statusFromProto(req.Status))
+	switch {
+	case errors.Is(err, ErrInvalidStatus):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrOrderClosed), errors.Is(err, ErrStatusBackwards):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, err
+	case o == nil:
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.Id)
+	}
+
+	if o.Status == StatusCancelled && o.ReservationID != "" {
+		if _, err := s.catalogClient.ReturnReservation(ctx, o.ReservationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.describeProducts(ctx, *o); err != nil {
+		return nil, err
+	}
+
+	return &pb.UpdateOrderStatusResponse{Order: toProto(o)}, nil
+}
+
+// HasPurchased handles purchase checks via gRPC
+func (s *grpcServer) HasPurchased(ctx context.Context, req *pb.HasPurchasedRequest) (*pb.HasPurchasedResponse, error) {
+	if req.AccountId == "" || req.ProductId == "" {
+		return nil, status.Error(codes.InvalidArgument, "an account ID and a product ID are required")
+	}
+	purchased, err := s.service.HasPurchased(ctx, req.AccountId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.HasPurchasedResponse{Purchased: purchased}, nil
+}
+
+// WatchOrderStatus streams the orders of an account as they are placed or
+// change status, until the client goes away. A client that cannot keep up
+// is disconnected with UNAVAILABLE and should resubscribe.
+func (s *grpcServer) WatchOrderStatus(req *pb.WatchOrderStatusRequest, stream pb.OrderService_WatchOrderStatusServer) error {
+	if req.AccountId == "" {
+		return status.Error(codes.InvalidArgument, ErrMissingAccount.Error())
+	}
+
+	ctx := stream.Context()
+	for o := range s.service.WatchOrderStatus(ctx, req.AccountId) {
+		// Every watcher gets the same event, describe a copy of it
+		o = o.clone()
+		if err := s.describeProducts(ctx, o); err != nil {
+			return err
+		}
+		if err := stream.Send(toProto(&o)); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unavailable, "watcher fell behind, resubscribe")
+}
+
+// DeleteOrder handles order deletion requests via gRPC
+func (s *grpcServer) DeleteOrder(ctx context.Context, req *pb.DeleteOrderRequest) (*pb.DeleteOrderResponse, error) {
+	if err := s.service.DeleteOrder(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteOrderResponse{
+		Success:        true,
+		DeletedOrderId: req.Id,
+	}, nil
+}
+
+// describeProducts fills in name, description and options of every product
+// line of orders from the catalog service, with one batch lookup of the
+// products and one of the variants. Lines stored without a price get the
+// catalog price; those whose variant has since been deleted keep their SKU
+// and get the price of the product. Lines of products deleted from the
+// catalog keep their stored SKU and price and get an empty name, so one
+// deleted product does not make its orders unreadable.
+func (s *grpcServer) describeProducts(ctx context.Context, orders ...Order) error {
+	ids := []string{}
+	variantIDs := []string{}
+	for _, o := range orders {
+		for _, p := range o.Products {
+			ids = append(ids, p.ID)
+			if p.VariantID != "" {
+				variantIDs = append(variantIDs, p.VariantID)
+			}
+		}
+	}
+
+	catalogProducts, _, err := s.catalogClient.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	variants, _, err := s.catalogClient.GetVariantsByIDs(ctx, variantIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orders {
+		for i, p := range o.Products {
+			cp, found := catalogProducts[p.ID]
+			if !found {
+				continue
+			}
+			o.Products[i].Name = cp.Name
+			o.Products[i].Description = cp.Description
+			v, ok := variants[p.VariantID]
+			if ok {
+				o.Products[i].Options = v.Options
+			}
+			if p.Price.Currency == "" {
+				o.Products[i].Price = cp.Price
+				if ok {
+					o.Products[i].Price = v.Price
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lookupProducts fetches the catalog products of ids in batches and fails
+// with NotFound when one of them does not exist.
+func (s *grpcServer) lookupProducts(ctx context.Context, ids []string) (map[string]catalog.Product, error) {
+	products, notFound, err := s.catalogClient.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(notFound) > 0 {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", notFound[0])
+	}
+	return products, nil
+}
+
+// lookupVariants fetches the catalog variants of skus, keyed by normalized
+// SKU, and fails with NotFound when one of them does not exist.
+func (s *grpcServer) lookupVariants(ctx context.Context, skus []string) (map[string]catalog.Variant, error) {
+	variants, notFound, err := s.catalogClient.GetVariantsBySKUs(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+	if len(notFound) > 0 {
+		return nil, status.Errorf(codes.NotFound, "SKU %s not found", notFound[0])
+	}
+	return variants, nil
+}
+
+// toProto maps an internal order to its gRPC representation
+func toProto(o *Order) *pb.Order {
+	op := &pb.Order{
+		Id:         o.ID,
+		CreatedAt:  o.CreatedAt.Format(time.RFC3339),
+		AccountId:  o.AccountID,
+		TotalPrice: moneyToProto(o.TotalPrice),
+		Status:     statusToProto(o.Status),
+	}
+	for _, p := range o.Products {
+		op.Products = append(op.Products, &pb.Order_OrderProduct{
+			Id:            p.ID,
+			Name:          p.Name,
+			Description:   p.Description,
+			Price:         moneyToProto(p.Price),
+			Quantity:      p.Quantity,
+			VariantId:     p.VariantID,
+			Sku:           p.SKU,
+			Options:       p.Options,
+			ConvertedFrom: optionalMoneyToProto(p.ConvertedFrom),
+			ExchangeRate:  p.ExchangeRate,
+		})
+	}
+	return op
+}
+
+// statusToProto maps an internal order status to its gRPC enum
+func statusToProto(s OrderStatus) pb.OrderStatus {
+	switch s {
+	case StatusPlaced:
+		return pb.OrderStatus_ORDER_STATUS_PLACED
+	case StatusPaid:
+		return pb.OrderStatus_ORDER_STATUS_PAID
+	case StatusShipped:
+		return pb.OrderStatus_ORDER_STATUS_SHIPPED
+	case StatusDelivered:
+		return pb.OrderStatus_ORDER_STATUS_DELIVERED
+	case StatusCancelled:
+		return pb.OrderStatus_ORDER_STATUS_CANCELLED
+	}
+	return pb.OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+// statusFromProto maps a gRPC order status to the internal one; unknown
+// values map to "" which the service rejects
+func statusFromProto(s pb.OrderStatus) OrderStatus {
+	switch s {
+	case pb.OrderStatus_ORDER_STATUS_PLACED:
+		return StatusPlaced
+	case pb.OrderStatus_ORDER_STATUS_PAID:
+		return StatusPaid
+	case pb.OrderStatus_ORDER_STATUS_SHIPPED:
+		return StatusShipped
+	case pb.OrderStatus_ORDER_STATUS_DELIVERED:
+		return StatusDelivered
+	case pb.OrderStatus_ORDER_STATUS_CANCELLED:
+		return StatusCancelled
+	}
+	return ""
+}
+
+// moneyToProto maps an amount to its gRPC representation
+func moneyToProto(m money.Money) *pb.Money {
+	return &pb.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+func optionalMoneyToProto(m *money.Money) *pb.Money {
+	if m == nil {
+		return nil
+	}
+	return moneyToProto(*m)
+}
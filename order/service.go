@@ -0,0 +1,324 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/ksuid"
+
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/pubsub"
+)
+
+// Predefined errors for input validation
+var (
+	ErrMissingAccount  = errors.New("order must belong to an account")
+	ErrEmptyOrder      = errors.New("order must contain at least one product")
+	ErrInvalidQuantity = errors.New("ordered quantity must be greater than zero")
+	ErrTooManyIDs      = fmt.Errorf("at most %d IDs can be fetched at once", MaxBatchSize)
+	ErrInvalidStatus   = errors.New("unknown order status")
+	ErrOrderClosed     = errors.New("order is delivered or cancelled and can no longer change")
+	ErrStatusBackwards = errors.New("order status can only move forward")
+	ErrMixedCurrencies = errors.New("all products of an order must be priced in the same currency")
+)
+
+// MaxBatchSize caps how many accounts ListOrdersByAccountIDs serves per call.
+const MaxBatchSize = 100
+
+// Service defines the business operations related to orders.
+type Service interface {
+	// PostOrder creates an order for the account and computes its total
+	// price. reservationID is the catalog reservation holding its stock.
+	// Watchers are not told about it until PublishOrder is called.
+	PostOrder(ctx context.Context, accountID, reservationID string, products []OrderedProduct) (*Order, error)
+
+	// PublishOrder notifies the watchers of the account of o that it was
+	// placed, once nothing can undo the order any more.
+	PublishOrder(o Order)
+
+	// GetOrder fetches an order by its unique ID.
+	GetOrder(ctx context.Context, id string) (*Order, error)
+
+	// GetOrdersForAccount returns every order placed by the account.
+	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+
+	// ListOrdersByAccountIDs returns the orders of up to MaxBatchSize
+	// accounts keyed by account ID. Every account gets an entry, empty when
+	// it has no orders.
+	ListOrdersByAccountIDs(ctx context.Context, accountIDs []string) (map[string][]Order, error)
+
+	// UpdateOrderStatus moves an order to status and notifies the watchers
+	// of its account. It returns (nil, nil) if the order does not exist.
+	UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error)
+
+	// WatchOrderStatus streams every order of the account as it is placed
+	// or changes status, until ctx is done. The channel is also closed when
+	// the watcher falls too far behind; ctx.Err() is nil in that case.
+	WatchOrderStatus(ctx context.Context, accountID string) <-chan Order
+
+	// DeleteOrder removes an order by ID.
+	DeleteOrder(ctx context.Context, id string) error
+
+	// HasPurchased reports whether the account ordered the product, in an
+	// order that was not cancelled, e.g. to mark reviews as verified.
+	HasPurchased(ctx context.Context, accountID, productID string) (bool, error)
+}
+
+// OrderStatus is the lifecycle stage of an order.
+type OrderStatus string
+
+const (
+	StatusPlaced    OrderStatus = "PLACED"
+	StatusPaid      OrderStatus = "PAID"
+	StatusShipped   OrderStatus = "SHIPPED"
+	StatusDelivered OrderStatus = "DELIVERED"
+	StatusCancelled OrderStatus = "CANCELLED"
+)
+
+// Valid reports whether s is a known status.
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case StatusPlaced, StatusPaid, StatusShipped, StatusDelivered, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Final reports whether an order in status s can no longer change.
+func (s OrderStatus) Final() bool {
+	return s == StatusDelivered || s == StatusCancelled
+}
+
+// AllowedFrom returns the statuses an order can move to s from. Orders only
+// move forward (PLACED, PAID, SHIPPED, DELIVERED) and can be cancelled until
+// they are delivered; nothing moves back to PLACED.
+func (s OrderStatus) AllowedFrom() []OrderStatus {
+	switch s {
+	case StatusPaid:
+		return []OrderStatus{StatusPlaced}
+	case StatusShipped:
+		return []OrderStatus{StatusPlaced, StatusPaid}
+	case StatusDelivered:
+		return []OrderStatus{StatusPlaced, StatusPaid, StatusShipped}
+	case StatusCancelled:
+		return []OrderStatus{StatusPlaced, StatusPaid, StatusShipped}
+	}
+	return nil
+}
+
+// Order is a purchase made by an account. TotalPrice is the exact sum
+// of its lines when it was placed.
+type Order struct {
+	ID         string           `json:"id"`
+	CreatedAt  time.Time        `json:"createdAt"`
+	TotalPrice money.Money      `json:"totalPrice"`
+	AccountID  string           `json:"accountId"`
+	Status     OrderStatus      `json:"status"`
+	Products   []OrderedProduct `json:"products"`
+
+	// The catalog reservation holding the stock of the order, empty for
+	// orders placed before it was recorded
+	ReservationID string `json:"reservationId,omitempty"`
+}
+
+// clone copies o with its own product lines, so the copy can be handed to
+// another goroutine and modified there.
+func (o Order) clone() Order {
+	o.Products = append([]OrderedProduct(nil), o.Products...)
+	return o
+}
+
+// OrderedProduct is a product line inside an order.
+// Name, Description and Options are filled in from the catalog service.
+// Price is the unit price the line was sold at, in the currency of the
+// order, and is stored with it; lines of orders placed before prices were
+// stored get the catalog price instead.
+type OrderedProduct struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       money.Money `json:"price"`
+	Quantity    uint32      `json:"quantity"`
+
+	// Set when Price was converted from the currency of the product: the
+	// catalog price it was converted from and the rate used
+	ConvertedFrom *money.Money `json:"convertedFrom,omitempty"`
+	ExchangeRate  string       `json:"exchangeRate,omitempty"`
+
+	// The variant bought, empty for products ordered without one. SKU is
+	// stored with the order, so it survives the deletion of the variant.
+	VariantID string            `json:"variantId,omitempty"`
+	SKU       string            `json:"sku,omitempty"`
+	Options   map[string]string `json:"options,omitempty"`
+}
+
+// orderService implements the Service interface by interacting with a repository.
+// Status changes are fanned out to the watchers connected to this instance.
+type orderService struct {
+	repository Repository
+	events     *pubsub.Broker[Order]
+}
+
+// NewService constructs a new Service implementation backed by a repository.
+func NewService(r Repository) Service {
+	return &orderService{
+		repository: r,
+		events:     pubsub.NewBroker[Order](pubsub.DefaultBuffer),
+	}
+}
+
+// PostOrder validates the order, totals it and stores it. It is not
+// published yet, since the caller may still delete it again.
+// products must already carry the price each is sold at, all in the
+// currency of the order.
+func (s *orderService) PostOrder(ctx context.Context, accountID, reservationID string, products []OrderedProduct) (*Order, error) {
+	if accountID == "" {
+		return nil, ErrMissingAccount
+	}
+	if len(products) == 0 {
+		return nil, ErrEmptyOrder
+	}
+
+	o := &Order{
+		ID:        ksuid.New().String(),
+		CreatedAt: time.Now().UTC(),
+		AccountID: accountID,
+		Status:    StatusPlaced,
+		Products:  products,
+
+		ReservationID: reservationID,
+	}
+
+	// Calculate the total price from each product line
+	o.TotalPrice = money.Zero(products[0].Price.Currency)
+	for _, p := range products {
+		if p.Quantity == 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if p.Price.Currency != o.TotalPrice.Currency {
+			return nil, ErrMixedCurrencies
+		}
+		line, err := p.Price.Mul(int64(p.Quantity))
+		if err != nil {
+			return nil, err
+		}
+		if o.TotalPrice, err = o.TotalPrice.Add(line); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repository.PutOrder(ctx, *o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// PublishOrder fans a placed order out to its watchers.
+func (s *orderService) PublishOrder(o Order) {
+	s.events.Publish(o.clone())
+}
+
+// GetOrder retrieves an order by ID via the repository.
+func (s *orderService) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return s.repository.GetOrderByID(ctx, id)
+}
+
+// GetOrdersForAccount retrieves the orders of an account via the repository.
+func (s *orderService) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
+	return s.repository.GetOrdersForAccount(ctx, accountID)
+}
+
+// ListOrdersByAccountIDs de-duplicates accountIDs and fetches their orders in one query.
+func (s *orderService) ListOrdersByAccountIDs(ctx context.Context, accountIDs []string) (map[string][]Order, error) {
+	accountIDs = uniqueIDs(accountIDs)
+	if len(accountIDs) > MaxBatchSize {
+		return nil, ErrTooManyIDs
+	}
+
+	byAccount := make(map[string][]Order, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return byAccount, nil
+	}
+	for _, id := range accountIDs {
+		byAccount[id] = []Order{}
+	}
+
+	orders, err := s.repository.GetOrdersForAccounts(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		byAccount[o.AccountID] = append(byAccount[o.AccountID], o)
+	}
+	return byAccount, nil
+}
+
+// UpdateOrderStatus stores the transition and notifies the watchers. The
+// repository only updates the order if it is still in one of the statuses
+// it may move from, so concurrent updates cannot overwrite each other or
+// move an order backwards. When nothing was updated the current order
+// tells why.
+func (s *orderService) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	if !status.Valid() {
+		return nil, ErrInvalidStatus
+	}
+
+	updated, err := s.repository.UpdateOrderStatus(ctx, id, status, status.AllowedFrom())
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := s.repository.GetOrderByID(ctx, id)
+	if err != nil || o == nil {
+		return nil, err
+	}
+	if !updated {
+		switch {
+		case o.Status == status:
+			return o, nil
+		case o.Status.Final():
+			return nil, ErrOrderClosed
+		default:
+			return nil, ErrStatusBackwards
+		}
+	}
+
+	s.events.Publish(o.clone())
+	return o, nil
+}
+
+// WatchOrderStatus subscribes to the orders of accountID.
+func (s *orderService) WatchOrderStatus(ctx context.Context, accountID string) <-chan Order {
+	return s.events.Subscribe(ctx, func(o Order) bool {
+		return o.AccountID == accountID
+	})
+}
+
+// DeleteOrder removes an order by ID.
+func (s *orderService) DeleteOrder(ctx context.Context, id string) error {
+	return s.repository.DeleteOrder(ctx, id)
+}
+
+// HasPurchased asks the repository; empty IDs never match.
+func (s *orderService) HasPurchased(ctx context.Context, accountID, productID string) (bool, error) {
+	if accountID == "" || productID == "" {
+		return false, nil
+	}
+	return s.repository.HasPurchased(ctx, accountID, productID)
+}
+
+// uniqueIDs drops empty and repeated IDs, keeping the first occurrence.
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS orders (
+  id CHAR(27) PRIMARY KEY,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  account_id CHAR(27) NOT NULL,
+  -- Minor units of currency, e.g. cents for USD
+  total_price BIGINT NOT NULL,
+  currency CHAR(3) NOT NULL,
+  status TEXT NOT NULL DEFAULT 'PLACED'
+);
+
+-- Databases created before orders had a status
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'PLACED';
+
+-- Totals used to be NUMERIC(12, 2) dollars. Convert them to cents once,
+-- on databases created before.
+DO $$
+BEGIN
+  IF (SELECT data_type FROM information_schema.columns
+      WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'total_price') = 'numeric' THEN
+    ALTER TABLE orders ALTER COLUMN total_price TYPE BIGINT USING round(total_price * 100);
+    ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+    ALTER TABLE orders ALTER COLUMN currency DROP DEFAULT;
+  END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS orders_account_id_idx ON orders (account_id);
+
+-- The catalog stock reservation committed for the order, returned to stock
+-- when the order is cancelled. NULL on orders placed before it was kept.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reservation_id CHAR(27);
+
+CREATE TABLE IF NOT EXISTS order_products (
+  order_id CHAR(27) NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+  product_id CHAR(27) NOT NULL,
+  quantity INT NOT NULL
+);
+
+-- The variant bought, NULL for products ordered without one. The SKU is
+-- kept so the line stays identifiable after the variant is deleted.
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS variant_id CHAR(27);
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+-- A product can be ordered once per variant; this replaces the primary key
+-- of databases created before variants existed
+ALTER TABLE order_products DROP CONSTRAINT IF EXISTS order_products_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS order_products_line_idx ON order_products (order_id, product_id, COALESCE(variant_id, ''));
+
+-- The unit price a line was sold at, in the currency of the order. When
+-- it was converted, the catalog price it was converted from and the rate.
+-- NULL on lines of orders placed before prices were recorded.
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS price BIGINT;
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS base_price BIGINT;
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS base_currency CHAR(3);
+ALTER TABLE order_products ADD COLUMN IF NOT EXISTS exchange_rate NUMERIC;
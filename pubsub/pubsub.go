@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBuffer is how many events a subscriber may lag behind before it
+// is dropped.
+const DefaultBuffer = 64
+
+// Broker fans events out to the subscribers of this process. Services use
+// it to feed their server-streaming RPCs: the service publishes after a
+// change is stored, every open stream receives the events it matches.
+//
+// Publish never blocks. A subscriber that falls more than its buffer
+// behind is dropped and its channel closed, so one slow client cannot
+// hold up the service; the client is expected to resubscribe.
+type Broker[T any] struct {
+	buffer int
+
+	mu   sync.Mutex
+	subs map[*subscriber[T]]struct{}
+}
+
+type subscriber[T any] struct {
+	match  func(T) bool
+	events chan T
+}
+
+// NewBroker creates a Broker whose subscribers buffer up to buffer events.
+func NewBroker[T any](buffer int) *Broker[T] {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &Broker[T]{
+		buffer: buffer,
+		subs:   map[*subscriber[T]]struct{}{},
+	}
+}
+
+// Subscribe returns a channel receiving every published event for which
+// match returns true (every event when match is nil). The channel is
+// closed when ctx is done or when the subscriber is dropped for being too
+// slow; ctx.Err() tells the two apart.
+func (b *Broker[T]) Subscribe(ctx context.Context, match func(T) bool) <-chan T {
+	s := &subscriber[T]{
+		match:  match,
+		events: make(chan T, b.buffer),
+	}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.remove(s)
+	}()
+
+	return s.events
+}
+
+// Publish delivers event to every matching subscriber without waiting.
+func (b *Broker[T]) Publish(event T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		if s.match != nil && !s.match(event) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			// Buffer full: drop the subscriber rather than the event
+			delete(b.subs, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribers returns how many subscribers are connected.
+func (b *Broker[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Broker[T]) remove(s *subscriber[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.events)
+	}
+}
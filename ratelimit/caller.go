@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Headers (and gRPC metadata keys) used to identify a caller.
+const (
+	CallerHeader       = "x-caller"
+	ForwardedForHeader = "x-forwarded-for"
+)
+
+type callerKeyCtx struct{}
+
+// WithCaller stores an authenticated caller key (e.g. "account:<id>") in ctx.
+// Authentication middleware running before the limiter uses it so that
+// signed-in callers are limited per account rather than per address.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKeyCtx{}, caller)
+}
+
+// CallerFromContext returns the caller key stored by WithCaller.
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKeyCtx{}).(string)
+	return caller
+}
+
+// GRPCCaller identifies the caller of an incoming RPC, in order of preference:
+// the authenticated caller, the caller and client address forwarded by the
+// gateway (if trusted), and finally the peer address.
+//
+// Headers sent by the client itself are never used unless trustForwardedFor
+// is set, since anyone can make them up to get a fresh bucket.
+func GRPCCaller(ctx context.Context, trustForwardedFor bool) string {
+	if caller := CallerFromContext(ctx); caller != "" {
+		return caller
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if trustForwardedFor {
+		if v := firstValue(md, CallerHeader); v != "" {
+			return v
+		}
+		if v := firstHop(firstValue(md, ForwardedForHeader)); v != "" {
+			return "ip:" + v
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return "ip:" + hostOnly(p.Addr.String())
+	}
+
+	return "unknown"
+}
+
+// HTTPCaller identifies the caller of an HTTP request: the authenticated
+// caller stored by WithCaller, otherwise the client address.
+func HTTPCaller(r *http.Request, trustForwardedFor bool) string {
+	if caller := CallerFromContext(r.Context()); caller != "" {
+		return caller
+	}
+	return "ip:" + ClientIP(r, trustForwardedFor)
+}
+
+// ClientIP returns the address of the client that sent r.
+func ClientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if v := firstHop(r.Header.Get(ForwardedForHeader)); v != "" {
+			return v
+		}
+	}
+	return hostOnly(r.RemoteAddr)
+}
+
+// ForwardCaller copies the caller identity of r into the outgoing gRPC
+// metadata of ctx so downstream services rate limit the real client
+// rather than the gateway. The authenticated caller of r, if any, is
+// forwarded alongside the client address.
+func ForwardCaller(ctx context.Context, r *http.Request, trustForwardedFor bool) context.Context {
+	pairs := []string{ForwardedForHeader, ClientIP(r, trustForwardedFor)}
+	if caller := CallerFromContext(r.Context()); caller != "" {
+		pairs = append(pairs, CallerHeader, caller)
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// firstHop returns the original client from "client, proxy1, proxy2".
+func firstHop(forwardedFor string) string {
+	first, _, _ := strings.Cut(forwardedFor, ",")
+	return strings.TrimSpace(first)
+}
+
+// hostOnly strips the port from "host:port".
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
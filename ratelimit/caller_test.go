@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGRPCCaller(t *testing.T) {
+	peerAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 51234}
+
+	tests := []struct {
+		name    string
+		caller  string
+		md      metadata.MD
+		trusted bool
+		want    string
+	}{
+		{"peer", "", nil, false, "ip:10.0.0.2"},
+		{"authenticated", "account:1", metadata.Pairs(CallerHeader, "account:2"), true, "account:1"},
+		{"forwarded caller", "", metadata.Pairs(CallerHeader, "account:2", ForwardedForHeader, "203.0.113.7"), true, "account:2"},
+		{"forwarded address", "", metadata.Pairs(ForwardedForHeader, "203.0.113.7, 10.0.0.1"), true, "ip:203.0.113.7"},
+		{"untrusted forwarded caller", "", metadata.Pairs(CallerHeader, "account:2"), false, "ip:10.0.0.2"},
+		{"untrusted forwarded address", "", metadata.Pairs(ForwardedForHeader, "203.0.113.7"), false, "ip:10.0.0.2"},
+		{"api key", "", metadata.Pairs("x-api-key", "made-up"), false, "ip:10.0.0.2"},
+		{"trusted api key", "", metadata.Pairs("x-api-key", "made-up"), true, "ip:10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: peerAddr})
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			if tt.caller != "" {
+				ctx = WithCaller(ctx, tt.caller)
+			}
+			if got := GRPCCaller(ctx, tt.trusted); got != tt.want {
+				t.Errorf("GRPCCaller() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPCaller(t *testing.T) {
+	tests := []struct {
+		name    string
+		caller  string
+		headers map[string]string
+		trusted bool
+		want    string
+	}{
+		{"remote address", "", nil, false, "ip:192.0.2.1"},
+		{"authenticated", "account:1", nil, false, "account:1"},
+		{"forwarded address", "", map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"}, true, "ip:203.0.113.7"},
+		{"untrusted forwarded address", "", map[string]string{"X-Forwarded-For": "203.0.113.7"}, false, "ip:192.0.2.1"},
+		{"api key", "", map[string]string{"X-Api-Key": "made-up"}, false, "ip:192.0.2.1"},
+		{"caller header", "", map[string]string{"X-Caller": "account:2"}, true, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/graphql", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if tt.caller != "" {
+				r = r.WithContext(WithCaller(r.Context(), tt.caller))
+			}
+			if got := HTTPCaller(r, tt.trusted); got != tt.want {
+				t.Errorf("HTTPCaller() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardCaller(t *testing.T) {
+	tests := []struct {
+		name       string
+		caller     string
+		apiKey     string
+		wantCaller []string
+	}{
+		{"anonymous", "", "", nil},
+		{"authenticated", "account:1", "", []string{"account:1"}},
+		{"api key is not forwarded", "", "made-up", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/graphql", nil)
+			if tt.apiKey != "" {
+				r.Header.Set("X-Api-Key", tt.apiKey)
+			}
+			if tt.caller != "" {
+				r = r.WithContext(WithCaller(r.Context(), tt.caller))
+			}
+
+			md, _ := metadata.FromOutgoingContext(ForwardCaller(context.Background(), r, false))
+			if got := md.Get(ForwardedForHeader); len(got) != 1 || got[0] != "192.0.2.1" {
+				t.Errorf("forwarded address = %q, want [192.0.2.1]", got)
+			}
+			if got := md.Get(CallerHeader); len(got) != len(tt.wantCaller) || (len(got) > 0 && got[0] != tt.wantCaller[0]) {
+				t.Errorf("forwarded caller = %q, want %q", got, tt.wantCaller)
+			}
+			if got := md.Get("x-api-key"); len(got) != 0 {
+				t.Errorf("forwarded api key = %q, want none", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterHeader is the response metadata key carrying the number of
+// seconds a throttled caller should wait.
+const RetryAfterHeader = "retry-after"
+
+// healthService is never throttled: a probe refused for being too frequent
+// would take a healthy instance out of rotation.
+const healthService = "/grpc.health.v1.Health/"
+
+// UnaryServerInterceptor rejects RPCs with codes.ResourceExhausted once the
+// caller has used up its bucket for the method. The status carries a
+// RetryInfo detail and the retry-after header is set as well.
+func UnaryServerInterceptor(l *Limiter, trustForwardedFor bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, info.FullMethod, trustForwardedFor); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor applies the same limit when a stream is opened.
+func StreamServerInterceptor(l *Limiter, trustForwardedFor bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod, trustForwardedFor); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check takes a token for the RPC and builds the rejection status if none is left.
+func (l *Limiter) check(ctx context.Context, fullMethod string, trustForwardedFor bool) error {
+	if strings.HasPrefix(fullMethod, healthService) {
+		return nil
+	}
+
+	method := path.Base(fullMethod)
+
+	ok, retryAfter := l.Allow(method, GRPCCaller(ctx, trustForwardedFor))
+	if ok {
+		return nil
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(RetryAfterSeconds(retryAfter))))
+
+	st := status.Newf(codes.ResourceExhausted, "rate limit exceeded for %s, retry in %s", method, retryAfter.Round(time.Millisecond))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// ServerOptions returns the interceptors enforcing l, ready to be passed to
+// a service's ListenGRPCServer. They are chained after the service's own
+// interceptors, so throttled calls still show up in metrics and traces.
+func ServerOptions(l *Limiter, trustForwardedFor bool) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(l, trustForwardedFor)),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(l, trustForwardedFor)),
+	}
+}
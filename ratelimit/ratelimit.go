@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/time/rate"
+)
+
+// Config controls how many requests a single caller may make.
+// Every method gets its own token bucket per caller. Services embed it as
+// `RateLimit ratelimit.Config envconfig:"RATE_LIMIT"`, so the variables are
+// RATE_LIMIT_ENABLED, RATE_LIMIT_RPS, RATE_LIMIT_BURST, ...
+type Config struct {
+	Enabled bool `envconfig:"ENABLED" default:"true"`
+
+	// Default bucket: RPS tokens are added per second, up to Burst.
+	RPS   float64 `envconfig:"RPS" default:"20" validate:"min=0"`
+	Burst int     `envconfig:"BURST" default:"40" validate:"min=0"`
+
+	// Per-method overrides as "method:rps/burst" pairs, e.g.
+	// "PostAccount:0.2/5,ListAccounts:5/10". Methods are gRPC method names
+	// on the services and root field names on the gateway.
+	Methods map[string]string `envconfig:"METHODS" default:"PostAccount:0.2/5,createAccount:0.2/5"`
+
+	// TrustForwardedFor keys callers on the x-caller and x-forwarded-for
+	// values set by the gateway (or another proxy) instead of the peer
+	// address. Services deployed behind the gateway need it, otherwise every
+	// request through the gateway shares one bucket. Only enable it when the
+	// service is not reachable directly, since clients can forge both values.
+	TrustForwardedFor bool `envconfig:"TRUST_FORWARDED_FOR" default:"false"`
+
+	// MaxCallers bounds how many callers are tracked at once; the least
+	// recently seen caller is forgotten first.
+	MaxCallers int `envconfig:"MAX_CALLERS" default:"100000" validate:"min=1"`
+}
+
+// Validate checks that every per-method rule parses.
+func (c *Config) Validate() error {
+	for method, spec := range c.Methods {
+		if _, err := ParseRule(spec); err != nil {
+			return fmt.Errorf("RATE_LIMIT_METHODS: method %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// Rule is a token bucket definition.
+type Rule struct {
+	RPS   rate.Limit
+	Burst int
+}
+
+// Limiter hands out tokens per (method, caller) pair.
+type Limiter struct {
+	defaultRule Rule
+	rules       map[string]Rule
+
+	mu      sync.Mutex
+	buckets *lru.Cache[string, *rate.Limiter]
+}
+
+// ErrInvalidRule is returned when a per-method rule cannot be parsed.
+var ErrInvalidRule = errors.New("rate limit rule must look like rps/burst")
+
+// New builds a Limiter from cfg. It returns (nil, nil) when rate limiting
+// is disabled; a nil *Limiter allows everything.
+func New(cfg Config) (*Limiter, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	rules := map[string]Rule{}
+	for method, spec := range cfg.Methods {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method, err)
+		}
+		rules[method] = rule
+	}
+
+	maxCallers := cfg.MaxCallers
+	if maxCallers <= 0 {
+		maxCallers = 100000
+	}
+	buckets, err := lru.New[string, *rate.Limiter](maxCallers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limiter{
+		defaultRule: Rule{RPS: rate.Limit(cfg.RPS), Burst: cfg.Burst},
+		rules:       rules,
+		buckets:     buckets,
+	}, nil
+}
+
+// ParseRule parses "rps/burst", e.g. "0.5/10".
+func ParseRule(spec string) (Rule, error) {
+	rps, burst, ok := strings.Cut(strings.TrimSpace(spec), "/")
+	if !ok {
+		return Rule{}, ErrInvalidRule
+	}
+
+	r, err := strconv.ParseFloat(rps, 64)
+	if err != nil || r < 0 {
+		return Rule{}, ErrInvalidRule
+	}
+	b, err := strconv.Atoi(burst)
+	if err != nil || b < 0 {
+		return Rule{}, ErrInvalidRule
+	}
+
+	return Rule{RPS: rate.Limit(r), Burst: b}, nil
+}
+
+// Allow takes one token from the caller's bucket for method.
+// When the bucket is empty it returns false and how long the caller
+// should wait before trying again.
+func (l *Limiter) Allow(method, caller string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+	r := l.bucket(method, caller).ReserveN(now, 1)
+	if !r.OK() {
+		// Burst of 0: the method is closed for everyone
+		return false, time.Minute
+	}
+
+	if delay := r.DelayFrom(now); delay > 0 {
+		// Give the token back, we are rejecting rather than waiting
+		r.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// bucket returns the token bucket of caller for method, creating it on first use.
+func (l *Limiter) bucket(method, caller string) *rate.Limiter {
+	key := method + "|" + caller
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets.Get(key); ok {
+		return b
+	}
+
+	rule, ok := l.rules[method]
+	if !ok {
+		rule = l.defaultRule
+	}
+	b := rate.NewLimiter(rule.RPS, rule.Burst)
+	l.buckets.Add(key, b)
+
+	return b
+}
+
+// RetryAfterSeconds rounds a delay up to whole seconds, the unit used by
+// the HTTP Retry-After header.
+func RetryAfterSeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
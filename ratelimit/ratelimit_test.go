@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Rule
+		err  error
+	}{
+		{"0.2/5", Rule{RPS: 0.2, Burst: 5}, nil},
+		{" 20/40 ", Rule{RPS: 20, Burst: 40}, nil},
+		{"0/0", Rule{}, nil},
+		{"20", Rule{}, ErrInvalidRule},
+		{"/5", Rule{}, ErrInvalidRule},
+		{"-1/5", Rule{}, ErrInvalidRule},
+		{"1/-5", Rule{}, ErrInvalidRule},
+		{"1/2.5", Rule{}, ErrInvalidRule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRule(tt.spec)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseRule(%q) error = %v, want %v", tt.spec, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRule(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	l, err := New(Config{
+		Enabled:    true,
+		RPS:        0.001,
+		Burst:      2,
+		Methods:    map[string]string{"PostAccount": "0.001/1", "Closed": "1/0"},
+		MaxCallers: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		method, caller string
+		want           bool
+	}{
+		{"GetAccount", "ip:1", true},
+		{"GetAccount", "ip:1", true},
+		{"GetAccount", "ip:1", false},
+		// Every caller and every method has its own bucket
+		{"GetAccount", "ip:2", true},
+		{"GetAccount", "account:1", true},
+		{"ListAccounts", "ip:1", true},
+		// Per-method rules override the default
+		{"PostAccount", "ip:1", true},
+		{"PostAccount", "ip:1", false},
+		{"PostAccount", "ip:2", true},
+		{"Closed", "ip:1", false},
+	}
+
+	for i, s := range steps {
+		ok, retryAfter := l.Allow(s.method, s.caller)
+		if ok != s.want {
+			t.Errorf("step %d: Allow(%q, %q) = %v, want %v", i, s.method, s.caller, ok, s.want)
+		}
+		if !ok && retryAfter <= 0 {
+			t.Errorf("step %d: Allow(%q, %q) retry after %s, want a positive delay", i, s.method, s.caller, retryAfter)
+		}
+	}
+}
+
+func TestAllowDisabled(t *testing.T) {
+	l, err := New(Config{Enabled: false, Burst: 0})
+	if err != nil || l != nil {
+		t.Fatalf("New(disabled) = %v, %v, want nil, nil", l, err)
+	}
+	if ok, _ := l.Allow("PostAccount", "ip:1"); !ok {
+		t.Error("nil Limiter refused a call")
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 1},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{time.Second + time.Millisecond, 2},
+		{5 * time.Second, 5},
+	}
+
+	for _, tt := range tests {
+		if got := RetryAfterSeconds(tt.d); got != tt.want {
+			t.Errorf("RetryAfterSeconds(%s) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package accountgraph
+
+type Account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (Account) IsEntity() {}
+
+type AccountByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type PaginationInput struct {
+	Offset *int `json:"offset,omitempty"`
+	Limit  *int `json:"limit,omitempty"`
+}
+
+type Query struct {
+}
@@ -0,0 +1,126 @@
+// Package accountgraph serves the account domain as an Apollo Federation v2
+// subgraph. It owns the Account entity; other subgraphs extend it.
+package accountgraph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/olujimiAdebakin/ProtoGraph/account"
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+)
+
+// defaultPageSize is used when a list query does not ask for a limit.
+const defaultPageSize = 100
+
+// Resolver is the root resolver of the account subgraph.
+type Resolver struct {
+	client *account.Client
+}
+
+// NewSchema returns the executable schema of the account subgraph, with the
+// access directives enforced by the auth package.
+func NewSchema(client *account.Client) graphql.ExecutableSchema {
+	return NewExecutableSchema(Config{
+		Resolvers: &Resolver{client: client},
+		Directives: DirectiveRoot{
+			HasRole: auth.HasRole,
+			Owner: func(ctx context.Context, obj any, next graphql.Resolver, field string) (any, error) {
+				return auth.Owner(ctx, obj, next, field, ownAccount)
+			},
+		},
+	})
+}
+
+// ownAccount is the owner of an account: the account itself.
+func ownAccount(ctx context.Context, id string) (string, error) {
+	return id, nil
+}
+
+// Entity returns the resolver of the router's _entities lookups.
+func (r *Resolver) Entity() EntityResolver {
+	return &entityResolver{r}
+}
+
+// Query returns the QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+
+type entityResolver struct{ *Resolver }
+
+// FindManyAccountByIDs implements EntityResolver.
+// All representations of a request are fetched with one GetAccountsByIDs
+// call; unknown IDs resolve to null.
+func (r *entityResolver) FindManyAccountByIDs(ctx context.Context, reps []*AccountByIDsInput) ([]*Account, error) {
+	ids := make([]string, len(reps))
+	for i, rep := range reps {
+		ids[i] = rep.ID
+	}
+
+	accounts, _, err := r.client.GetAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Account, len(reps))
+	for i, id := range ids {
+		if a, ok := accounts[id]; ok {
+			out[i] = toAccount(&a)
+		}
+	}
+	return out, nil
+}
+
+type queryResolver struct{ *Resolver }
+
+// GetAccount implements QueryResolver.
+func (r *queryResolver) GetAccount(ctx context.Context, id string) (*Account, error) {
+	accounts, _, err := r.client.GetAccountsByIDs(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	a, ok := accounts[id]
+	if !ok {
+		return nil, nil
+	}
+	return toAccount(&a), nil
+}
+
+// ListAccounts implements QueryResolver.
+func (r *queryResolver) ListAccounts(ctx context.Context, pagination *PaginationInput) ([]*Account, error) {
+	skip, take := paginate(pagination)
+	accounts, err := r.client.ListAccounts(ctx, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Account, 0, len(accounts))
+	for i := range accounts {
+		out = append(out, toAccount(&accounts[i]))
+	}
+	return out, nil
+}
+
+// paginate converts the optional GraphQL pagination input to the
+// skip/take pair of the account service.
+func paginate(p *PaginationInput) (skip uint64, take uint64) {
+	take = defaultPageSize
+	if p == nil {
+		return 0, take
+	}
+	if p.Offset != nil && *p.Offset > 0 {
+		skip = uint64(*p.Offset)
+	}
+	if p.Limit != nil && *p.Limit > 0 {
+		take = uint64(*p.Limit)
+	}
+	return skip, take
+}
+
+func toAccount(a *account.Account) *Account {
+	return &Account{
+		ID:   a.ID,
+		Name: a.Name,
+	}
+}
@@ -0,0 +1,52 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package cataloggraph
+
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type MoneyInput struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type PaginationInput struct {
+	Offset *int `json:"offset,omitempty"`
+	Limit  *int `json:"limit,omitempty"`
+}
+
+type Product struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       *Money `json:"price"`
+}
+
+func (Product) IsEntity() {}
+
+type ProductByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type ProductFilters struct {
+	MinPrice *MoneyInput `json:"minPrice,omitempty"`
+	MaxPrice *MoneyInput `json:"maxPrice,omitempty"`
+}
+
+type ProductSearchHit struct {
+	Product              *Product `json:"product"`
+	Rank                 float64  `json:"rank"`
+	NameHighlight        string   `json:"nameHighlight"`
+	DescriptionHighlight string   `json:"descriptionHighlight"`
+}
+
+type ProductSearchResult struct {
+	Hits       []*ProductSearchHit `json:"hits"`
+	TotalCount int                 `json:"totalCount"`
+	Fuzzy      bool                `json:"fuzzy"`
+}
+
+type Query struct {
+}
@@ -0,0 +1,167 @@
+// Package cataloggraph serves the catalog domain as an Apollo Federation v2
+// subgraph. It owns the Product entity that orders reference.
+package cataloggraph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/olujimiAdebakin/ProtoGraph/catalog"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+)
+
+// defaultPageSize is used when a list query does not ask for a limit.
+const defaultPageSize = 100
+
+// Resolver is the root resolver of the catalog subgraph.
+type Resolver struct {
+	client *catalog.Client
+}
+
+// NewSchema returns the executable schema of the catalog subgraph. The
+// catalog is public, so it declares no access directives.
+func NewSchema(client *catalog.Client) graphql.ExecutableSchema {
+	return NewExecutableSchema(Config{
+		Resolvers: &Resolver{client: client},
+	})
+}
+
+// Entity returns the resolver of the router's _entities lookups.
+func (r *Resolver) Entity() EntityResolver {
+	return &entityResolver{r}
+}
+
+// Query returns the QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+
+type entityResolver struct{ *Resolver }
+
+// FindManyProductByIDs implements EntityResolver.
+// All representations of a request, e.g. the products of every line of a
+// list of orders, are fetched with one GetProductsByIDs call; unknown IDs
+// resolve to null.
+func (r *entityResolver) FindManyProductByIDs(ctx context.Context, reps []*ProductByIDsInput) ([]*Product, error) {
+	ids := make([]string, len(reps))
+	for i, rep := range reps {
+		ids[i] = rep.ID
+	}
+
+	products, _, err := r.client.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Product, len(reps))
+	for i, id := range ids {
+		if p, ok := products[id]; ok {
+			out[i] = toProduct(&p)
+		}
+	}
+	return out, nil
+}
+
+type queryResolver struct{ *Resolver }
+
+// GetProduct implements QueryResolver.
+func (r *queryResolver) GetProduct(ctx context.Context, id string) (*Product, error) {
+	products, _, err := r.client.GetProductsByIDs(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	p, ok := products[id]
+	if !ok {
+		return nil, nil
+	}
+	return toProduct(&p), nil
+}
+
+// ListProducts implements QueryResolver.
+func (r *queryResolver) ListProducts(ctx context.Context, pagination *PaginationInput) ([]*Product, error) {
+	skip, take := paginate(pagination)
+	products, err := r.client.ListProducts(ctx, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Product, 0, len(products))
+	for i := range products {
+		out = append(out, toProduct(&products[i]))
+	}
+	return out, nil
+}
+
+// SearchProducts implements QueryResolver.
+func (r *queryResolver) SearchProducts(ctx context.Context, query string, filters *ProductFilters, pagination *PaginationInput) (*ProductSearchResult, error) {
+	skip, take := paginate(pagination)
+
+	var f catalog.SearchFilters
+	if filters != nil {
+		var err error
+		if f.MinPrice, err = moneyInput(filters.MinPrice); err != nil {
+			return nil, err
+		}
+		if f.MaxPrice, err = moneyInput(filters.MaxPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := r.client.SearchProducts(ctx, query, f, skip, take)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ProductSearchResult{
+		Hits:       make([]*ProductSearchHit, 0, len(res.Hits)),
+		TotalCount: int(res.Total),
+		Fuzzy:      res.Fuzzy,
+	}
+	for i := range res.Hits {
+		h := &res.Hits[i]
+		out.Hits = append(out.Hits, &ProductSearchHit{
+			Product:              toProduct(&h.Product),
+			Rank:                 h.Rank,
+			NameHighlight:        h.NameHighlight,
+			DescriptionHighlight: h.DescriptionHighlight,
+		})
+	}
+	return out, nil
+}
+
+// paginate converts the optional GraphQL pagination input to the
+// skip/take pair of the catalog service.
+func paginate(p *PaginationInput) (skip uint64, take uint64) {
+	take = defaultPageSize
+	if p == nil {
+		return 0, take
+	}
+	if p.Offset != nil && *p.Offset > 0 {
+		skip = uint64(*p.Offset)
+	}
+	if p.Limit != nil && *p.Limit > 0 {
+		take = uint64(*p.Limit)
+	}
+	return skip, take
+}
+
+func toProduct(p *catalog.Product) *Product {
+	return &Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       &Money{Amount: p.Price.Decimal(), Currency: p.Price.Currency},
+	}
+}
+
+// moneyInput parses an optional price bound; nil stays nil.
+func moneyInput(in *MoneyInput) (*money.Money, error) {
+	if in == nil {
+		return nil, nil
+	}
+	m, err := money.Parse(in.Amount, in.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,13 @@
+package ordergraph
+
+// Account is the order domain's part of the Account entity. orders holds
+// the orders fetched in bulk by the entity resolver, so resolving the
+// orders of many accounts costs one order-service call.
+type Account struct {
+	ID string `json:"id"`
+
+	orders []*Order
+}
+
+// IsEntity implements fedruntime.Entity.
+func (Account) IsEntity() {}
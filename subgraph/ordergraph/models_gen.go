@@ -0,0 +1,169 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package ordergraph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type AccountByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type Mutation struct {
+}
+
+type Order struct {
+	ID         string            `json:"id"`
+	AccountID  string            `json:"accountId"`
+	Account    *Account          `json:"account"`
+	Status     OrderStatus       `json:"status"`
+	Products   []*OrderedProduct `json:"products"`
+	Quantity   int               `json:"quantity"`
+	TotalPrice *Money            `json:"totalPrice"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+func (Order) IsEntity() {}
+
+type OrderedProduct struct {
+	Product     *Product `json:"product"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       *Money   `json:"price"`
+	Quantity    int      `json:"quantity"`
+}
+
+type Product struct {
+	ID string `json:"id"`
+}
+
+func (Product) IsEntity() {}
+
+type Query struct {
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPlaced    OrderStatus = "PLACED"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPlaced,
+	OrderStatusPaid,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPlaced, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OwnedType string
+
+const (
+	OwnedTypeAccount OwnedType = "ACCOUNT"
+	OwnedTypeOrder   OwnedType = "ORDER"
+)
+
+var AllOwnedType = []OwnedType{
+	OwnedTypeAccount,
+	OwnedTypeOrder,
+}
+
+func (e OwnedType) IsValid() bool {
+	switch e {
+	case OwnedTypeAccount, OwnedTypeOrder:
+		return true
+	}
+	return false
+}
+
+func (e OwnedType) String() string {
+	return string(e)
+}
+
+func (e *OwnedType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OwnedType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OwnedType", str)
+	}
+	return nil
+}
+
+func (e OwnedType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OwnedType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OwnedType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
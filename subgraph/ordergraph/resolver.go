@@ -0,0 +1,206 @@
+// Package ordergraph serves the order domain as an Apollo Federation v2
+// subgraph. It owns the Order entity and extends the Account entity of the
+// account domain with its orders, so Account.orders is resolved here
+// without the account subgraph knowing about orders.
+package ordergraph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/olujimiAdebakin/ProtoGraph/auth"
+	"github.com/olujimiAdebakin/ProtoGraph/money"
+	"github.com/olujimiAdebakin/ProtoGraph/order"
+)
+
+// Resolver is the root resolver of the order subgraph.
+type Resolver struct {
+	client *order.Client
+}
+
+// NewSchema returns the executable schema of the order subgraph, with the
+// access directives enforced by the auth package.
+func NewSchema(client *order.Client) graphql.ExecutableSchema {
+	r := &Resolver{client: client}
+	return NewExecutableSchema(Config{
+		Resolvers: r,
+		Directives: DirectiveRoot{
+			HasRole: auth.HasRole,
+			Owner:   r.ownerDirective,
+		},
+	})
+}
+
+// ownerDirective implements @owner(field:, of:). With of: ORDER the ID is
+// an order's, and the order service tells whose it is.
+func (r *Resolver) ownerDirective(ctx context.Context, obj any, next graphql.Resolver, field string, of *OwnedType) (any, error) {
+	owner := ownAccount
+	if of != nil && *of == OwnedTypeOrder {
+		owner = r.orderOwner
+	}
+	return auth.Owner(ctx, obj, next, field, owner)
+}
+
+// ownAccount is the owner of an account: the account itself.
+func ownAccount(ctx context.Context, id string) (string, error) {
+	return id, nil
+}
+
+// orderOwner returns the account an order belongs to.
+func (r *Resolver) orderOwner(ctx context.Context, id string) (string, error) {
+	o, err := r.client.GetOrder(ctx, id)
+	if status.Code(err) == codes.NotFound {
+		return "", auth.ErrNoOwner
+	}
+	if err != nil {
+		return "", err
+	}
+	return o.AccountID, nil
+}
+
+// Account returns the resolver of the order domain's Account fields.
+func (r *Resolver) Account() AccountResolver {
+	return &accountResolver{r}
+}
+
+// Entity returns the resolver of the router's _entities lookups.
+func (r *Resolver) Entity() EntityResolver {
+	return &entityResolver{r}
+}
+
+// Mutation returns the MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver {
+	return &mutationResolver{r}
+}
+
+// Query returns the QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver {
+	return &queryResolver{r}
+}
+
+type accountResolver struct{ *Resolver }
+
+// Orders implements AccountResolver. Accounts resolved as entities come
+// with their orders; accounts reached through Order.account are fetched here.
+func (r *accountResolver) Orders(ctx context.Context, obj *Account) ([]*Order, error) {
+	if obj.orders != nil {
+		return obj.orders, nil
+	}
+
+	orders, err := r.client.GetOrdersForAccount(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toOrders(orders), nil
+}
+
+type entityResolver struct{ *Resolver }
+
+// FindManyAccountByIDs implements EntityResolver.
+// The orders of all accounts of a request are fetched with one
+// ListOrdersByAccountIDs call. Whether the accounts exist is the account
+// domain's business: an unknown ID simply has no orders.
+func (r *entityResolver) FindManyAccountByIDs(ctx context.Context, reps []*AccountByIDsInput) ([]*Account, error) {
+	ids := make([]string, len(reps))
+	for i, rep := range reps {
+		ids[i] = rep.ID
+	}
+
+	byAccount, err := r.client.ListOrdersByAccountIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Account, len(reps))
+	for i, id := range ids {
+		out[i] = &Account{
+			ID:     id,
+			orders: toOrders(byAccount[id]),
+		}
+	}
+	return out, nil
+}
+
+// FindOrderByID implements EntityResolver.
+// _entities is not a field of Query, so the ownership check of getOrder is
+// repeated here.
+func (r *entityResolver) FindOrderByID(ctx context.Context, id string) (*Order, error) {
+	o, err := r.client.GetOrder(ctx, id)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.AuthorizeOwner(ctx, o.AccountID); err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
+
+type queryResolver struct{ *Resolver }
+
+// GetOrder implements QueryResolver.
+func (r *queryResolver) GetOrder(ctx context.Context, id string) (*Order, error) {
+	o, err := r.client.GetOrder(ctx, id)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
+
+type mutationResolver struct{ *Resolver }
+
+// UpdateOrderStatus implements MutationResolver.
+func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	o, err := r.client.UpdateOrderStatus(ctx, id, order.OrderStatus(status))
+	if err != nil {
+		return nil, err
+	}
+	return toOrder(o), nil
+}
+
+// toOrder maps an order of the order service to the subgraph model. The
+// account and products are entity references the router resolves in the
+// account and catalog subgraphs.
+func toOrder(o *order.Order) *Order {
+	out := &Order{
+		ID:         o.ID,
+		AccountID:  o.AccountID,
+		Account:    &Account{ID: o.AccountID},
+		Status:     OrderStatus(o.Status),
+		TotalPrice: toMoney(o.TotalPrice),
+		CreatedAt:  o.CreatedAt,
+		Products:   []*OrderedProduct{},
+	}
+	for _, p := range o.Products {
+		out.Quantity += int(p.Quantity)
+		out.Products = append(out.Products, &OrderedProduct{
+			Product:     &Product{ID: p.ID},
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       toMoney(p.Price),
+			Quantity:    int(p.Quantity),
+		})
+	}
+	return out
+}
+
+// toMoney maps an amount of the order service to the subgraph model.
+func toMoney(m money.Money) *Money {
+	return &Money{Amount: m.Decimal(), Currency: m.Currency}
+}
+
+func toOrders(orders []order.Order) []*Order {
+	out := make([]*Order, 0, len(orders))
+	for i := range orders {
+		out = append(out, toOrder(&orders[i]))
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Supported values for Config.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// instrumentationName identifies spans created by this package.
+const instrumentationName = "github.com/olujimiAdebakin/ProtoGraph/tracing"
+
+// Config controls where spans are exported to.
+// Services embed it as `Tracing tracing.Config envconfig:"TRACING"`, so the
+// variables are TRACING_EXPORTER, TRACING_OTLP_ENDPOINT and
+// TRACING_SAMPLE_RATIO. Tracing is off unless the exporter is "stdout" or "otlp".
+type Config struct {
+	Exporter     string  `envconfig:"EXPORTER" default:"none" validate:"oneof=none|stdout|otlp"`
+	OTLPEndpoint string  `envconfig:"OTLP_ENDPOINT" default:"localhost:4317" validate:"hostport"`
+	SampleRatio  float64 `envconfig:"SAMPLE_RATIO" default:"1" validate:"min=0,max=1"`
+}
+
+// Init installs the global tracer provider for serviceName and returns a
+// function that flushes pending spans on shutdown.
+//
+// W3C trace context propagation is always enabled, so a service with
+// tracing turned off still forwards the trace of its callers.
+func Init(ctx context.Context, serviceName string, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Tracer returns a named tracer from the global provider.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// ServerOption instruments a gRPC server: it extracts the caller's trace
+// context from the request metadata and opens a server span per RPC.
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler())
+}
+
+// DialOption instruments a gRPC client: it opens a client span per RPC and
+// injects the trace context into the outgoing metadata.
+func DialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler())
+}
+
+// StartDBSpan starts a client span around a single SQL statement.
+// table and operation name the span ("accounts GetAccountByID");
+// statement is recorded as-is, so it must never contain literal values.
+func StartDBSpan(ctx context.Context, table, operation, statement string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, table+" "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.sql.table", table),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+		),
+	)
+}
+
+// EndSpan marks the span as failed when err is non-nil and ends it.
+// It is meant to be deferred with a named error result:
+//
+//	defer func() { tracing.EndSpan(span, err) }()
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}